@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	awsclient "github.com/mattermost/cloud-blackbox-target-discovery/internal/aws"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/fake"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/reconcile"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// benchPhase is the measured cost of a single phase of a benchmark run.
+type benchPhase struct {
+	name      string
+	detail    string
+	duration  time.Duration
+	allocated uint64
+	heap      uint64
+}
+
+// benchCommand runs the discovery pipeline against synthetic hosted zones and
+// reports the time and memory used by each phase.
+func benchCommand(sources *settingSources, args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	publicRecords := flags.Int("public-records", 10000, "number of records in the synthetic public hosted zone")
+	privateRecords := flags.Int("private-records", 1000, "number of records in the synthetic private hosted zone")
+	pageSize := flags.Int("page-size", 300, "number of records returned per Route53 page")
+	templatePath := flags.String("template", "scrapeconfig.yml", "scrape config template to render into")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *publicRecords < 1 || *privateRecords < 0 || *pageSize < 1 {
+		return errors.New("the number of records and the page size must be positive")
+	}
+
+	fmt.Fprintf(os.Stdout, "Generating %d public and %d private records\n", *publicRecords, *privateRecords)
+	const publicZoneID, privateZoneID = "ZBENCHPUBLIC", "ZBENCHPRIVATE"
+	route53API := fake.NewRoute53(fake.SyntheticZones(publicZoneID, *publicRecords, privateZoneID, *privateRecords))
+	route53API.PageSize = *pageSize
+
+	reconciler := reconcile.New(&reconcile.Config{
+		PublicHostedZoneIDs:  []string{publicZoneID},
+		PrivateHostedZoneIDs: []string{privateZoneID},
+		ScrapeConfigTemplate: *templatePath,
+		GRPCProbeModule:      "grpc",
+		LatencyBudgets:       map[string]string{},
+	}, &reconcile.Clients{
+		Records: awsclient.NewClientWithAPIs(route53API, nil),
+	})
+
+	// The pipeline logs every additional target and phase, which would dominate the measurements.
+	level := log.GetLevel()
+	log.SetLevel(log.WarnLevel)
+	defer log.SetLevel(level)
+
+	var phases []benchPhase
+	var before runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	measure := func(name string, duration time.Duration, detail string) {
+		var after runtime.MemStats
+		runtime.ReadMemStats(&after)
+		phases = append(phases, benchPhase{
+			name:      name,
+			detail:    detail,
+			duration:  duration,
+			allocated: after.TotalAlloc - before.TotalAlloc,
+			heap:      after.HeapAlloc,
+		})
+		before = after
+	}
+
+	started := time.Now()
+	report := reconcile.NewReport()
+	report.OnPhase = func(phase reconcile.Phase) {
+		measure(phase.Name, phase.Duration, phase.Detail)
+	}
+	config, _, err := reconciler.GenerateScrapeConfig(report)
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		return errors.New("no targets were discovered")
+	}
+
+	marshalStarted := time.Now()
+	data, err := config.Marshal()
+	if err != nil {
+		return err
+	}
+	measure("Marshal config", time.Since(marshalStarted), fmt.Sprintf("%d bytes", len(data)))
+
+	diffStarted := time.Now()
+	changes, err := render.Diff(nil, config, nil)
+	if err != nil {
+		return err
+	}
+	measure("Diff config", time.Since(diffStarted), fmt.Sprintf("%d changes", len(changes)))
+	total := time.Since(started)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "PHASE\tDETAIL\tDURATION\tALLOCATED\tHEAP\t")
+	for _, phase := range phases {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", phase.name, phase.detail, phase.duration.Round(time.Microsecond), formatBytes(phase.allocated), formatBytes(phase.heap))
+	}
+	fmt.Fprintf(w, "Total\t%d Route53 requests\t%s\t\t\t\n", route53API.Calls(), total.Round(time.Microsecond))
+	w.Flush()
+
+	return nil
+}
+
+// formatBytes formats a byte count with a binary unit.
+func formatBytes(bytes uint64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := uint64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+const (
+	colorReset  = "\x1b[0m"
+	colorBold   = "\x1b[1m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorCyan   = "\x1b[36m"
+)
+
+// useColor reports whether output written to the file should be colorized.
+// Color is disabled by the --no-color flag, the NO_COLOR environment variable
+// and when the output is not a terminal.
+func useColor(f *os.File, noColor bool) bool {
+	return !noColor && len(os.Getenv("NO_COLOR")) == 0 && isTerminal(f)
+}
+
+// colorize wraps the text in the given color if color is enabled.
+func colorize(enabled bool, color, text string) string {
+	if !enabled || len(text) == 0 {
+		return text
+	}
+
+	return color + text + colorReset
+}
+
+// colorizeDiff colors the file headers, hunk headers, removed and added lines of a unified diff.
+func colorizeDiff(enabled bool, diff string) string {
+	if !enabled {
+		return diff
+	}
+
+	lines := strings.SplitAfter(diff, "\n")
+	for i, line := range lines {
+		text := strings.TrimSuffix(line, "\n")
+		newline := line[len(text):]
+		switch {
+		case strings.HasPrefix(text, "+++"), strings.HasPrefix(text, "---"):
+			lines[i] = colorize(enabled, colorBold, text) + newline
+		case strings.HasPrefix(text, "@@"):
+			lines[i] = colorize(enabled, colorCyan, text) + newline
+		case strings.HasPrefix(text, "+"):
+			lines[i] = colorize(enabled, colorGreen, text) + newline
+		case strings.HasPrefix(text, "-"):
+			lines[i] = colorize(enabled, colorRed, text) + newline
+		}
+	}
+
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/reconcile"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/version"
+	"github.com/pkg/errors"
+)
+
+// command describes a subcommand for the usage output and shell completion.
+type command struct {
+	name  string
+	usage string
+	flags []string
+}
+
+// commands lists the subcommands together with their flags.
+var commands = []command{
+	{"plan", "write the changes a discovery would make to a plan file", []string{"out", "diff", "no-color"}},
+	{"apply", "apply a previously created plan", []string{"plan", "no-color"}},
+	{"history", "show when targets entered or left monitoring", []string{"target", "json", "no-color"}},
+	{"migrate", "convert the Prometheus secret to the current format and optionally move it", []string{"to-namespace", "to-secret", "delete-source", "dry-run"}},
+	{"serve", "serve the discovered targets in the Prometheus HTTP service discovery format", []string{"listen", "refresh"}},
+	{"snapshot", "record the records of all hosted zones to a snapshot file", []string{"out"}},
+	{"simulate", "run the discovery against a recorded snapshot without contacting AWS or Kubernetes", []string{"snapshot", "config-out", "json"}},
+	{"bench", "measure the time and memory of each phase against synthetic hosted zones", []string{"public-records", "private-records", "page-size", "template"}},
+	{"selftest", "verify AWS, Kubernetes and webhook access and the scrape config template", []string{"no-color"}},
+	{"validate", "validate the configuration and scrape config template without contacting AWS or Kubernetes", []string{"repair", "out"}},
+	{"completion", "print the bash, zsh or fish completion script", nil},
+	{"version", "print the build metadata", nil},
+}
+
+// offlineCommands are the subcommands that run without validated configuration or clients.
+var offlineCommands = map[string]func(sources *settingSources, args []string) error{
+	"validate":   validateCommand,
+	"simulate":   simulateCommand,
+	"bench":      benchCommand,
+	"version":    versionCommand,
+	"completion": completionCommand,
+	"__complete": completeCommand,
+}
+
+// runCommand runs one of the Blackbox target discovery subcommands.
+func runCommand(reconciler *reconcile.Reconciler, command string, args []string) error {
+	switch command {
+	case "plan":
+		return planCommand(reconciler, args)
+	case "apply":
+		return applyCommand(reconciler, args)
+	case "selftest":
+		return selfTestCommand(reconciler, args)
+	case "snapshot":
+		return snapshotCommand(reconciler, args)
+	case "history":
+		return historyCommand(reconciler, args)
+	case "migrate":
+		return migrateCommand(reconciler, args)
+	case "serve":
+		return serveCommand(reconciler, args)
+	}
+
+	return errors.Errorf("unknown command %s", command)
+}
+
+// versionCommand prints the build metadata of the binary.
+func versionCommand(sources *settingSources, args []string) error {
+	fmt.Fprintln(os.Stdout, version.Get().String())
+	return nil
+}
+
+// validateCommand validates the configuration, the scrape config template and
+// the filter and sink settings without contacting AWS or Kubernetes, and
+// reports every problem found at once.
+func validateCommand(sources *settingSources, args []string) error {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	repair := flags.Bool("repair", false, "regenerate the broken sections of the scrape config template")
+	out := flags.String("out", "", "file to write the repaired template to (default the template itself)")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	var problems configProblems
+	config, err := validateAndGetEnvVars(sources)
+	if err != nil {
+		if configErrors, ok := err.(configProblems); ok {
+			problems = append(problems, configErrors...)
+		} else {
+			problems = append(problems, err)
+		}
+	}
+
+	if config != nil && *repair {
+		problems = append(problems, repairTemplate(config, *out)...)
+	} else if config != nil {
+		problems = append(problems, validateTemplate(config)...)
+	}
+
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stdout, "Found %d configuration problems:\n", len(problems))
+		for _, problem := range problems {
+			fmt.Fprintf(os.Stdout, "  - %s\n", problem)
+		}
+		return errors.Errorf("configuration is invalid")
+	}
+
+	fmt.Fprintln(os.Stdout, "Configuration is valid.")
+
+	return nil
+}
+
+// validateTemplate checks that the scrape config templates provide every job
+// the configuration renders targets into.
+func validateTemplate(config *reconcile.Config) []error {
+	template, err := config.LoadTemplates()
+	if err != nil {
+		return []error{err}
+	}
+
+	return render.ValidateTemplate(template, config.TemplateRequirements())
+}
+
+// repairTemplate repairs the scrape config template, writes the result if
+// anything was repaired and returns the problems left after the repair.
+func repairTemplate(config *reconcile.Config, out string) []error {
+	if render.IsGoTemplate(config.ScrapeConfigTemplate) {
+		return []error{errors.Errorf("SCRAPE_CONFIG_TEMPLATE %s is a Go template and cannot be repaired", config.ScrapeConfigTemplate)}
+	}
+	template, err := render.Load(config.ScrapeConfigTemplate)
+	if err != nil {
+		return []error{errors.Wrapf(err, "SCRAPE_CONFIG_TEMPLATE %s is invalid", config.ScrapeConfigTemplate)}
+	}
+
+	requirements := config.TemplateRequirements()
+	repaired, repairs := render.RepairTemplate(template, requirements)
+	if len(repairs) == 0 {
+		return render.ValidateTemplate(repaired, requirements)
+	}
+
+	data, err := repaired.Marshal()
+	if err != nil {
+		return []error{err}
+	}
+	if len(out) == 0 {
+		out = config.ScrapeConfigTemplate
+	}
+	err = ioutil.WriteFile(out, data, 0644)
+	if err != nil {
+		return []error{errors.Wrapf(err, "failed to write the repaired template to %s", out)}
+	}
+
+	fmt.Fprintf(os.Stdout, "Repaired the scrape config template and wrote it to %s:\n", out)
+	for _, repair := range repairs {
+		fmt.Fprintf(os.Stdout, "  - %s\n", repair)
+	}
+
+	return render.ValidateTemplate(repaired, requirements)
+}
+
+// selfTestCommand runs the self-test checks and fails if any of them fails,
+// so it can be used as a container health check or deployment smoke test.
+func selfTestCommand(reconciler *reconcile.Reconciler, args []string) error {
+	flags := flag.NewFlagSet("selftest", flag.ContinueOnError)
+	noColor := flags.Bool("no-color", false, "disable colorized output")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	color := useColor(os.Stdout, *noColor)
+	failed := 0
+	for _, check := range reconciler.SelfTest() {
+		if check.OK {
+			fmt.Fprintf(os.Stdout, "%s   %s (%s)\n", colorize(color, colorGreen, "ok"), check.Name, check.Duration.Round(time.Millisecond))
+			continue
+		}
+		failed++
+		fmt.Fprintf(os.Stdout, "%s %s: %s\n", colorize(color, colorRed, "FAIL"), check.Name, check.Error)
+	}
+	if failed > 0 {
+		return errors.Errorf("%d self-test checks failed", failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/k8s"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/reconcile"
+	"github.com/pkg/errors"
+)
+
+// completionScripts are the shell completion scripts. They delegate to the
+// hidden __complete command, so completions always match the binary.
+var completionScripts = map[string]string{
+	"bash": `_%[1]s_complete() {
+    local IFS=$'\n'
+    COMPREPLY=($(%[1]s __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2> /dev/null))
+}
+complete -o default -F _%[1]s_complete %[1]s
+`,
+	"zsh": `#compdef %[1]s
+_%[1]s() {
+    local -a candidates
+    candidates=("${(@f)$(%[1]s __complete "${(@)words[2,$CURRENT]}" 2> /dev/null)}")
+    compadd -- $candidates
+}
+compdef _%[1]s %[1]s
+`,
+	"fish": `complete -c %[1]s -f -a '(%[1]s __complete (commandline -opc)[2..-1] (commandline -ct) 2> /dev/null)'
+`,
+}
+
+// settingValues returns the possible values of settings with a known set of values.
+var settingValues = map[string]func() []string{
+	"TICKET_PROVIDER":      func() []string { return []string{"jira", "github"} },
+	"DEVELOPER_MODE":       func() []string { return []string{"true", "false"} },
+	"ASSUME_YES":           func() []string { return []string{"true", "false"} },
+	"DRY_RUN":              func() []string { return []string{"true", "false"} },
+	"NOTIFY_ON_SUCCESS":    func() []string { return []string{"true", "false"} },
+	"DISCOVER_INGRESSES":   func() []string { return []string{"true", "false"} },
+	"DISCOVER_SERVICES":    func() []string { return []string{"true", "false"} },
+	"DISCOVER_SRV_RECORDS": func() []string { return []string{"true", "false"} },
+	"FORCE_APPLY":          func() []string { return []string{"true", "false"} },
+	"FORCE_CONFLICTS":      func() []string { return []string{"true", "false"} },
+	"SECRET_CHUNKING":      func() []string { return []string{"true", "false"} },
+	"OPSGENIE_PRIORITY":    func() []string { return []string{"P1", "P2", "P3", "P4", "P5"} },
+	"LOG_FORMAT":           func() []string { return []string{"text", "json"} },
+	"LOG_LEVEL": func() []string {
+		return []string{"panic", "fatal", "error", "warn", "info", "debug", "trace"}
+	},
+	"OUTPUT_S3_FORMAT": func() []string {
+		return []string{reconcile.S3FormatScrapeConfig, reconcile.S3FormatFileSD}
+	},
+	"OUTPUT_KIND": func() []string {
+		return []string{reconcile.OutputSecret, reconcile.OutputConfigMap, reconcile.OutputProbe, reconcile.OutputScrapeConfig}
+	},
+	"IP_VERSION": func() []string {
+		return []string{discovery.IPVersion4, discovery.IPVersion6, discovery.IPVersionBoth}
+	},
+	"MAINTENANCE_ACTION": func() []string {
+		return []string{discovery.MaintenanceActionLabel, discovery.MaintenanceActionRemove}
+	},
+	"KUBE_CONTEXT": func() []string {
+		contexts, _ := k8s.ListContexts()
+		return contexts
+	},
+}
+
+// completionCommand prints the completion script of a shell.
+func completionCommand(sources *settingSources, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: completion bash|zsh|fish")
+	}
+	script, ok := completionScripts[args[0]]
+	if !ok {
+		return errors.Errorf("unsupported shell %s, use bash, zsh or fish", args[0])
+	}
+
+	fmt.Fprintf(os.Stdout, script, filepath.Base(os.Args[0]))
+
+	return nil
+}
+
+// completeCommand prints the completion candidates for the given words, the
+// last of which is the word being completed.
+func completeCommand(sources *settingSources, args []string) error {
+	for _, candidate := range completions(sources, args) {
+		fmt.Fprintln(os.Stdout, candidate)
+	}
+
+	return nil
+}
+
+func completions(sources *settingSources, words []string) []string {
+	current := ""
+	if len(words) > 0 {
+		current = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	// bash splits --flag=value into three words.
+	if current == "=" && len(words) > 0 {
+		return withPrefix(flagValues(words[len(words)-1]), "")
+	}
+	if len(words) > 1 && words[len(words)-1] == "=" {
+		return withPrefix(flagValues(words[len(words)-2]), current)
+	}
+
+	if strings.HasPrefix(current, "--") && strings.Contains(current, "=") {
+		parts := strings.SplitN(current, "=", 2)
+		var candidates []string
+		for _, value := range withPrefix(flagValues(parts[0]), parts[1]) {
+			candidates = append(candidates, parts[0]+"="+value)
+		}
+		return candidates
+	}
+	if len(words) > 0 {
+		if values := flagValues(words[len(words)-1]); values != nil && !strings.Contains(words[len(words)-1], "=") {
+			return withPrefix(values, current)
+		}
+	}
+
+	var subcommand *command
+	for _, word := range words {
+		for i := range commands {
+			if commands[i].name == word {
+				subcommand = &commands[i]
+			}
+		}
+	}
+
+	var candidates []string
+	switch {
+	case subcommand != nil && subcommand.name == "completion":
+		for shell := range completionScripts {
+			candidates = append(candidates, shell)
+		}
+	case subcommand != nil:
+		for _, name := range subcommand.flags {
+			candidates = append(candidates, "--"+name)
+		}
+	case strings.HasPrefix(current, "-"):
+		for _, s := range settings {
+			candidates = append(candidates, "--"+flagName(s.env))
+		}
+		candidates = append(candidates, "--config", "--version", "--help")
+	default:
+		for _, c := range commands {
+			candidates = append(candidates, c.name)
+		}
+	}
+	sort.Strings(candidates)
+
+	return withPrefix(candidates, current)
+}
+
+// flagValues returns the possible values of a global flag, or nil if they are not known.
+func flagValues(flag string) []string {
+	name := strings.TrimLeft(flag, "-")
+	for _, s := range settings {
+		if flagName(s.env) == name {
+			if values, ok := settingValues[s.env]; ok {
+				return values()
+			}
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func withPrefix(candidates []string, prefix string) []string {
+	var matching []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			matching = append(matching, candidate)
+		}
+	}
+
+	return matching
+}
@@ -0,0 +1,891 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/reconcile"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/retry"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/schedule"
+	"github.com/pkg/errors"
+)
+
+// validateEnvironmentVariables is used to validate the settings needed by Blackbox target discovery.
+// Each setting is read from its flag or, if the flag was not given, its environment variable.
+// All invalid settings are reported at once, together with the partially validated configuration.
+func validateAndGetEnvVars(sources *settingSources) (*reconcile.Config, error) {
+	envVars := &reconcile.Config{}
+	var problems configProblems
+
+	// In orchestration mode the hosted zones and secrets are set per run by the orchestration file.
+	envVars.OrchestrationConfig = sources.get("ORCHESTRATION_CONFIG")
+	orchestrated := len(envVars.OrchestrationConfig) > 0
+
+	// Hosted zones carrying the tag are discovered in addition to the configured zones.
+	hostedZoneTag := sources.get("HOSTED_ZONE_TAG")
+	if len(hostedZoneTag) > 0 {
+		parts := strings.SplitN(hostedZoneTag, "=", 2)
+		envVars.HostedZoneTagKey = strings.TrimSpace(parts[0])
+		if len(parts) == 2 {
+			envVars.HostedZoneTagValue = strings.TrimSpace(parts[1])
+		}
+		if len(envVars.HostedZoneTagKey) == 0 {
+			problems = append(problems, errors.Errorf("HOSTED_ZONE_TAG %q must be a tag key, optionally followed by =value", hostedZoneTag))
+		}
+	}
+	discoverZones := len(hostedZoneTag) > 0
+
+	// Cloudflare zones are discovered like public hosted zones.
+	envVars.CloudflareZoneIDs = reconcile.ParseHostedZoneIDs(sources.get("CLOUDFLARE_ZONE_IDS"))
+	envVars.CloudflareAPIToken = sources.get("CLOUDFLARE_API_TOKEN")
+	if len(envVars.CloudflareZoneIDs) > 0 && len(envVars.CloudflareAPIToken) == 0 {
+		problems = append(problems, errors.Errorf("CLOUDFLARE_API_TOKEN environment variable must be set when CLOUDFLARE_ZONE_IDS is set"))
+	}
+
+	// Cloud DNS managed zones are discovered like public hosted zones.
+	envVars.GCPDNSZones = reconcile.ParseHostedZoneIDs(sources.get("GCP_DNS_ZONES"))
+	envVars.GCPProject = sources.get("GCP_PROJECT")
+	envVars.GCPCredentialsFile = sources.get("GCP_CREDENTIALS_FILE")
+	if len(envVars.GCPDNSZones) > 0 && len(envVars.GCPProject) == 0 {
+		problems = append(problems, errors.Errorf("GCP_PROJECT environment variable must be set when GCP_DNS_ZONES is set"))
+	}
+
+	// Azure DNS zones are discovered like public hosted zones.
+	envVars.AzureDNSZones = reconcile.ParseHostedZoneIDs(sources.get("AZURE_DNS_ZONES"))
+	envVars.AzureSubscriptionID = sources.get("AZURE_SUBSCRIPTION_ID")
+	envVars.AzureResourceGroup = sources.get("AZURE_RESOURCE_GROUP")
+	envVars.AzureClientID = sources.get("AZURE_CLIENT_ID")
+	if len(envVars.AzureDNSZones) > 0 && (len(envVars.AzureSubscriptionID) == 0 || len(envVars.AzureResourceGroup) == 0) {
+		problems = append(problems, errors.Errorf("AZURE_SUBSCRIPTION_ID and AZURE_RESOURCE_GROUP environment variables must be set when AZURE_DNS_ZONES is set"))
+	}
+
+	// Zones transferred from the BIND servers are discovered like public hosted zones.
+	envVars.BindZones = reconcile.ParseHostedZoneIDs(sources.get("BIND_ZONES"))
+	if len(envVars.BindZones) > 0 && len(sources.get("BIND_SERVERS")) == 0 {
+		problems = append(problems, errors.Errorf("BIND_SERVERS environment variable must be set when BIND_ZONES is set"))
+	}
+
+	publicHostedZoneIDs := reconcile.ParseHostedZoneIDs(sources.get("PUBLIC_HOSTED_ZONE_ID"))
+	if len(publicHostedZoneIDs) == 0 && len(envVars.PublicZoneIDs()) == 0 && !orchestrated && !discoverZones {
+		problems = append(problems, errors.Errorf("PUBLIC_HOSTED_ZONE_ID environment variable is not set"))
+	}
+	envVars.PublicHostedZoneIDs = publicHostedZoneIDs
+
+	privateHostedZoneIDs := reconcile.ParseHostedZoneIDs(sources.get("PRIVATE_HOSTED_ZONE_ID"))
+	if len(privateHostedZoneIDs) == 0 && !orchestrated && !discoverZones {
+		problems = append(problems, errors.Errorf("PRIVATE_HOSTED_ZONE_ID environment variable is not set"))
+	}
+	envVars.PrivateHostedZoneIDs = privateHostedZoneIDs
+
+	prometheusNamespace := sources.get("PROMETHEUS_NAMESPACE")
+	if len(prometheusNamespace) == 0 && !orchestrated {
+		problems = append(problems, errors.Errorf("PROMETHEUS_NAMESPACE environment variable is not set"))
+	}
+	envVars.PrometheusNamespace = prometheusNamespace
+
+	excludedTargets := sources.get("EXCLUDED_TARGETS")
+	if len(excludedTargets) > 0 {
+		envVars.ExcludedTargets = strings.Split(excludedTargets, ",")
+		for _, target := range envVars.ExcludedTargets {
+			err := discovery.ValidateTargetPattern(target)
+			if err != nil {
+				problems = append(problems, errors.Wrap(err, "EXCLUDED_TARGETS is invalid"))
+			}
+		}
+	}
+
+	includedTargetPatterns := sources.get("INCLUDED_TARGET_PATTERNS")
+	if len(includedTargetPatterns) > 0 {
+		envVars.IncludedTargetPatterns = strings.Split(includedTargetPatterns, ",")
+		for _, pattern := range envVars.IncludedTargetPatterns {
+			err := discovery.ValidateTargetPattern(pattern)
+			if err != nil {
+				problems = append(problems, errors.Wrap(err, "INCLUDED_TARGET_PATTERNS is invalid"))
+			}
+		}
+	}
+
+	targetURLTemplate := sources.get("TARGET_URL_TEMPLATE")
+	if len(targetURLTemplate) == 0 {
+		targetURLTemplate = discovery.DefaultTargetURLTemplate
+	}
+	tmpl, err := discovery.ParseTargetURLTemplate(targetURLTemplate)
+	if err != nil {
+		problems = append(problems, errors.Wrap(err, "TARGET_URL_TEMPLATE environment variable is invalid"))
+	}
+	envVars.TargetURLTemplate = tmpl
+
+	targetURLRules := sources.get("TARGET_URL_RULES")
+	if len(targetURLRules) > 0 {
+		rules, err := discovery.ParseTargetURLRules(targetURLRules)
+		if err != nil {
+			problems = append(problems, errors.Wrap(err, "TARGET_URL_RULES environment variable is invalid"))
+		}
+		envVars.TargetURLRules = rules
+	}
+
+	jobRules := sources.get("JOB_RULES")
+	if len(jobRules) > 0 {
+		rules, err := discovery.ParseJobRules(jobRules)
+		if err != nil {
+			problems = append(problems, errors.Wrap(err, "JOB_RULES environment variable is invalid"))
+		}
+		envVars.JobRules = rules
+	}
+
+	moduleRules := sources.get("MODULE_RULES")
+	if len(moduleRules) > 0 {
+		patterns, err := discovery.ParseModulePatterns(moduleRules)
+		if err != nil {
+			problems = append(problems, errors.Wrap(err, "MODULE_RULES environment variable is invalid"))
+		}
+		envVars.ModulePatterns = patterns
+	}
+
+	recordTypes, err := discovery.ParseRecordTypes(sources.get("RECORD_TYPES"))
+	if err != nil {
+		problems = append(problems, errors.Wrap(err, "RECORD_TYPES environment variable is invalid"))
+	}
+	envVars.RecordTypes = recordTypes
+	recordNamePrefixes := sources.get("RECORD_NAME_PREFIXES")
+	if len(recordNamePrefixes) > 0 {
+		envVars.RecordNamePrefixes = strings.Split(recordNamePrefixes, ",")
+	}
+
+	envVars.IPVersion = sources.get("IP_VERSION")
+	switch envVars.IPVersion {
+	case "":
+		envVars.IPVersion = discovery.IPVersionBoth
+	case discovery.IPVersion4, discovery.IPVersion6, discovery.IPVersionBoth:
+	default:
+		problems = append(problems, errors.Errorf("IP_VERSION environment variable must be one of ipv4, ipv6 or both"))
+	}
+
+	targetLabels, err := discovery.ParseTargetLabels(sources.get("TARGET_LABELS"))
+	if err != nil {
+		problems = append(problems, errors.Wrap(err, "TARGET_LABELS environment variable is invalid"))
+	}
+	envVars.TargetLabels = targetLabels
+	envVars.Environment = sources.get("ENVIRONMENT")
+	for _, label := range envVars.TargetLabels {
+		if label == discovery.EnvironmentLabel && len(envVars.Environment) == 0 {
+			problems = append(problems, errors.Errorf("ENVIRONMENT environment variable must be set when TARGET_LABELS contains %s", discovery.EnvironmentLabel))
+		}
+	}
+
+	privateRecordRules := sources.get("PRIVATE_RECORD_RULES")
+	if len(privateRecordRules) > 0 {
+		rules, err := discovery.ParsePrivateRecordRules(privateRecordRules)
+		if err != nil {
+			problems = append(problems, errors.Wrap(err, "PRIVATE_RECORD_RULES environment variable is invalid"))
+		}
+		envVars.PrivateRecordRules = rules
+	}
+
+	additionalTargets := sources.get("ADDITIONAL_TARGETS")
+	if len(additionalTargets) > 0 {
+		envVars.AdditionalTargets = strings.Split(additionalTargets, ",")
+	}
+
+	prometheusSecretName := sources.get("PROMETHEUS_SECRET_NAME")
+	if len(prometheusSecretName) == 0 && !orchestrated {
+		problems = append(problems, errors.Errorf("PROMETHEUS_SECRET_NAME environment variable is not set."))
+	}
+	envVars.PrometheusSecretName = prometheusSecretName
+
+	envVars.ScrapeConfigKey = sources.get("SECRET_KEY")
+	if len(envVars.ScrapeConfigKey) == 0 {
+		envVars.ScrapeConfigKey = reconcile.ScrapeConfigSecretKey
+	}
+	secretKeyRules := sources.get("SECRET_KEY_RULES")
+	if len(secretKeyRules) > 0 {
+		rules, err := reconcile.ParseSecretKeyRules(secretKeyRules)
+		if err != nil {
+			problems = append(problems, errors.Wrap(err, "SECRET_KEY_RULES environment variable is invalid"))
+		}
+		envVars.SecretKeyRules = rules
+	}
+	err = reconcile.ValidateSecretKeys(envVars.ScrapeConfigKey, envVars.SecretKeyRules)
+	if err != nil {
+		problems = append(problems, errors.Wrap(err, "SECRET_KEY or SECRET_KEY_RULES environment variable is invalid"))
+	}
+	outputSecrets := sources.get("OUTPUT_SECRETS")
+	if len(outputSecrets) > 0 {
+		secrets, err := reconcile.ParseOutputSecrets(outputSecrets, prometheusSecretName)
+		if err != nil {
+			problems = append(problems, errors.Wrap(err, "OUTPUT_SECRETS environment variable is invalid"))
+		}
+		envVars.OutputSecrets = secrets
+	}
+
+	envVars.SecretChunking = sources.get("SECRET_CHUNKING") == "true"
+	envVars.SecretMountPath = sources.get("SECRET_MOUNT_PATH")
+	if len(envVars.SecretMountPath) == 0 {
+		envVars.SecretMountPath = reconcile.DefaultSecretMountPath
+	}
+	envVars.SecretSizeWarnPercent = reconcile.DefaultSecretSizeWarnPercent
+	secretSizeWarnPercent := sources.get("SECRET_SIZE_WARN_PERCENT")
+	if len(secretSizeWarnPercent) > 0 {
+		percent, err := strconv.Atoi(secretSizeWarnPercent)
+		if err != nil || percent < 1 || percent > 100 {
+			problems = append(problems, errors.Errorf("SECRET_SIZE_WARN_PERCENT environment variable must be a percentage between 1 and 100"))
+		}
+		envVars.SecretSizeWarnPercent = percent
+	}
+
+	mattermostAlertsHook := sources.get("MATTERMOST_ALERTS_HOOK")
+	if len(mattermostAlertsHook) == 0 {
+		problems = append(problems, errors.Errorf("MATTERMOST_ALERTS_HOOK environment variable is not set."))
+	}
+	envVars.MattermostAlertsHook = mattermostAlertsHook
+
+	_, err = logFormatter(sources.get("LOG_FORMAT"))
+	if err != nil {
+		problems = append(problems, err)
+	}
+	_, err = logLevel(sources.get("LOG_LEVEL"))
+	if err != nil {
+		problems = append(problems, err)
+	}
+
+	envVars.NotifyOnSuccess = sources.get("NOTIFY_ON_SUCCESS") == "true"
+	envVars.SlackWebhookURL = sources.get("SLACK_WEBHOOK_URL")
+	envVars.NotifyWebhookURL = sources.get("NOTIFY_WEBHOOK_URL")
+	envVars.NotifyWebhookTemplate = sources.get("NOTIFY_WEBHOOK_TEMPLATE")
+	if len(envVars.NotifyWebhookTemplate) > 0 {
+		if len(envVars.NotifyWebhookURL) == 0 {
+			problems = append(problems, errors.Errorf("NOTIFY_WEBHOOK_URL environment variable must be set when NOTIFY_WEBHOOK_TEMPLATE is set"))
+		}
+		_, err := newWebhookNotifier(envVars.NotifyWebhookURL, envVars.NotifyWebhookTemplate)
+		if err != nil {
+			problems = append(problems, errors.Wrap(err, "NOTIFY_WEBHOOK_TEMPLATE environment variable is invalid"))
+		}
+	}
+
+	developerMode := sources.get("DEVELOPER_MODE")
+	if len(developerMode) == 0 {
+		envVars.DevMode = "false"
+	} else {
+		envVars.DevMode = developerMode
+	}
+
+	bindServers := sources.get("BIND_SERVERS")
+	if len(bindServers) > 0 {
+		envVars.BindServers = strings.Split(bindServers, ",")
+	}
+
+	serviceNowURL := sources.get("SERVICENOW_URL")
+	if len(serviceNowURL) > 0 {
+		envVars.ServiceNowURL = strings.TrimSuffix(serviceNowURL, "/")
+		envVars.ServiceNowUsername = sources.get("SERVICENOW_USERNAME")
+		envVars.ServiceNowPassword = sources.get("SERVICENOW_PASSWORD")
+		if len(envVars.ServiceNowUsername) == 0 || len(envVars.ServiceNowPassword) == 0 {
+			problems = append(problems, errors.Errorf("SERVICENOW_USERNAME and SERVICENOW_PASSWORD environment variables must be set when SERVICENOW_URL is set"))
+		}
+		envVars.ServiceNowTable = sources.get("SERVICENOW_TABLE")
+		if len(envVars.ServiceNowTable) == 0 {
+			envVars.ServiceNowTable = "cmdb_ci_endpoint"
+		}
+	}
+
+	envVars.ScrapeConfigTemplate = sources.get("SCRAPE_CONFIG_TEMPLATE")
+	if len(envVars.ScrapeConfigTemplate) == 0 {
+		envVars.ScrapeConfigTemplate = "scrapeconfig.yml"
+	}
+
+	envVars.ChangeWebhookURL = sources.get("CHANGE_WEBHOOK_URL")
+
+	envVars.InventoryS3Bucket = sources.get("INVENTORY_S3_BUCKET")
+	envVars.InventoryS3Prefix = sources.get("INVENTORY_S3_PREFIX")
+	if len(envVars.InventoryS3Prefix) == 0 {
+		envVars.InventoryS3Prefix = "blackbox-target-inventory"
+	}
+
+	inventoryRetentionDays := sources.get("INVENTORY_RETENTION_DAYS")
+	if len(inventoryRetentionDays) > 0 {
+		days, err := strconv.Atoi(inventoryRetentionDays)
+		if err != nil || days < 0 {
+			problems = append(problems, errors.Errorf("INVENTORY_RETENTION_DAYS environment variable must be a non-negative number"))
+		}
+		envVars.InventoryRetentionDays = days
+	}
+
+	minTargets := sources.get("MIN_TARGETS")
+	if len(minTargets) > 0 {
+		count, err := strconv.Atoi(minTargets)
+		if err != nil || count < 0 {
+			problems = append(problems, errors.Errorf("MIN_TARGETS environment variable must be a non-negative number"))
+		}
+		envVars.MinTargets = count
+	}
+
+	regionNamePattern := sources.get("REGION_NAME_PATTERN")
+	if len(regionNamePattern) > 0 {
+		pattern, err := regexp.Compile(regionNamePattern)
+		if err != nil {
+			problems = append(problems, errors.Wrap(err, "REGION_NAME_PATTERN environment variable is not a valid regular expression"))
+		} else if pattern.SubexpIndex("region") < 0 {
+			problems = append(problems, errors.Errorf("REGION_NAME_PATTERN environment variable must contain a named group called region"))
+		}
+		envVars.RegionNamePattern = pattern
+	}
+
+	envVars.LatencyBudgets = map[string]string{}
+	latencyBudgets := sources.get("REGION_LATENCY_BUDGETS")
+	if len(latencyBudgets) > 0 {
+		for _, budget := range strings.Split(latencyBudgets, ",") {
+			parts := strings.SplitN(budget, "=", 2)
+			if len(parts) != 2 {
+				problems = append(problems, errors.Errorf("REGION_LATENCY_BUDGETS environment variable entries must be in the region=budget format"))
+				continue
+			}
+			_, err := time.ParseDuration(parts[1])
+			if err != nil {
+				problems = append(problems, errors.Wrapf(err, "invalid latency budget for region %s", parts[0]))
+				continue
+			}
+			envVars.LatencyBudgets[parts[0]] = parts[1]
+		}
+	}
+	envVars.DefaultLatencyBudget = sources.get("DEFAULT_LATENCY_BUDGET")
+	if len(envVars.DefaultLatencyBudget) > 0 {
+		_, err := time.ParseDuration(envVars.DefaultLatencyBudget)
+		if err != nil {
+			problems = append(problems, errors.Wrap(err, "DEFAULT_LATENCY_BUDGET environment variable must be a duration"))
+		}
+	}
+
+	envVars.GRPCProbeModule = sources.get("GRPC_PROBE_MODULE")
+	envVars.GRPCHealthService = sources.get("GRPC_HEALTH_SERVICE")
+	if len(envVars.GRPCHealthService) > 0 && len(envVars.GRPCProbeModule) == 0 {
+		problems = append(problems, errors.Errorf("GRPC_PROBE_MODULE environment variable must be set when GRPC_HEALTH_SERVICE is set"))
+	}
+
+	probeProfiles := sources.get("PROBE_PROFILES")
+	if len(probeProfiles) > 0 {
+		profiles, err := render.LoadProbeProfiles(probeProfiles)
+		if err != nil {
+			problems = append(problems, errors.Wrap(err, "PROBE_PROFILES environment variable is invalid"))
+		}
+		envVars.ProbeProfiles = profiles
+	}
+
+	envVars.WebSocketProbeModule = sources.get("WEBSOCKET_PROBE_MODULE")
+	envVars.WebSocketTargetPattern = sources.get("WEBSOCKET_TARGET_PATTERN")
+	if len(envVars.WebSocketTargetPattern) > 0 {
+		if len(envVars.WebSocketProbeModule) == 0 {
+			problems = append(problems, errors.Errorf("WEBSOCKET_PROBE_MODULE environment variable must be set when WEBSOCKET_TARGET_PATTERN is set"))
+		}
+		err := discovery.ValidateWebSocketTargetPattern(envVars.WebSocketTargetPattern)
+		if err != nil {
+			problems = append(problems, errors.Wrap(err, "WEBSOCKET_TARGET_PATTERN environment variable is invalid"))
+		}
+	} else {
+		envVars.WebSocketTargetPattern = discovery.DefaultWebSocketTargetPattern
+	}
+
+	tcpProbeTargets := sources.get("TCP_PROBE_TARGETS")
+	if len(tcpProbeTargets) > 0 {
+		probes, err := discovery.ParseTCPProbes(tcpProbeTargets)
+		if err != nil {
+			problems = append(problems, errors.Wrap(err, "TCP_PROBE_TARGETS environment variable is invalid"))
+		}
+		envVars.TCPProbes = probes
+	}
+
+	cloudMapNamespaces := sources.get("CLOUD_MAP_NAMESPACES")
+	if len(cloudMapNamespaces) > 0 {
+		envVars.CloudMapNamespaces = strings.Split(cloudMapNamespaces, ",")
+	}
+
+	loadBalancerTag := sources.get("LOAD_BALANCER_TAG")
+	if len(loadBalancerTag) > 0 {
+		parts := strings.SplitN(loadBalancerTag, "=", 2)
+		envVars.LoadBalancerTagKey = strings.TrimSpace(parts[0])
+		if len(parts) == 2 {
+			envVars.LoadBalancerTagValue = strings.TrimSpace(parts[1])
+		}
+		if len(envVars.LoadBalancerTagKey) == 0 {
+			problems = append(problems, errors.Errorf("LOAD_BALANCER_TAG %q must be a tag key, optionally followed by =value", loadBalancerTag))
+		}
+	}
+
+	databaseTag := sources.get("DATABASE_TAG")
+	if len(databaseTag) > 0 {
+		parts := strings.SplitN(databaseTag, "=", 2)
+		envVars.DatabaseTagKey = strings.TrimSpace(parts[0])
+		if len(parts) == 2 {
+			envVars.DatabaseTagValue = strings.TrimSpace(parts[1])
+		}
+		if len(envVars.DatabaseTagKey) == 0 {
+			problems = append(problems, errors.Errorf("DATABASE_TAG %q must be a tag key, optionally followed by =value", databaseTag))
+		}
+	}
+
+	envVars.DiscoverIngresses = sources.get("DISCOVER_INGRESSES") == "true"
+	envVars.DiscoverServices = sources.get("DISCOVER_SERVICES") == "true"
+	envVars.DiscoverSRVRecords = sources.get("DISCOVER_SRV_RECORDS") == "true"
+	envVars.CertificateProbeModule = sources.get("CERTIFICATE_PROBE_MODULE")
+	envVars.ProvisionerURL = strings.TrimSuffix(sources.get("PROVISIONER_URL"), "/")
+
+	envVars.StateConfigMapName = sources.get("STATE_CONFIGMAP_NAME")
+	if len(envVars.StateConfigMapName) == 0 {
+		envVars.StateConfigMapName = "blackbox-target-discovery-state"
+	}
+	envVars.TargetsConfigMapName = sources.get("TARGETS_CONFIGMAP_NAME")
+	envVars.StatusConfigMapName = sources.get("STATUS_CONFIGMAP_NAME")
+	if len(envVars.StatusConfigMapName) == 0 {
+		envVars.StatusConfigMapName = "blackbox-target-discovery-status"
+	}
+	envVars.HistoryConfigMapName = sources.get("HISTORY_CONFIGMAP_NAME")
+
+	envVars.OutputKind = sources.get("OUTPUT_KIND")
+	switch envVars.OutputKind {
+	case "":
+		envVars.OutputKind = reconcile.OutputSecret
+	case reconcile.OutputSecret, reconcile.OutputConfigMap, reconcile.OutputProbe, reconcile.OutputScrapeConfig:
+	default:
+		problems = append(problems, errors.Errorf("OUTPUT_KIND environment variable must be %s, %s, %s or %s", reconcile.OutputSecret, reconcile.OutputConfigMap, reconcile.OutputProbe, reconcile.OutputScrapeConfig))
+	}
+
+	envVars.PrometheusReloadURL = sources.get("PROMETHEUS_RELOAD_URL")
+	envVars.PrometheusWorkload = sources.get("PROMETHEUS_WORKLOAD")
+	if len(envVars.PrometheusWorkload) > 0 {
+		_, _, err := reconcile.ParseWorkload(envVars.PrometheusWorkload)
+		if err != nil {
+			problems = append(problems, errors.Wrap(err, "PROMETHEUS_WORKLOAD environment variable is invalid"))
+		}
+	}
+	if envVars.NotifyOnSuccess && !envVars.WritesScrapeConfig() {
+		problems = append(problems, errors.Errorf("NOTIFY_ON_SUCCESS environment variable requires the %s or %s output", reconcile.OutputSecret, reconcile.OutputConfigMap))
+	}
+	if envVars.SecretChunking && envVars.OutputKind != reconcile.OutputSecret {
+		problems = append(problems, errors.Errorf("SECRET_CHUNKING environment variable requires the %s output", reconcile.OutputSecret))
+	}
+	if len(envVars.OutputSecrets) > 0 && !envVars.WritesScrapeConfig() {
+		problems = append(problems, errors.Errorf("OUTPUT_SECRETS environment variable requires the %s or %s output", reconcile.OutputSecret, reconcile.OutputConfigMap))
+	}
+	if (len(envVars.PrometheusReloadURL) > 0 || len(envVars.PrometheusWorkload) > 0) && !envVars.WritesScrapeConfig() {
+		problems = append(problems, errors.Errorf("PROMETHEUS_RELOAD_URL and PROMETHEUS_WORKLOAD environment variables require the %s or %s output", reconcile.OutputSecret, reconcile.OutputConfigMap))
+	}
+
+	maxTargetDrop := sources.get("MAX_TARGET_DROP_PERCENT")
+	if len(maxTargetDrop) > 0 {
+		percent, err := strconv.Atoi(maxTargetDrop)
+		if err != nil || percent < 0 || percent > 100 {
+			problems = append(problems, errors.Errorf("MAX_TARGET_DROP_PERCENT environment variable must be a percentage between 0 and 100"))
+		}
+		envVars.MaxTargetDropPercent = percent
+	}
+	if envVars.MaxTargetDropPercent > 0 && !envVars.WritesScrapeConfig() {
+		problems = append(problems, errors.Errorf("MAX_TARGET_DROP_PERCENT environment variable requires the %s or %s output", reconcile.OutputSecret, reconcile.OutputConfigMap))
+	}
+	envVars.ForceApply = sources.get("FORCE_APPLY") == "true"
+	envVars.ForceConflicts = sources.get("FORCE_CONFLICTS") == "true"
+
+	preflightTimeout := sources.get("PREFLIGHT_TIMEOUT")
+	if len(preflightTimeout) > 0 {
+		timeout, err := time.ParseDuration(preflightTimeout)
+		if err != nil || timeout < 0 {
+			problems = append(problems, errors.Errorf("PREFLIGHT_TIMEOUT environment variable must be a duration such as 5s, or 0 to disable the preflight"))
+		}
+		envVars.PreflightTimeout = timeout
+	}
+	if envVars.PreflightTimeout > 0 && !envVars.WritesScrapeConfig() {
+		problems = append(problems, errors.Errorf("PREFLIGHT_TIMEOUT environment variable requires the %s or %s output", reconcile.OutputSecret, reconcile.OutputConfigMap))
+	}
+	envVars.QuarantineRuns = reconcile.DefaultQuarantineRuns
+	quarantineRuns := sources.get("QUARANTINE_RUNS")
+	if len(quarantineRuns) > 0 {
+		runs, err := strconv.Atoi(quarantineRuns)
+		if err != nil || runs < 0 {
+			problems = append(problems, errors.Errorf("QUARANTINE_RUNS environment variable must be a non-negative number"))
+		}
+		envVars.QuarantineRuns = runs
+	}
+
+	envVars.FileSDPath = sources.get("FILE_SD_PATH")
+	envVars.FileSDConfigMapName = sources.get("FILE_SD_CONFIGMAP_NAME")
+	envVars.FileSDConfigMapKey = sources.get("FILE_SD_CONFIGMAP_KEY")
+	if len(envVars.FileSDConfigMapKey) == 0 {
+		envVars.FileSDConfigMapKey = reconcile.DefaultFileSDConfigMapKey
+	} else if len(envVars.FileSDConfigMapName) == 0 {
+		problems = append(problems, errors.Errorf("FILE_SD_CONFIGMAP_NAME environment variable must be set when FILE_SD_CONFIGMAP_KEY is set"))
+	}
+
+	ticketFailureThreshold := sources.get("TICKET_FAILURE_THRESHOLD")
+	if len(ticketFailureThreshold) > 0 {
+		threshold, err := strconv.Atoi(ticketFailureThreshold)
+		if err != nil || threshold < 0 {
+			problems = append(problems, errors.Errorf("TICKET_FAILURE_THRESHOLD environment variable must be a non-negative number"))
+		}
+		envVars.TicketFailureThreshold = threshold
+	}
+
+	if envVars.TicketFailureThreshold > 0 {
+		envVars.TicketProvider = sources.get("TICKET_PROVIDER")
+		switch envVars.TicketProvider {
+		case "jira":
+			envVars.JiraURL = strings.TrimSuffix(sources.get("JIRA_URL"), "/")
+			envVars.JiraUsername = sources.get("JIRA_USERNAME")
+			envVars.JiraAPIToken = sources.get("JIRA_API_TOKEN")
+			envVars.JiraProjectKey = sources.get("JIRA_PROJECT_KEY")
+			if len(envVars.JiraURL) == 0 || len(envVars.JiraUsername) == 0 || len(envVars.JiraAPIToken) == 0 || len(envVars.JiraProjectKey) == 0 {
+				problems = append(problems, errors.Errorf("JIRA_URL, JIRA_USERNAME, JIRA_API_TOKEN and JIRA_PROJECT_KEY environment variables must be set when TICKET_PROVIDER is jira"))
+			}
+			envVars.JiraIssueType = sources.get("JIRA_ISSUE_TYPE")
+			if len(envVars.JiraIssueType) == 0 {
+				envVars.JiraIssueType = "Bug"
+			}
+		case "github":
+			envVars.GitHubToken = sources.get("GITHUB_TOKEN")
+			envVars.GitHubRepository = sources.get("GITHUB_REPOSITORY")
+			if len(envVars.GitHubToken) == 0 || len(envVars.GitHubRepository) == 0 {
+				problems = append(problems, errors.Errorf("GITHUB_TOKEN and GITHUB_REPOSITORY environment variables must be set when TICKET_PROVIDER is github"))
+			}
+		default:
+			problems = append(problems, errors.Errorf("TICKET_PROVIDER environment variable must be one of jira or github when TICKET_FAILURE_THRESHOLD is set"))
+		}
+	}
+
+	envVars.PagerDutyRoutingKey = sources.get("PAGERDUTY_ROUTING_KEY")
+	envVars.OpsgenieAPIKey = sources.get("OPSGENIE_API_KEY")
+	envVars.OpsgenieAPIURL = sources.get("OPSGENIE_API_URL")
+	envVars.OpsgeniePriority = sources.get("OPSGENIE_PRIORITY")
+	switch envVars.OpsgeniePriority {
+	case "":
+		envVars.OpsgeniePriority = "P3"
+	case "P1", "P2", "P3", "P4", "P5":
+	default:
+		problems = append(problems, errors.Errorf("OPSGENIE_PRIORITY environment variable must be one of P1, P2, P3, P4 or P5"))
+	}
+	envVars.IncidentFailureThreshold = 1
+	incidentFailureThreshold := sources.get("INCIDENT_FAILURE_THRESHOLD")
+	if len(incidentFailureThreshold) > 0 {
+		threshold, err := strconv.Atoi(incidentFailureThreshold)
+		if err != nil || threshold < 1 {
+			problems = append(problems, errors.Errorf("INCIDENT_FAILURE_THRESHOLD environment variable must be a positive number"))
+		}
+		envVars.IncidentFailureThreshold = threshold
+	}
+
+	envVars.MetricsTextfile = sources.get("METRICS_TEXTFILE")
+	envVars.PushgatewayURL = sources.get("PUSHGATEWAY_URL")
+	envVars.StatusListenAddress = sources.get("STATUS_LISTEN_ADDRESS")
+
+	envVars.RetryPolicy = retry.DefaultPolicy
+	retryMaxAttempts := sources.get("RETRY_MAX_ATTEMPTS")
+	if len(retryMaxAttempts) > 0 {
+		attempts, err := strconv.Atoi(retryMaxAttempts)
+		if err != nil || attempts < 1 {
+			problems = append(problems, errors.Errorf("RETRY_MAX_ATTEMPTS environment variable must be a positive number"))
+		}
+		envVars.RetryPolicy.MaxAttempts = attempts
+	}
+	for _, setting := range []struct {
+		name  string
+		delay *time.Duration
+	}{
+		{"RETRY_INITIAL_DELAY", &envVars.RetryPolicy.InitialDelay},
+		{"RETRY_MAX_DELAY", &envVars.RetryPolicy.MaxDelay},
+	} {
+		value := sources.get(setting.name)
+		if len(value) == 0 {
+			continue
+		}
+		delay, err := time.ParseDuration(value)
+		if err != nil || delay <= 0 {
+			problems = append(problems, errors.Errorf("%s environment variable must be a positive duration such as 2s", setting.name))
+			continue
+		}
+		*setting.delay = delay
+	}
+	if envVars.RetryPolicy.MaxDelay < envVars.RetryPolicy.InitialDelay {
+		problems = append(problems, errors.Errorf("RETRY_MAX_DELAY environment variable must not be shorter than RETRY_INITIAL_DELAY"))
+	}
+
+	envVars.AWSTimeout = 2 * time.Minute
+	awsTimeout := sources.get("AWS_TIMEOUT")
+	if len(awsTimeout) > 0 {
+		timeout, err := time.ParseDuration(awsTimeout)
+		if err != nil || timeout < 0 {
+			problems = append(problems, errors.Errorf("AWS_TIMEOUT environment variable must be a duration such as 2m, or 0 to disable the timeout"))
+		}
+		envVars.AWSTimeout = timeout
+	}
+
+	envVars.ZoneListWorkers = reconcile.DefaultZoneListWorkers
+	zoneListWorkers := sources.get("ZONE_LIST_WORKERS")
+	if len(zoneListWorkers) > 0 {
+		workers, err := strconv.Atoi(zoneListWorkers)
+		if err != nil || workers < 1 {
+			problems = append(problems, errors.Errorf("ZONE_LIST_WORKERS environment variable must be a positive number"))
+		}
+		envVars.ZoneListWorkers = workers
+	}
+	envVars.Route53RequestRate = reconcile.DefaultRoute53RequestRate
+	route53RequestRate := sources.get("ROUTE53_REQUEST_RATE")
+	if len(route53RequestRate) > 0 {
+		rate, err := strconv.Atoi(route53RequestRate)
+		if err != nil || rate < 0 {
+			problems = append(problems, errors.Errorf("ROUTE53_REQUEST_RATE environment variable must be a number of requests per second, or 0 to disable the limit"))
+		}
+		envVars.Route53RequestRate = rate
+	}
+
+	envVars.AWSRegion = sources.get("AWS_REGION")
+	if len(envVars.AWSRegion) > 0 && !awsRegionPattern.MatchString(envVars.AWSRegion) {
+		problems = append(problems, errors.Errorf("AWS_REGION environment variable must be an AWS region such as us-east-1"))
+	}
+	envVars.AWSProfile = sources.get("AWS_PROFILE")
+	envVars.AWSEndpointURL = sources.get("AWS_ENDPOINT_URL")
+
+	envVars.AssumeRoleARN = sources.get("AWS_ASSUME_ROLE_ARN")
+	if len(envVars.AssumeRoleARN) > 0 && !isRoleARN(envVars.AssumeRoleARN) {
+		problems = append(problems, errors.Errorf("AWS_ASSUME_ROLE_ARN environment variable must be an IAM role ARN such as arn:aws:iam::123456789012:role/name"))
+	}
+	envVars.ZoneRoleARNs = map[string]string{}
+	zoneRoleARNs := sources.get("AWS_ZONE_ROLE_ARNS")
+	if len(zoneRoleARNs) > 0 {
+		for _, zoneRole := range strings.Split(zoneRoleARNs, ",") {
+			parts := strings.SplitN(zoneRole, "=", 2)
+			if len(parts) != 2 || len(strings.TrimSpace(parts[0])) == 0 || !isRoleARN(strings.TrimSpace(parts[1])) {
+				problems = append(problems, errors.Errorf("AWS_ZONE_ROLE_ARNS environment variable entries must be in the zone=role ARN format"))
+				continue
+			}
+			envVars.ZoneRoleARNs[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	accountRoleARNs := sources.get("AWS_ACCOUNT_ROLE_ARNS")
+	if len(accountRoleARNs) > 0 {
+		seenAccounts := map[string]bool{}
+		for _, accountRole := range strings.Split(accountRoleARNs, ",") {
+			parts := strings.SplitN(accountRole, "=", 2)
+			if len(parts) != 2 || len(strings.TrimSpace(parts[0])) == 0 || !isRoleARN(strings.TrimSpace(parts[1])) {
+				problems = append(problems, errors.Errorf("AWS_ACCOUNT_ROLE_ARNS environment variable entries must be in the account=role ARN format"))
+				continue
+			}
+			name := strings.TrimSpace(parts[0])
+			if seenAccounts[name] {
+				problems = append(problems, errors.Errorf("AWS_ACCOUNT_ROLE_ARNS environment variable lists account %s more than once", name))
+				continue
+			}
+			seenAccounts[name] = true
+			envVars.AWSAccounts = append(envVars.AWSAccounts, reconcile.AWSAccount{Name: name, RoleARN: strings.TrimSpace(parts[1])})
+		}
+		if len(envVars.HostedZoneTagKey) == 0 {
+			problems = append(problems, errors.Errorf("HOSTED_ZONE_TAG environment variable must be set when AWS_ACCOUNT_ROLE_ARNS is set, the hosted zones of the accounts are discovered by tag"))
+		}
+	}
+	assumesRole := len(envVars.AssumeRoleARN) > 0 || len(envVars.ZoneRoleARNs) > 0 || len(envVars.AWSAccounts) > 0
+	envVars.AssumeRoleExternalID = sources.get("AWS_ASSUME_ROLE_EXTERNAL_ID")
+	if len(envVars.AssumeRoleExternalID) > 0 && !assumesRole {
+		problems = append(problems, errors.Errorf("AWS_ASSUME_ROLE_EXTERNAL_ID environment variable requires AWS_ASSUME_ROLE_ARN, AWS_ZONE_ROLE_ARNS or AWS_ACCOUNT_ROLE_ARNS"))
+	}
+	envVars.AssumeRoleSessionName = sources.get("AWS_ASSUME_ROLE_SESSION_NAME")
+	if len(envVars.AssumeRoleSessionName) > 0 {
+		if !assumesRole {
+			problems = append(problems, errors.Errorf("AWS_ASSUME_ROLE_SESSION_NAME environment variable requires AWS_ASSUME_ROLE_ARN, AWS_ZONE_ROLE_ARNS or AWS_ACCOUNT_ROLE_ARNS"))
+		}
+		if !sessionNamePattern.MatchString(envVars.AssumeRoleSessionName) {
+			problems = append(problems, errors.Errorf("AWS_ASSUME_ROLE_SESSION_NAME environment variable must be 2 to 64 letters, digits or any of +=,.@_-"))
+		}
+	}
+
+	envVars.KubeContext = sources.get("KUBE_CONTEXT")
+	if len(envVars.KubeContext) > 0 && envVars.DevMode != "true" {
+		problems = append(problems, errors.Errorf("KUBE_CONTEXT environment variable can only be set when DEVELOPER_MODE is true"))
+	}
+	productionContextPattern := sources.get("PRODUCTION_CONTEXT_PATTERN")
+	if len(productionContextPattern) == 0 {
+		productionContextPattern = "prod"
+	}
+	productionContexts, err := regexp.Compile(productionContextPattern)
+	if err != nil {
+		problems = append(problems, errors.Wrap(err, "PRODUCTION_CONTEXT_PATTERN environment variable is not a valid regular expression"))
+	}
+	envVars.ProductionContexts = productionContexts
+	envVars.AssumeYes = sources.get("ASSUME_YES") == "true"
+	envVars.DryRun = sources.get("DRY_RUN") == "true"
+
+	maintenanceWindows := sources.get("MAINTENANCE_WINDOWS")
+	if len(maintenanceWindows) > 0 {
+		windows, err := discovery.ParseMaintenanceWindows(maintenanceWindows)
+		if err != nil {
+			problems = append(problems, errors.Wrap(err, "MAINTENANCE_WINDOWS environment variable is invalid"))
+		}
+		envVars.MaintenanceWindows = windows
+	}
+	envVars.MaintenanceAction = sources.get("MAINTENANCE_ACTION")
+	switch envVars.MaintenanceAction {
+	case "":
+		envVars.MaintenanceAction = discovery.MaintenanceActionLabel
+	case discovery.MaintenanceActionLabel, discovery.MaintenanceActionRemove:
+	default:
+		problems = append(problems, errors.Errorf("MAINTENANCE_ACTION environment variable must be one of label or remove"))
+	}
+	envVars.MaintenanceConfigMapName = sources.get("MAINTENANCE_CONFIGMAP_NAME")
+
+	envVars.FailPhase = sources.get("FAIL_PHASE")
+	switch envVars.FailPhase {
+	case "", reconcile.PhaseAWS, reconcile.PhaseKubernetes, reconcile.PhaseRender:
+	default:
+		problems = append(problems, errors.Errorf("FAIL_PHASE environment variable must be one of aws, k8s or render"))
+	}
+
+	runSchedule := sources.get("RUN_SCHEDULE")
+	runTimezone := sources.get("RUN_TIMEZONE")
+	if len(runSchedule) > 0 {
+		location, err := time.LoadLocation(runTimezone)
+		if err != nil {
+			problems = append(problems, errors.Wrap(err, "RUN_TIMEZONE environment variable is not a valid time zone"))
+		} else {
+			cron, err := schedule.ParseCron(runSchedule, location)
+			if err != nil {
+				problems = append(problems, errors.Wrap(err, "RUN_SCHEDULE environment variable is invalid"))
+			} else if cron.Next(time.Now()).IsZero() {
+				problems = append(problems, errors.Errorf("RUN_SCHEDULE environment variable never matches a date"))
+			} else {
+				envVars.RunSchedule = cron
+			}
+		}
+	} else if len(runTimezone) > 0 {
+		problems = append(problems, errors.Errorf("RUN_TIMEZONE environment variable can only be set together with RUN_SCHEDULE"))
+	}
+
+	runInterval := sources.get("RUN_INTERVAL")
+	if len(runInterval) > 0 {
+		interval, err := time.ParseDuration(runInterval)
+		if err != nil || interval < time.Minute {
+			problems = append(problems, errors.Errorf("RUN_INTERVAL environment variable must be a duration of at least 1m"))
+		} else if len(runSchedule) > 0 {
+			problems = append(problems, errors.Errorf("RUN_INTERVAL and RUN_SCHEDULE environment variables cannot be set at the same time"))
+		} else {
+			envVars.RunSchedule = schedule.Interval{Every: interval}
+		}
+	}
+
+	envVars.RecordDir = sources.get("RECORD")
+	envVars.ReplayDir = sources.get("REPLAY")
+	if len(envVars.RecordDir) > 0 && len(envVars.ReplayDir) > 0 {
+		problems = append(problems, errors.Errorf("RECORD and REPLAY environment variables cannot be set at the same time"))
+	}
+
+	for _, setting := range []struct{ name, value string }{
+		{"MATTERMOST_ALERTS_HOOK", envVars.MattermostAlertsHook},
+		{"SLACK_WEBHOOK_URL", envVars.SlackWebhookURL},
+		{"NOTIFY_WEBHOOK_URL", envVars.NotifyWebhookURL},
+		{"OPSGENIE_API_URL", envVars.OpsgenieAPIURL},
+		{"PUSHGATEWAY_URL", envVars.PushgatewayURL},
+		{"CHANGE_WEBHOOK_URL", envVars.ChangeWebhookURL},
+		{"PROMETHEUS_RELOAD_URL", envVars.PrometheusReloadURL},
+		{"SERVICENOW_URL", envVars.ServiceNowURL},
+		{"JIRA_URL", envVars.JiraURL},
+		{"AWS_ENDPOINT_URL", envVars.AWSEndpointURL},
+		{"PROVISIONER_URL", envVars.ProvisionerURL},
+	} {
+		if len(setting.value) > 0 && !isHTTPURL(setting.value) {
+			problems = append(problems, errors.Errorf("%s environment variable must be an http or https URL", setting.name))
+		}
+	}
+	if len(envVars.GitHubRepository) > 0 && strings.Count(envVars.GitHubRepository, "/") != 1 {
+		problems = append(problems, errors.Errorf("GITHUB_REPOSITORY environment variable must be in the owner/repo format"))
+	}
+	if envVars.InventoryRetentionDays > 0 && len(envVars.InventoryS3Bucket) == 0 {
+		problems = append(problems, errors.Errorf("INVENTORY_S3_BUCKET environment variable must be set when INVENTORY_RETENTION_DAYS is set"))
+	}
+
+	envVars.OutputS3Bucket = sources.get("OUTPUT_S3_BUCKET")
+	envVars.OutputS3Format = sources.get("OUTPUT_S3_FORMAT")
+	switch envVars.OutputS3Format {
+	case "":
+		envVars.OutputS3Format = reconcile.S3FormatScrapeConfig
+	case reconcile.S3FormatScrapeConfig, reconcile.S3FormatFileSD:
+	default:
+		problems = append(problems, errors.Errorf("OUTPUT_S3_FORMAT environment variable must be %s or %s", reconcile.S3FormatScrapeConfig, reconcile.S3FormatFileSD))
+	}
+	envVars.OutputS3Key = sources.get("OUTPUT_S3_KEY")
+	if len(envVars.OutputS3Key) == 0 {
+		envVars.OutputS3Key = reconcile.DefaultS3ScrapeConfigKey
+		if envVars.OutputS3Format == reconcile.S3FormatFileSD {
+			envVars.OutputS3Key = reconcile.DefaultS3FileSDKey
+		}
+	}
+	envVars.OutputS3KMSKeyID = sources.get("OUTPUT_S3_KMS_KEY_ID")
+	if len(envVars.OutputS3KMSKeyID) > 0 && len(envVars.OutputS3Bucket) == 0 {
+		problems = append(problems, errors.Errorf("OUTPUT_S3_KMS_KEY_ID environment variable requires OUTPUT_S3_BUCKET"))
+	}
+
+	envVars.AuditS3Bucket = sources.get("AUDIT_S3_BUCKET")
+	envVars.AuditS3Prefix = sources.get("AUDIT_S3_PREFIX")
+	if len(envVars.AuditS3Prefix) == 0 {
+		envVars.AuditS3Prefix = "blackbox-target-audit"
+	}
+	envVars.AuditOperator = sources.get("AUDIT_OPERATOR")
+	if len(envVars.AuditS3Bucket) > 0 && !envVars.WritesScrapeConfig() {
+		problems = append(problems, errors.Errorf("AUDIT_S3_BUCKET environment variable requires the %s or %s output", reconcile.OutputSecret, reconcile.OutputConfigMap))
+	}
+
+	for _, setting := range []struct {
+		name   string
+		values []string
+	}{
+		{"EXCLUDED_TARGETS", envVars.ExcludedTargets},
+		{"INCLUDED_TARGET_PATTERNS", envVars.IncludedTargetPatterns},
+		{"ADDITIONAL_TARGETS", envVars.AdditionalTargets},
+		{"BIND_SERVERS", envVars.BindServers},
+		{"CLOUD_MAP_NAMESPACES", envVars.CloudMapNamespaces},
+	} {
+		for _, value := range setting.values {
+			if len(strings.TrimSpace(value)) == 0 {
+				problems = append(problems, errors.Errorf("%s environment variable contains an empty entry", setting.name))
+				break
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return envVars, problems
+	}
+
+	return envVars, nil
+}
+
+// configProblems is every problem found while validating the configuration.
+type configProblems []error
+
+func (p configProblems) Error() string {
+	if len(p) == 1 {
+		return p[0].Error()
+	}
+
+	messages := make([]string, 0, len(p))
+	for _, problem := range p {
+		messages = append(messages, problem.Error())
+	}
+
+	return fmt.Sprintf("%d configuration problems: %s", len(p), strings.Join(messages, "; "))
+}
+
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && len(u.Host) > 0
+}
+
+// awsRegionPattern matches the names of AWS regions, including those of other
+// partitions such as us-gov-west-1.
+var awsRegionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+
+// sessionNamePattern matches the session names accepted by STS AssumeRole.
+var sessionNamePattern = regexp.MustCompile(`^[\w+=,.@-]{2,64}$`)
+
+// isRoleARN reports whether the value looks like the ARN of an IAM role.
+func isRoleARN(value string) bool {
+	return strings.HasPrefix(value, "arn:") && strings.Contains(value, ":role/")
+}
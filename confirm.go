@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/k8s"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/reconcile"
+	"github.com/pkg/errors"
+)
+
+// confirmContext asks for confirmation before a developer mode run writes to
+// a kubeconfig context that looks like production. The context name has to be
+// typed to continue, unless ASSUME_YES is set. Without a terminal the run is refused.
+func confirmContext(envVars *reconcile.Config) error {
+	context, err := k8s.ResolveContext(envVars.KubeContext)
+	if err != nil {
+		return err
+	}
+	if envVars.ProductionContexts == nil || !envVars.ProductionContexts.MatchString(context) || envVars.AssumeYes {
+		return nil
+	}
+
+	if !isTerminal(os.Stdin) {
+		return errors.Errorf("context %s looks like production, set ASSUME_YES=true to write to it without confirmation", context)
+	}
+
+	fmt.Fprintf(os.Stderr, "Context %s looks like production. This will overwrite the secret %s/%s.\n", context, envVars.PrometheusNamespace, envVars.PrometheusSecretName)
+	fmt.Fprintf(os.Stderr, "Type the context name to continue: ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return errors.Wrap(err, "failed to read confirmation")
+	}
+	if strings.TrimSpace(answer) != context {
+		return errors.New("confirmation did not match the context name, aborting")
+	}
+
+	return nil
+}
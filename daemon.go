@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/schedule"
+	log "github.com/sirupsen/logrus"
+)
+
+// runDaemon keeps running the discovery at the times of the schedule until
+// SIGINT or SIGTERM is received. With a fixed interval the first run starts
+// immediately. A signal on triggers runs the discovery immediately. A run in
+// progress is completed before the daemon exits. Failed runs are reported by
+// run itself and do not stop the daemon.
+func runDaemon(runSchedule schedule.Schedule, triggers <-chan struct{}, run func() error) {
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	if _, ok := runSchedule.(schedule.Interval); ok {
+		run()
+	}
+
+	for {
+		now := time.Now()
+		next := runSchedule.Next(now)
+		if next.IsZero() {
+			log.Error("The run schedule has no upcoming runs, stopping")
+			return
+		}
+		log.Infof("Next Blackbox target discovery run at %s", next.Format(time.RFC3339))
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case sig := <-stop:
+			timer.Stop()
+			log.Infof("Received %s, stopping", sig)
+			return
+		case <-triggers:
+			timer.Stop()
+			log.Info("Running Blackbox target discovery for changed targets")
+		case <-timer.C:
+		}
+
+		run()
+	}
+}
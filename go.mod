@@ -3,7 +3,17 @@ module github.com/mattermost/cloud-blackbox-target-discovery
 go 1.14
 
 require (
-	github.com/aws/aws-sdk-go v1.35.9
+	github.com/aws/aws-sdk-go v1.35.9 // indirect
+	github.com/aws/aws-sdk-go-v2 v1.16.4
+	github.com/aws/aws-sdk-go-v2/config v1.15.3
+	github.com/aws/aws-sdk-go-v2/credentials v1.11.2
+	github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2 v1.18.3
+	github.com/aws/aws-sdk-go-v2/service/rds v1.20.0
+	github.com/aws/aws-sdk-go-v2/service/route53 v1.20.3
+	github.com/aws/aws-sdk-go-v2/service/s3 v1.26.5
+	github.com/aws/aws-sdk-go-v2/service/servicediscovery v1.17.5
+	github.com/aws/aws-sdk-go-v2/service/sts v1.16.3
+	github.com/aws/smithy-go v1.11.2
 	github.com/containerd/containerd v1.4.3 // indirect
 	github.com/docker/distribution v2.7.1+incompatible // indirect
 	github.com/docker/docker v20.10.3+incompatible // indirect
@@ -17,7 +27,10 @@ require (
 	github.com/opencontainers/image-spec v1.0.1 // indirect
 	github.com/pingcap/errors v0.11.4
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/prometheus v1.8.2-0.20201015110737-0a7fdd3b7696
 	github.com/sirupsen/logrus v1.7.0
+	golang.org/x/net v0.0.0-20201006153459-a7d1128ccaa0
+	golang.org/x/oauth2 v0.0.0-20200902213428-5d25da1a8d43
 	golang.org/x/time v0.0.0-20200630173020-3af7569d3a1e // indirect
 	gopkg.in/yaml.v2 v2.3.0
 	k8s.io/api v0.19.2
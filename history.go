@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/reconcile"
+)
+
+// historyCommand prints when targets entered or left monitoring, as recorded
+// in the history ConfigMap.
+func historyCommand(reconciler *reconcile.Reconciler, args []string) error {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	target := flags.String("target", "", "only show the changes of targets containing this text")
+	asJSON := flags.Bool("json", false, "print the history as JSON")
+	noColor := flags.Bool("no-color", false, "disable colorized output")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	history, err := reconciler.History()
+	if err != nil {
+		return err
+	}
+	if len(*target) > 0 {
+		history = filterHistory(history, *target)
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(history, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stdout, string(data))
+		return nil
+	}
+
+	if len(history) == 0 {
+		fmt.Fprintln(os.Stdout, "No target changes recorded.")
+		return nil
+	}
+	color := useColor(os.Stdout, *noColor)
+	for _, entry := range history {
+		timestamp := entry.Time.Format(time.RFC3339)
+		for _, ref := range entry.Added {
+			fmt.Fprintf(os.Stdout, "%s %s %s %s (run %s)\n", timestamp, colorize(color, colorGreen, "+"), ref.Job, ref.Target, entry.RunID)
+		}
+		for _, ref := range entry.Removed {
+			fmt.Fprintf(os.Stdout, "%s %s %s %s (run %s)\n", timestamp, colorize(color, colorRed, "-"), ref.Job, ref.Target, entry.RunID)
+		}
+	}
+
+	return nil
+}
+
+// filterHistory keeps the changes of the targets containing the text,
+// dropping the entries without any.
+func filterHistory(history []reconcile.HistoryEntry, text string) []reconcile.HistoryEntry {
+	matches := func(refs []reconcile.TargetRef) []reconcile.TargetRef {
+		var matched []reconcile.TargetRef
+		for _, ref := range refs {
+			if strings.Contains(ref.Target, text) {
+				matched = append(matched, ref)
+			}
+		}
+		return matched
+	}
+
+	var filtered []reconcile.HistoryEntry
+	for _, entry := range history {
+		entry.Added = matches(entry.Added)
+		entry.Removed = matches(entry.Removed)
+		if len(entry.Added) > 0 || len(entry.Removed) > 0 {
+			filtered = append(filtered, entry)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,289 @@
+package aws
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/servicediscovery"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// Route53API is the subset of the Route53 API used by the client.
+type Route53API interface {
+	ListResourceRecordSets(ctx context.Context, input *route53.ListResourceRecordSetsInput, optFns ...func(*route53.Options)) (*route53.ListResourceRecordSetsOutput, error)
+}
+
+// Route53ZoneAPI is the subset of the Route53 API used to discover hosted zones.
+type Route53ZoneAPI interface {
+	route53.ListHostedZonesAPIClient
+	ListTagsForResources(ctx context.Context, input *route53.ListTagsForResourcesInput, optFns ...func(*route53.Options)) (*route53.ListTagsForResourcesOutput, error)
+}
+
+// S3API is the subset of the S3 API used by the client.
+type S3API interface {
+	s3.ListObjectsV2APIClient
+	PutObject(ctx context.Context, input *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	DeleteObjects(ctx context.Context, input *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error)
+}
+
+// DefaultSessionName is the session name used when assuming a role.
+const DefaultSessionName = "blackbox-target-discovery"
+
+// Account is a named AWS account together with the IAM role assumed to read
+// its hosted zones.
+type Account struct {
+	Name    string
+	RoleARN string
+}
+
+// Options configures the client created by NewClient.
+type Options struct {
+	// Region overrides the region of the shared config and the environment.
+	Region string
+	// Profile selects a profile of the shared config and credentials files.
+	Profile string
+	// EndpointURL sends the requests of all services to this endpoint, such
+	// as a localstack instance or the endpoint of another partition.
+	EndpointURL string
+	// Timeout cancels every operation after the duration, if not zero.
+	Timeout time.Duration
+	// RoleARN is the IAM role assumed for Route53 calls, if any.
+	RoleARN string
+	// ZoneRoleARNs maps hosted zone IDs to the IAM role assumed to read
+	// their records, instead of RoleARN.
+	ZoneRoleARNs map[string]string
+	// Accounts are the AWS accounts the hosted zones are discovered in,
+	// instead of the default account.
+	Accounts []Account
+	// ExternalID is passed when assuming any of the roles.
+	ExternalID string
+	// SessionName names the sessions of the assumed roles.
+	SessionName string
+	// RequestRate limits the requests listing records to this many per
+	// second across all hosted zones, if not zero.
+	RequestRate int
+}
+
+// ServiceDiscoveryAPI is the subset of the Cloud Map API used by the client.
+type ServiceDiscoveryAPI interface {
+	servicediscovery.ListNamespacesAPIClient
+	servicediscovery.ListServicesAPIClient
+	servicediscovery.ListInstancesAPIClient
+}
+
+// ELBV2API is the subset of the Elastic Load Balancing v2 API used by the client.
+type ELBV2API interface {
+	elasticloadbalancingv2.DescribeLoadBalancersAPIClient
+	elasticloadbalancingv2.DescribeListenersAPIClient
+	DescribeTags(ctx context.Context, input *elasticloadbalancingv2.DescribeTagsInput, optFns ...func(*elasticloadbalancingv2.Options)) (*elasticloadbalancingv2.DescribeTagsOutput, error)
+}
+
+// RDSAPI is the subset of the RDS API used by the client.
+type RDSAPI interface {
+	rds.DescribeDBClustersAPIClient
+	rds.DescribeDBInstancesAPIClient
+	ListTagsForResource(ctx context.Context, input *rds.ListTagsForResourceInput, optFns ...func(*rds.Options)) (*rds.ListTagsForResourceOutput, error)
+}
+
+// Client provides access to the AWS services used by the Blackbox target discovery.
+type Client struct {
+	route53 Route53API
+	zones   Route53ZoneAPI
+	s3      S3API
+	// serviceDiscovery is the Cloud Map API, if available.
+	serviceDiscovery ServiceDiscoveryAPI
+	// elbv2 is the Elastic Load Balancing v2 API, if available.
+	elbv2 ELBV2API
+	// rds is the RDS API, if available.
+	rds RDSAPI
+	// accounts are the AWS accounts hosted zones are discovered in.
+	accounts []*account
+
+	mu sync.Mutex
+	// zoneRoute53 holds the Route53 APIs of the hosted zones read with their own role.
+	zoneRoute53 map[string]Route53API
+	// zoneAccounts holds the names of the accounts hosted zones were discovered in.
+	zoneAccounts map[string]string
+
+	ctx     context.Context
+	timeout time.Duration
+	// limiter paces the requests listing records, if a request rate is set.
+	limiter <-chan time.Time
+}
+
+// NewClient creates an AWS client using the default credential chain, or the
+// credentials of the profile of the options. Route53 calls are made with the
+// credentials of the assumed roles of the options, all other calls always
+// with the default credentials. Every operation is made with a context
+// derived from ctx that is cancelled after the timeout, or only when ctx is
+// done if the timeout is zero.
+func NewClient(ctx context.Context, options Options) (*Client, error) {
+	cfg, err := loadConfig(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	client := NewClientWithAPIs(route53.NewFromConfig(cfg, roleOptions(cfg, options.RoleARN, options)), s3.NewFromConfig(cfg, func(o *s3.Options) {
+		// Custom endpoints such as localstack don't serve buckets as subdomains.
+		o.UsePathStyle = len(options.EndpointURL) > 0
+	}))
+	for zoneID, roleARN := range options.ZoneRoleARNs {
+		client.SetZoneRoute53(zoneID, route53.NewFromConfig(cfg, roleOptions(cfg, roleARN, options)))
+	}
+	for _, accountOptions := range options.Accounts {
+		api := route53.NewFromConfig(cfg, roleOptions(cfg, accountOptions.RoleARN, options))
+		client.accounts = append(client.accounts, &account{name: accountOptions.Name, route53: api, zones: api})
+	}
+	client.serviceDiscovery = servicediscovery.NewFromConfig(cfg)
+	client.elbv2 = elasticloadbalancingv2.NewFromConfig(cfg)
+	client.rds = rds.NewFromConfig(cfg)
+	client.ctx = ctx
+	client.timeout = options.Timeout
+	if options.RequestRate > 0 {
+		client.limiter = time.NewTicker(time.Second / time.Duration(options.RequestRate)).C
+	}
+
+	return client, nil
+}
+
+// loadConfig loads the configuration of the service clients. Settings
+// missing from the options are taken from the shared config files and the
+// environment, like with config.LoadDefaultConfig.
+func loadConfig(ctx context.Context, options Options) (aws.Config, error) {
+	// Adaptive retries also slow down the requests of the client after it
+	// was throttled, which Route53 does with 5 requests per second.
+	optFns := []func(*config.LoadOptions) error{config.WithRetryMode(aws.RetryModeAdaptive)}
+	if len(options.Region) > 0 {
+		optFns = append(optFns, config.WithRegion(options.Region))
+	}
+	if len(options.Profile) > 0 {
+		optFns = append(optFns, config.WithSharedConfigProfile(options.Profile))
+	}
+	if len(options.EndpointURL) > 0 {
+		optFns = append(optFns, config.WithEndpointResolverWithOptions(aws.EndpointResolverWithOptionsFunc(
+			func(service, region string, _ ...interface{}) (aws.Endpoint, error) {
+				return aws.Endpoint{URL: options.EndpointURL, SigningRegion: region, HostnameImmutable: true}, nil
+			})))
+	}
+
+	return config.LoadDefaultConfig(ctx, optFns...)
+}
+
+// roleOptions returns the option of a Route53 client making it use the
+// credentials of the assumed role, or an option leaving the client unchanged
+// without a role.
+func roleOptions(cfg aws.Config, roleARN string, options Options) func(*route53.Options) {
+	if len(roleARN) == 0 {
+		return func(*route53.Options) {}
+	}
+
+	sessionName := options.SessionName
+	if len(sessionName) == 0 {
+		sessionName = DefaultSessionName
+	}
+	provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), roleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = sessionName
+		if len(options.ExternalID) > 0 {
+			o.ExternalID = aws.String(options.ExternalID)
+		}
+	})
+	credentials := aws.NewCredentialsCache(provider)
+
+	return func(o *route53.Options) {
+		o.Credentials = credentials
+	}
+}
+
+// NewClientWithAPIs creates an AWS client using the given service APIs.
+// Hosted zones can only be discovered if the Route53 API implements Route53ZoneAPI.
+func NewClientWithAPIs(route53API Route53API, s3API S3API) *Client {
+	zones, _ := route53API.(Route53ZoneAPI)
+
+	return &Client{
+		route53:      route53API,
+		zones:        zones,
+		s3:           s3API,
+		zoneRoute53:  map[string]Route53API{},
+		zoneAccounts: map[string]string{},
+		ctx:          context.Background(),
+	}
+}
+
+// account holds the Route53 APIs of an AWS account.
+type account struct {
+	name    string
+	route53 Route53API
+	zones   Route53ZoneAPI
+}
+
+// SetZoneRoute53 makes the client read the records of the hosted zone with
+// the given Route53 API instead of the default one.
+func (c *Client) SetZoneRoute53(zoneID string, route53API Route53API) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.zoneRoute53[zoneID] = route53API
+}
+
+// setZoneAccount makes the client read the records of the hosted zone with
+// the Route53 API of the account it was discovered in.
+func (c *Client) setZoneAccount(zoneID string, account *account) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.zoneRoute53[zoneID] = account.route53
+	c.zoneAccounts[zoneID] = account.name
+}
+
+// ZoneAccount returns the name of the account the hosted zone was discovered
+// in, or an empty string for zones of the default account.
+func (c *Client) ZoneAccount(zoneID string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.zoneAccounts[zoneID]
+}
+
+// recordsAPI returns the Route53 API used to read the records of the hosted zone.
+func (c *Client) recordsAPI(zoneID string) Route53API {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if api, ok := c.zoneRoute53[zoneID]; ok {
+		return api
+	}
+
+	return c.route53
+}
+
+// wait blocks until the request rate allows another request, or the context
+// is done.
+func (c *Client) wait(ctx context.Context) error {
+	if c.limiter == nil {
+		return nil
+	}
+	select {
+	case <-c.limiter:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// operationContext returns the context of a single operation of the client.
+// The timeout covers all pages of a paginated operation.
+func (c *Client) operationContext() (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return context.WithCancel(c.ctx)
+	}
+
+	return context.WithTimeout(c.ctx, c.timeout)
+}
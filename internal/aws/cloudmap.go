@@ -0,0 +1,103 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/servicediscovery"
+	servicediscoverytypes "github.com/aws/aws-sdk-go-v2/service/servicediscovery/types"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Attributes of Cloud Map instances holding their address.
+const (
+	instanceIPv4Attribute  = "AWS_INSTANCE_IPV4"
+	instanceCNAMEAttribute = "AWS_INSTANCE_CNAME"
+	instancePortAttribute  = "AWS_INSTANCE_PORT"
+)
+
+// ListServiceInstances returns the instances of all services of the Cloud
+// Map namespaces with the given names. Instances registered without an
+// address or a port are skipped.
+func (c *Client) ListServiceInstances(namespaces []string) ([]discovery.ServiceInstance, error) {
+	if c.serviceDiscovery == nil {
+		return nil, errors.New("the AWS client does not support Cloud Map discovery")
+	}
+
+	ctx, cancel := c.operationContext()
+	defer cancel()
+
+	wanted := map[string]bool{}
+	for _, namespace := range namespaces {
+		wanted[namespace] = true
+	}
+	namespaceIDs := map[string]string{}
+	namespacePages := servicediscovery.NewListNamespacesPaginator(c.serviceDiscovery, &servicediscovery.ListNamespacesInput{})
+	for namespacePages.HasMorePages() {
+		page, err := namespacePages.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list the Cloud Map namespaces")
+		}
+		for _, namespace := range page.Namespaces {
+			if wanted[aws.ToString(namespace.Name)] {
+				namespaceIDs[aws.ToString(namespace.Name)] = aws.ToString(namespace.Id)
+			}
+		}
+	}
+
+	var instances []discovery.ServiceInstance
+	for _, namespace := range namespaces {
+		namespaceID, ok := namespaceIDs[namespace]
+		if !ok {
+			return nil, errors.Errorf("Cloud Map namespace %s not found", namespace)
+		}
+
+		var services []servicediscoverytypes.ServiceSummary
+		servicePages := servicediscovery.NewListServicesPaginator(c.serviceDiscovery, &servicediscovery.ListServicesInput{
+			Filters: []servicediscoverytypes.ServiceFilter{{
+				Name:      servicediscoverytypes.ServiceFilterNameNamespaceId,
+				Values:    []string{namespaceID},
+				Condition: servicediscoverytypes.FilterConditionEq,
+			}},
+		})
+		for servicePages.HasMorePages() {
+			page, err := servicePages.NextPage(ctx)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to list the services of Cloud Map namespace %s", namespace)
+			}
+			services = append(services, page.Services...)
+		}
+
+		for _, service := range services {
+			serviceName := aws.ToString(service.Name)
+			instancePages := servicediscovery.NewListInstancesPaginator(c.serviceDiscovery, &servicediscovery.ListInstancesInput{
+				ServiceId: service.Id,
+			})
+			for instancePages.HasMorePages() {
+				page, err := instancePages.NextPage(ctx)
+				if err != nil {
+					return nil, errors.Wrapf(err, "failed to list the instances of Cloud Map service %s/%s", namespace, serviceName)
+				}
+				for _, instance := range page.Instances {
+					host := instance.Attributes[instanceIPv4Attribute]
+					if len(host) == 0 {
+						host = instance.Attributes[instanceCNAMEAttribute]
+					}
+					port := instance.Attributes[instancePortAttribute]
+					if len(host) == 0 || len(port) == 0 {
+						log.Debugf("Skipping Cloud Map instance %s of %s/%s without an address and port", aws.ToString(instance.Id), namespace, serviceName)
+						continue
+					}
+					instances = append(instances, discovery.ServiceInstance{
+						Namespace: namespace,
+						Service:   serviceName,
+						Host:      host,
+						Port:      port,
+					})
+				}
+			}
+		}
+	}
+
+	return instances, nil
+}
@@ -0,0 +1,94 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+)
+
+// ListDatabaseEndpoints returns the writer and reader endpoints of the Aurora
+// clusters and the endpoints of the RDS instances tagged with the given key.
+// An empty value matches any value of the tag. Instances that belong to a
+// cluster are reached through the endpoints of the cluster.
+func (c *Client) ListDatabaseEndpoints(tagKey, tagValue string) ([]discovery.DatabaseEndpoint, error) {
+	if c.rds == nil {
+		return nil, errors.New("the AWS client does not support database discovery")
+	}
+
+	ctx, cancel := c.operationContext()
+	defer cancel()
+
+	var endpoints []discovery.DatabaseEndpoint
+	var clusters []rdstypes.DBCluster
+	clusterPages := rds.NewDescribeDBClustersPaginator(c.rds, &rds.DescribeDBClustersInput{})
+	for clusterPages.HasMorePages() {
+		page, err := clusterPages.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list the database clusters")
+		}
+		clusters = append(clusters, page.DBClusters...)
+	}
+	for _, cluster := range clusters {
+		name := aws.ToString(cluster.DBClusterIdentifier)
+		tagged, err := c.hasDatabaseTag(ctx, cluster.DBClusterArn, tagKey, tagValue)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the tags of database cluster %s", name)
+		}
+		if !tagged {
+			continue
+		}
+		for _, host := range []*string{cluster.Endpoint, cluster.ReaderEndpoint} {
+			if len(aws.ToString(host)) > 0 {
+				endpoints = append(endpoints, discovery.DatabaseEndpoint{Database: name, Host: aws.ToString(host), Port: int64(aws.ToInt32(cluster.Port))})
+			}
+		}
+	}
+
+	var instances []rdstypes.DBInstance
+	instancePages := rds.NewDescribeDBInstancesPaginator(c.rds, &rds.DescribeDBInstancesInput{})
+	for instancePages.HasMorePages() {
+		page, err := instancePages.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list the database instances")
+		}
+		instances = append(instances, page.DBInstances...)
+	}
+	for _, instance := range instances {
+		if len(aws.ToString(instance.DBClusterIdentifier)) > 0 || instance.Endpoint == nil {
+			continue
+		}
+		name := aws.ToString(instance.DBInstanceIdentifier)
+		tagged, err := c.hasDatabaseTag(ctx, instance.DBInstanceArn, tagKey, tagValue)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the tags of database instance %s", name)
+		}
+		if tagged {
+			endpoints = append(endpoints, discovery.DatabaseEndpoint{
+				Database: name,
+				Host:     aws.ToString(instance.Endpoint.Address),
+				Port:     int64(instance.Endpoint.Port),
+			})
+		}
+	}
+
+	return endpoints, nil
+}
+
+// hasDatabaseTag reports whether the RDS resource carries the tag.
+func (c *Client) hasDatabaseTag(ctx context.Context, arn *string, key, value string) (bool, error) {
+	resp, err := c.rds.ListTagsForResource(ctx, &rds.ListTagsForResourceInput{ResourceName: arn})
+	if err != nil {
+		return false, err
+	}
+	for _, tag := range resp.TagList {
+		if aws.ToString(tag.Key) == key && (len(value) == 0 || aws.ToString(tag.Value) == value) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
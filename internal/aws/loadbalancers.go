@@ -0,0 +1,92 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	elbv2 "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	elbv2types "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+)
+
+// maxDescribedTags is the number of load balancers DescribeTags accepts per request.
+const maxDescribedTags = 20
+
+// ListLoadBalancerListeners returns the listeners of the application and
+// network load balancers tagged with the given key. An empty value matches
+// any value of the tag.
+func (c *Client) ListLoadBalancerListeners(tagKey, tagValue string) ([]discovery.LoadBalancerListener, error) {
+	if c.elbv2 == nil {
+		return nil, errors.New("the AWS client does not support load balancer discovery")
+	}
+
+	ctx, cancel := c.operationContext()
+	defer cancel()
+
+	loadBalancers := map[string]elbv2types.LoadBalancer{}
+	var arns []string
+	loadBalancerPages := elbv2.NewDescribeLoadBalancersPaginator(c.elbv2, &elbv2.DescribeLoadBalancersInput{})
+	for loadBalancerPages.HasMorePages() {
+		page, err := loadBalancerPages.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list the load balancers")
+		}
+		for _, loadBalancer := range page.LoadBalancers {
+			arn := aws.ToString(loadBalancer.LoadBalancerArn)
+			loadBalancers[arn] = loadBalancer
+			arns = append(arns, arn)
+		}
+	}
+
+	var tagged []elbv2types.LoadBalancer
+	for start := 0; start < len(arns); start += maxDescribedTags {
+		end := start + maxDescribedTags
+		if end > len(arns) {
+			end = len(arns)
+		}
+		resp, err := c.elbv2.DescribeTags(ctx, &elbv2.DescribeTagsInput{
+			ResourceArns: arns[start:end],
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list the load balancer tags")
+		}
+		for _, tagDescription := range resp.TagDescriptions {
+			if hasLoadBalancerTag(tagDescription.Tags, tagKey, tagValue) {
+				tagged = append(tagged, loadBalancers[aws.ToString(tagDescription.ResourceArn)])
+			}
+		}
+	}
+
+	var listeners []discovery.LoadBalancerListener
+	for _, loadBalancer := range tagged {
+		name := aws.ToString(loadBalancer.LoadBalancerName)
+		listenerPages := elbv2.NewDescribeListenersPaginator(c.elbv2, &elbv2.DescribeListenersInput{
+			LoadBalancerArn: loadBalancer.LoadBalancerArn,
+		})
+		for listenerPages.HasMorePages() {
+			page, err := listenerPages.NextPage(ctx)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to list the listeners of load balancer %s", name)
+			}
+			for _, listener := range page.Listeners {
+				listeners = append(listeners, discovery.LoadBalancerListener{
+					LoadBalancer: name,
+					DNSName:      aws.ToString(loadBalancer.DNSName),
+					Protocol:     string(listener.Protocol),
+					Port:         int64(aws.ToInt32(listener.Port)),
+				})
+			}
+		}
+	}
+
+	return listeners, nil
+}
+
+func hasLoadBalancerTag(tags []elbv2types.Tag, key, value string) bool {
+	for _, tag := range tags {
+		if aws.ToString(tag.Key) == key && (len(value) == 0 || aws.ToString(tag.Value) == value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,56 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+)
+
+// ListAllRecordSets is used to get the existing Route53 Records. All pages of
+// the hosted zone are listed, the records are filtered by the caller.
+func (c *Client) ListAllRecordSets(hostedZoneID string) ([]*route53types.ResourceRecordSet, error) {
+	req := route53.ListResourceRecordSetsInput{
+		HostedZoneId: aws.String(hostedZoneID),
+	}
+
+	ctx, cancel := c.operationContext()
+	defer cancel()
+
+	var rrsets []*route53types.ResourceRecordSet
+
+	for {
+		err := c.wait(ctx)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.recordsAPI(hostedZoneID).ListResourceRecordSets(ctx, &req)
+		if err != nil {
+			return nil, err
+		}
+		for i := range resp.ResourceRecordSets {
+			rrsets = append(rrsets, &resp.ResourceRecordSets[i])
+		}
+		if resp.IsTruncated {
+			req.StartRecordName = resp.NextRecordName
+			req.StartRecordType = resp.NextRecordType
+			req.StartRecordIdentifier = resp.NextRecordIdentifier
+		} else {
+			break
+		}
+	}
+
+	return rrsets, nil
+}
+
+// CheckHostedZone verifies that the credentials can read the records of a hosted zone.
+func (c *Client) CheckHostedZone(hostedZoneID string) error {
+	ctx, cancel := c.operationContext()
+	defer cancel()
+
+	_, err := c.recordsAPI(hostedZoneID).ListResourceRecordSets(ctx, &route53.ListResourceRecordSetsInput{
+		HostedZoneId: aws.String(hostedZoneID),
+		MaxItems:     aws.Int32(1),
+	})
+
+	return err
+}
@@ -0,0 +1,88 @@
+package aws
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// PutObject uploads an object to S3.
+func (c *Client) PutObject(bucket, key string, data []byte, contentType string) error {
+	ctx, cancel := c.operationContext()
+	defer cancel()
+
+	_, err := c.s3.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
+	})
+
+	return err
+}
+
+// PutEncryptedObject uploads an object to S3, encrypted with SSE-KMS using
+// the given KMS key ID, ARN or alias.
+func (c *Client) PutEncryptedObject(bucket, key string, data []byte, contentType, kmsKeyID string) error {
+	ctx, cancel := c.operationContext()
+	defer cancel()
+
+	_, err := c.s3.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:               aws.String(bucket),
+		Key:                  aws.String(key),
+		Body:                 bytes.NewReader(data),
+		ContentType:          aws.String(contentType),
+		ServerSideEncryption: s3types.ServerSideEncryptionAwsKms,
+		SSEKMSKeyId:          aws.String(kmsKeyID),
+	})
+
+	return err
+}
+
+// DeleteObjectsBefore deletes the objects under the prefix that were last
+// modified before the cutoff and returns how many were deleted.
+func (c *Client) DeleteObjectsBefore(bucket, prefix string, cutoff time.Time) (int, error) {
+	ctx, cancel := c.operationContext()
+	defer cancel()
+
+	var expired []s3types.ObjectIdentifier
+	paginator := s3.NewListObjectsV2Paginator(c.s3, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for _, object := range page.Contents {
+			if object.LastModified.Before(cutoff) {
+				expired = append(expired, s3types.ObjectIdentifier{Key: object.Key})
+			}
+		}
+	}
+
+	deleted := 0
+	// DeleteObjects accepts at most 1000 keys per request.
+	for len(expired) > 0 {
+		batch := expired
+		if len(batch) > 1000 {
+			batch = batch[:1000]
+		}
+		expired = expired[len(batch):]
+
+		_, err := c.s3.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(bucket),
+			Delete: &s3types.Delete{Objects: batch, Quiet: true},
+		})
+		if err != nil {
+			return deleted, err
+		}
+		deleted += len(batch)
+	}
+
+	return deleted, nil
+}
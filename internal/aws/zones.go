@@ -0,0 +1,103 @@
+package aws
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/pkg/errors"
+)
+
+// maxTaggedResources is the number of resources ListTagsForResources accepts per request.
+const maxTaggedResources = 10
+
+// FindHostedZones returns the IDs of the public and private hosted zones
+// tagged with the given key. An empty value matches any value of the tag.
+// If accounts are configured, the zones of every account are returned
+// instead of those of the default account, and their records are read with
+// the role of their account.
+func (c *Client) FindHostedZones(tagKey, tagValue string) ([]string, []string, error) {
+	if len(c.accounts) == 0 {
+		return c.findHostedZones(c.zones, tagKey, tagValue)
+	}
+
+	var publicZoneIDs, privateZoneIDs []string
+	for _, account := range c.accounts {
+		accountPublicZoneIDs, accountPrivateZoneIDs, err := c.findHostedZones(account.zones, tagKey, tagValue)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to discover the hosted zones of account %s", account.name)
+		}
+		for _, zoneID := range append(accountPublicZoneIDs, accountPrivateZoneIDs...) {
+			c.setZoneAccount(zoneID, account)
+		}
+		publicZoneIDs = append(publicZoneIDs, accountPublicZoneIDs...)
+		privateZoneIDs = append(privateZoneIDs, accountPrivateZoneIDs...)
+	}
+
+	return publicZoneIDs, privateZoneIDs, nil
+}
+
+// findHostedZones returns the IDs of the public and private hosted zones
+// tagged with the given key that the Route53 API can see.
+func (c *Client) findHostedZones(zones Route53ZoneAPI, tagKey, tagValue string) ([]string, []string, error) {
+	if zones == nil {
+		return nil, nil, errors.New("the Route53 client does not support hosted zone discovery")
+	}
+
+	ctx, cancel := c.operationContext()
+	defer cancel()
+
+	private := map[string]bool{}
+	var zoneIDs []string
+	paginator := route53.NewListHostedZonesPaginator(zones, &route53.ListHostedZonesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to list the hosted zones")
+		}
+		for _, zone := range page.HostedZones {
+			zoneID := strings.TrimPrefix(aws.ToString(zone.Id), "/hostedzone/")
+			zoneIDs = append(zoneIDs, zoneID)
+			private[zoneID] = zone.Config != nil && zone.Config.PrivateZone
+		}
+	}
+
+	var publicZoneIDs, privateZoneIDs []string
+	for start := 0; start < len(zoneIDs); start += maxTaggedResources {
+		end := start + maxTaggedResources
+		if end > len(zoneIDs) {
+			end = len(zoneIDs)
+		}
+		resp, err := zones.ListTagsForResources(ctx, &route53.ListTagsForResourcesInput{
+			ResourceType: route53types.TagResourceTypeHostedzone,
+			ResourceIds:  zoneIDs[start:end],
+		})
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to list the hosted zone tags")
+		}
+		for _, tagSet := range resp.ResourceTagSets {
+			if !hasTag(tagSet.Tags, tagKey, tagValue) {
+				continue
+			}
+			zoneID := aws.ToString(tagSet.ResourceId)
+			if private[zoneID] {
+				privateZoneIDs = append(privateZoneIDs, zoneID)
+			} else {
+				publicZoneIDs = append(publicZoneIDs, zoneID)
+			}
+		}
+	}
+
+	return publicZoneIDs, privateZoneIDs, nil
+}
+
+func hasTag(tags []route53types.Tag, key, value string) bool {
+	for _, tag := range tags {
+		if aws.ToString(tag.Key) == key && (len(value) == 0 || aws.ToString(tag.Value) == value) {
+			return true
+		}
+	}
+
+	return false
+}
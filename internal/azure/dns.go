@@ -0,0 +1,209 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// DefaultManagementURL is the Azure Resource Manager endpoint.
+	DefaultManagementURL = "https://management.azure.com"
+
+	// dnsAPIVersion is the version of the Azure DNS API.
+	dnsAPIVersion = "2018-05-01"
+	// identityTokenURL returns tokens of the managed identity of the workload.
+	identityTokenURL = "http://169.254.169.254/metadata/identity/oauth2/token"
+	// identityAPIVersion is the version of the managed identity endpoint.
+	identityAPIVersion = "2018-02-01"
+
+	requestTimeout = 30 * time.Second
+)
+
+// Client lists the record sets of Azure DNS zones.
+type Client struct {
+	// SubscriptionID is the subscription of the DNS zones.
+	SubscriptionID string
+	// ResourceGroup is the resource group of the DNS zones.
+	ResourceGroup string
+	// ManagementURL is the Azure Resource Manager endpoint,
+	// DefaultManagementURL if empty.
+	ManagementURL string
+
+	httpClient *http.Client
+}
+
+// NewClient creates an Azure DNS client authenticated with the managed
+// identity of the workload. The client ID selects a user-assigned identity,
+// the system-assigned identity is used if it is empty.
+func NewClient(subscriptionID, resourceGroup, clientID string) *Client {
+	tokenSource := oauth2.ReuseTokenSource(nil, identityTokenSource{clientID: clientID})
+	httpClient := oauth2.NewClient(context.Background(), tokenSource)
+	httpClient.Timeout = requestTimeout
+
+	return &Client{
+		SubscriptionID: subscriptionID,
+		ResourceGroup:  resourceGroup,
+		httpClient:     httpClient,
+	}
+}
+
+// identityTokenSource gets access tokens of a managed identity from the
+// instance metadata service.
+type identityTokenSource struct {
+	clientID string
+}
+
+// Token requests an access token for the Resource Manager from the instance metadata service.
+func (s identityTokenSource) Token() (*oauth2.Token, error) {
+	query := url.Values{}
+	query.Set("api-version", identityAPIVersion)
+	query.Set("resource", DefaultManagementURL+"/")
+	if len(s.clientID) > 0 {
+		query.Set("client_id", s.clientID)
+	}
+	req, err := http.NewRequest(http.MethodGet, identityTokenURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+
+	client := &http.Client{Timeout: requestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get a managed identity token")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to get a managed identity token with status code %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresOn   string `json:"expires_on"`
+		TokenType   string `json:"token_type"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&token)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode the managed identity token")
+	}
+	expiresOn, err := strconv.ParseInt(token.ExpiresOn, 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid expiry %q of the managed identity token", token.ExpiresOn)
+	}
+
+	return &oauth2.Token{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		Expiry:      time.Unix(expiresOn, 0),
+	}, nil
+}
+
+// recordSet is a CNAME record set of the Azure DNS API.
+type recordSet struct {
+	Name       string `json:"name"`
+	Properties struct {
+		FQDN        string `json:"fqdn"`
+		TTL         int64  `json:"TTL"`
+		CNAMERecord *struct {
+			CNAME string `json:"cname"`
+		} `json:"CNAMERecord"`
+	} `json:"properties"`
+}
+
+// recordSetsResponse is a page of the record sets of a zone.
+type recordSetsResponse struct {
+	Value    []recordSet `json:"value"`
+	NextLink string      `json:"nextLink"`
+	Error    *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// ListAllRecordSets returns the CNAME records of a DNS zone as Route53 record
+// sets, so they are discovered like the records of a public hosted zone.
+// Every CNAME record counts as an installation record and is given its name
+// as the set identifier.
+func (c *Client) ListAllRecordSets(zoneName string) ([]*route53types.ResourceRecordSet, error) {
+	var rrsets []*route53types.ResourceRecordSet
+	requestURL := c.recordSetsURL(zoneName)
+	for len(requestURL) > 0 {
+		resp, err := c.listRecordSets(zoneName, requestURL)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range resp.Value {
+			if record.Properties.CNAMERecord != nil {
+				rrsets = append(rrsets, route53RecordSet(record))
+			}
+		}
+		requestURL = resp.NextLink
+	}
+
+	return rrsets, nil
+}
+
+// CheckHostedZone verifies that the managed identity can read the records of a DNS zone.
+func (c *Client) CheckHostedZone(zoneName string) error {
+	_, err := c.listRecordSets(zoneName, c.recordSetsURL(zoneName))
+
+	return err
+}
+
+// recordSetsURL returns the URL of the first page of the CNAME record sets of a zone.
+func (c *Client) recordSetsURL(zoneName string) string {
+	managementURL := c.ManagementURL
+	if len(managementURL) == 0 {
+		managementURL = DefaultManagementURL
+	}
+
+	return fmt.Sprintf("%s/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/dnsZones/%s/CNAME?api-version=%s",
+		strings.TrimSuffix(managementURL, "/"), url.PathEscape(c.SubscriptionID), url.PathEscape(c.ResourceGroup), url.PathEscape(zoneName), dnsAPIVersion)
+}
+
+// listRecordSets returns a page of the record sets of a zone.
+func (c *Client) listRecordSets(zoneName, requestURL string) (*recordSetsResponse, error) {
+	resp, err := c.httpClient.Get(requestURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send HTTP request")
+	}
+	defer resp.Body.Close()
+
+	var records recordSetsResponse
+	err = json.NewDecoder(resp.Body).Decode(&records)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode the record sets of DNS zone %s with status code %d", zoneName, resp.StatusCode)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message := http.StatusText(resp.StatusCode)
+		if records.Error != nil {
+			message = records.Error.Message
+		}
+		return nil, errors.Errorf("failed to list the record sets of DNS zone %s with status code %d: %s", zoneName, resp.StatusCode, message)
+	}
+
+	return &records, nil
+}
+
+// route53RecordSet converts an Azure DNS CNAME record set to a Route53 record set.
+func route53RecordSet(record recordSet) *route53types.ResourceRecordSet {
+	name := strings.TrimSuffix(record.Properties.FQDN, ".") + "."
+
+	return &route53types.ResourceRecordSet{
+		Name:            aws.String(name),
+		Type:            route53types.RRType("CNAME"),
+		TTL:             aws.Int64(record.Properties.TTL),
+		SetIdentifier:   aws.String("azure-" + strings.TrimSuffix(name, ".")),
+		ResourceRecords: []route53types.ResourceRecord{{Value: aws.String(record.Properties.CNAMERecord.CNAME)}},
+	}
+}
@@ -0,0 +1,228 @@
+package bind
+
+import (
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/pkg/errors"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// dnsPort is the port zone transfers are requested on.
+	dnsPort = "53"
+
+	transferTimeout = 30 * time.Second
+)
+
+// Client transfers the zones of self-hosted BIND servers with AXFR.
+type Client struct {
+	// Servers are the DNS addresses of the BIND servers, tried in order.
+	Servers []string
+}
+
+// NewClient creates a client transferring zones from the hosts of the BIND
+// server metric endpoints, on the DNS port.
+func NewClient(bindServers []string) *Client {
+	var servers []string
+	for _, bindServer := range bindServers {
+		host := serverHost(bindServer)
+		if len(host) > 0 {
+			servers = append(servers, net.JoinHostPort(host, dnsPort))
+		}
+	}
+
+	return &Client{Servers: servers}
+}
+
+// serverHost returns the host of a BIND server metric endpoint, which is a
+// host:port address or a URL.
+func serverHost(endpoint string) string {
+	endpoint = strings.TrimSpace(endpoint)
+	if i := strings.Index(endpoint, "://"); i >= 0 {
+		endpoint = endpoint[i+3:]
+	}
+	host := strings.SplitN(endpoint, "/", 2)[0]
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return host
+}
+
+// ListAllRecordSets transfers a zone from the first BIND server that allows
+// it and returns its CNAME, A and AAAA records as Route53 record sets, so they
+// are discovered like the records of a public hosted zone. The records of a
+// name and type are grouped into one record set, which counts as an
+// installation record and is given its name as the set identifier. Other
+// record types are skipped.
+func (c *Client) ListAllRecordSets(zone string) ([]*route53types.ResourceRecordSet, error) {
+	if len(c.Servers) == 0 {
+		return nil, errors.Errorf("no BIND server to transfer zone %s from", zone)
+	}
+
+	var lastErr error
+	for _, server := range c.Servers {
+		rrsets, err := transferZone(server, zone)
+		if err == nil {
+			return rrsets, nil
+		}
+		lastErr = errors.Wrapf(err, "failed to transfer zone %s from %s", zone, server)
+	}
+
+	return nil, lastErr
+}
+
+// CheckHostedZone verifies that a BIND server allows the transfer of a zone.
+func (c *Client) CheckHostedZone(zone string) error {
+	_, err := c.ListAllRecordSets(zone)
+
+	return err
+}
+
+// transferZone requests a zone transfer from a server over TCP and returns
+// the CNAME, A and AAAA record sets of the zone.
+func transferZone(server, zone string) ([]*route53types.ResourceRecordSet, error) {
+	name, err := dnsmessage.NewName(strings.TrimSuffix(zone, ".") + ".")
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid zone name %s", zone)
+	}
+	id := uint16(rand.Intn(1 << 16))
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id})
+	err = builder.StartQuestions()
+	if err != nil {
+		return nil, err
+	}
+	err = builder.Question(dnsmessage.Question{Name: name, Type: dnsmessage.TypeAXFR, Class: dnsmessage.ClassINET})
+	if err != nil {
+		return nil, err
+	}
+	query, err := builder.Finish()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.DialTimeout("tcp", server, transferTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	err = conn.SetDeadline(time.Now().Add(transferTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	// Messages over TCP are prefixed with their length.
+	length := make([]byte, 2)
+	binary.BigEndian.PutUint16(length, uint16(len(query)))
+	_, err = conn.Write(append(length, query...))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send the AXFR query")
+	}
+
+	var rrsets []*route53types.ResourceRecordSet
+	byNameAndType := map[string]*route53types.ResourceRecordSet{}
+	addRecord := func(header dnsmessage.ResourceHeader, recordType route53types.RRType, value string) {
+		key := header.Name.String() + "\x00" + string(recordType)
+		rrset, ok := byNameAndType[key]
+		if !ok {
+			rrset = recordSet(header, recordType)
+			byNameAndType[key] = rrset
+			rrsets = append(rrsets, rrset)
+		}
+		rrset.ResourceRecords = append(rrset.ResourceRecords, route53types.ResourceRecord{Value: aws.String(value)})
+	}
+	// The transfer starts and ends with the SOA record of the zone.
+	soaRecords := 0
+	for soaRecords < 2 {
+		_, err = io.ReadFull(conn, length)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the AXFR response")
+		}
+		msg := make([]byte, binary.BigEndian.Uint16(length))
+		_, err = io.ReadFull(conn, msg)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the AXFR response")
+		}
+
+		var parser dnsmessage.Parser
+		header, err := parser.Start(msg)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid AXFR response")
+		}
+		if header.ID != id {
+			return nil, errors.Errorf("AXFR response ID %d does not match the query ID %d", header.ID, id)
+		}
+		if header.RCode != dnsmessage.RCodeSuccess {
+			return nil, errors.Errorf("zone transfer refused with response code %v", header.RCode)
+		}
+		err = parser.SkipAllQuestions()
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid AXFR response")
+		}
+
+		answers := 0
+		for {
+			answer, err := parser.AnswerHeader()
+			if err == dnsmessage.ErrSectionDone {
+				break
+			}
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid AXFR response")
+			}
+			answers++
+
+			switch answer.Type {
+			case dnsmessage.TypeSOA:
+				soaRecords++
+				err = parser.SkipAnswer()
+			case dnsmessage.TypeCNAME:
+				var cname dnsmessage.CNAMEResource
+				cname, err = parser.CNAMEResource()
+				if err == nil {
+					addRecord(answer, route53types.RRTypeCname, cname.CNAME.String())
+				}
+			case dnsmessage.TypeA:
+				var a dnsmessage.AResource
+				a, err = parser.AResource()
+				if err == nil {
+					addRecord(answer, route53types.RRTypeA, net.IP(a.A[:]).String())
+				}
+			case dnsmessage.TypeAAAA:
+				var aaaa dnsmessage.AAAAResource
+				aaaa, err = parser.AAAAResource()
+				if err == nil {
+					addRecord(answer, route53types.RRTypeAaaa, net.IP(aaaa.AAAA[:]).String())
+				}
+			default:
+				err = parser.SkipAnswer()
+			}
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid AXFR response")
+			}
+		}
+		if answers == 0 {
+			return nil, errors.New("empty AXFR response")
+		}
+	}
+
+	return rrsets, nil
+}
+
+// recordSet returns an empty Route53 record set for a transferred record.
+func recordSet(header dnsmessage.ResourceHeader, recordType route53types.RRType) *route53types.ResourceRecordSet {
+	name := header.Name.String()
+
+	return &route53types.ResourceRecordSet{
+		Name:          aws.String(name),
+		Type:          recordType,
+		TTL:           aws.Int64(int64(header.TTL)),
+		SetIdentifier: aws.String("bind-" + strings.TrimSuffix(name, ".")),
+	}
+}
@@ -0,0 +1,172 @@
+package bind
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// axfrResponder answers a single AXFR query over TCP with the given
+// messages, each built by a function adding answers to a builder.
+func axfrResponder(t *testing.T, rcode dnsmessage.RCode, messages ...func(b *dnsmessage.Builder) error) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		length := make([]byte, 2)
+		_, err = io.ReadFull(conn, length)
+		if err != nil {
+			return
+		}
+		query := make([]byte, binary.BigEndian.Uint16(length))
+		_, err = io.ReadFull(conn, query)
+		if err != nil {
+			return
+		}
+		var parser dnsmessage.Parser
+		header, err := parser.Start(query)
+		if err != nil {
+			return
+		}
+		question, err := parser.Question()
+		if err != nil {
+			return
+		}
+
+		for _, addAnswers := range messages {
+			builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: header.ID, Response: true, RCode: rcode})
+			_ = builder.StartQuestions()
+			_ = builder.Question(question)
+			_ = builder.StartAnswers()
+			if addAnswers(&builder) != nil {
+				return
+			}
+			msg, err := builder.Finish()
+			if err != nil {
+				return
+			}
+			binary.BigEndian.PutUint16(length, uint16(len(msg)))
+			_, err = conn.Write(append(length, msg...))
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func resourceHeader(name string, ttl uint32) dnsmessage.ResourceHeader {
+	return dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Class: dnsmessage.ClassINET, TTL: ttl}
+}
+
+func soa(b *dnsmessage.Builder) error {
+	return b.SOAResource(resourceHeader("example.com.", 300), dnsmessage.SOAResource{
+		NS:   dnsmessage.MustNewName("ns.example.com."),
+		MBox: dnsmessage.MustNewName("admin.example.com."),
+	})
+}
+
+// TestTransferZone transfers a zone split across two messages and checks
+// that the CNAME, A and AAAA records are grouped into record sets by name and
+// type while other records are skipped.
+func TestTransferZone(t *testing.T) {
+	server := axfrResponder(t, dnsmessage.RCodeSuccess,
+		func(b *dnsmessage.Builder) error {
+			err := soa(b)
+			if err != nil {
+				return err
+			}
+			err = b.CNAMEResource(resourceHeader("customer-a.example.com.", 60), dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName("lb.example.net.")})
+			if err != nil {
+				return err
+			}
+			err = b.AResource(resourceHeader("customer-b.example.com.", 60), dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}})
+			if err != nil {
+				return err
+			}
+			return b.TXTResource(resourceHeader("customer-b.example.com.", 60), dnsmessage.TXTResource{TXT: []string{"ignored"}})
+		},
+		func(b *dnsmessage.Builder) error {
+			err := b.AResource(resourceHeader("customer-b.example.com.", 60), dnsmessage.AResource{A: [4]byte{192, 0, 2, 2}})
+			if err != nil {
+				return err
+			}
+			err = b.AAAAResource(resourceHeader("customer-b.example.com.", 60), dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}})
+			if err != nil {
+				return err
+			}
+			return soa(b)
+		},
+	)
+
+	rrsets, err := (&Client{Servers: []string{server}}).ListAllRecordSets("example.com")
+	if err != nil {
+		t.Fatalf("failed to transfer the zone: %v", err)
+	}
+
+	want := []*route53types.ResourceRecordSet{
+		{
+			Name:            aws.String("customer-a.example.com."),
+			Type:            route53types.RRTypeCname,
+			TTL:             aws.Int64(60),
+			SetIdentifier:   aws.String("bind-customer-a.example.com"),
+			ResourceRecords: []route53types.ResourceRecord{{Value: aws.String("lb.example.net.")}},
+		},
+		{
+			Name:            aws.String("customer-b.example.com."),
+			Type:            route53types.RRTypeA,
+			TTL:             aws.Int64(60),
+			SetIdentifier:   aws.String("bind-customer-b.example.com"),
+			ResourceRecords: []route53types.ResourceRecord{{Value: aws.String("192.0.2.1")}, {Value: aws.String("192.0.2.2")}},
+		},
+		{
+			Name:            aws.String("customer-b.example.com."),
+			Type:            route53types.RRTypeAaaa,
+			TTL:             aws.Int64(60),
+			SetIdentifier:   aws.String("bind-customer-b.example.com"),
+			ResourceRecords: []route53types.ResourceRecord{{Value: aws.String("2001:db8::1")}},
+		},
+	}
+	if !reflect.DeepEqual(rrsets, want) {
+		t.Errorf("got record sets %s, want %s", describe(rrsets), describe(want))
+	}
+}
+
+// TestTransferZoneRefused checks that a refused transfer fails.
+func TestTransferZoneRefused(t *testing.T) {
+	server := axfrResponder(t, dnsmessage.RCodeRefused, soa)
+
+	_, err := (&Client{Servers: []string{server}}).ListAllRecordSets("example.com")
+	if err == nil {
+		t.Fatal("expected the refused transfer to fail")
+	}
+}
+
+func describe(rrsets []*route53types.ResourceRecordSet) []string {
+	var descriptions []string
+	for _, rrset := range rrsets {
+		description := aws.ToString(rrset.Name) + " " + string(rrset.Type)
+		for _, record := range rrset.ResourceRecords {
+			description += " " + aws.ToString(record.Value)
+		}
+		descriptions = append(descriptions, description)
+	}
+
+	return descriptions
+}
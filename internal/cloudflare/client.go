@@ -0,0 +1,135 @@
+package cloudflare
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultAPIURL is the Cloudflare API v4 endpoint.
+	DefaultAPIURL = "https://api.cloudflare.com/client/v4"
+
+	requestTimeout = 30 * time.Second
+	recordsPerPage = 100
+)
+
+// Client lists the DNS records of Cloudflare zones.
+type Client struct {
+	// APIToken is a Cloudflare API token with the Zone.DNS read permission.
+	APIToken string
+	// APIURL is the Cloudflare API endpoint, DefaultAPIURL if empty.
+	APIURL string
+}
+
+// dnsRecord is a DNS record of the Cloudflare API.
+type dnsRecord struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int64  `json:"ttl"`
+}
+
+// dnsRecordsResponse is a page of the DNS records of a zone.
+type dnsRecordsResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result     []dnsRecord `json:"result"`
+	ResultInfo struct {
+		Page       int `json:"page"`
+		TotalPages int `json:"total_pages"`
+	} `json:"result_info"`
+}
+
+// ListAllRecordSets returns the CNAME records of a Cloudflare zone as Route53
+// record sets, so they are discovered like the records of a public hosted
+// zone. Cloudflare has no routing policies, so the record ID is used as the
+// set identifier and every CNAME record counts as an installation record.
+func (c *Client) ListAllRecordSets(zoneID string) ([]*route53types.ResourceRecordSet, error) {
+	var rrsets []*route53types.ResourceRecordSet
+	for page := 1; ; page++ {
+		resp, err := c.listRecords(zoneID, page)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range resp.Result {
+			rrsets = append(rrsets, recordSet(record))
+		}
+		if resp.ResultInfo.Page >= resp.ResultInfo.TotalPages {
+			break
+		}
+	}
+
+	return rrsets, nil
+}
+
+// CheckHostedZone verifies that the API token can read the records of a zone.
+func (c *Client) CheckHostedZone(zoneID string) error {
+	_, err := c.listRecords(zoneID, 1)
+
+	return err
+}
+
+// listRecords returns a page of the CNAME records of a zone.
+func (c *Client) listRecords(zoneID string, page int) (*dnsRecordsResponse, error) {
+	apiURL := c.APIURL
+	if len(apiURL) == 0 {
+		apiURL = DefaultAPIURL
+	}
+	query := url.Values{}
+	query.Set("type", "CNAME")
+	query.Set("page", strconv.Itoa(page))
+	query.Set("per_page", strconv.Itoa(recordsPerPage))
+	requestURL := fmt.Sprintf("%s/zones/%s/dns_records?%s", strings.TrimSuffix(apiURL, "/"), url.PathEscape(zoneID), query.Encode())
+
+	req, err := http.NewRequest(http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+
+	client := &http.Client{Timeout: requestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send HTTP request")
+	}
+	defer resp.Body.Close()
+
+	var records dnsRecordsResponse
+	err = json.NewDecoder(resp.Body).Decode(&records)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode the DNS records of Cloudflare zone %s with status code %d", zoneID, resp.StatusCode)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || !records.Success {
+		message := http.StatusText(resp.StatusCode)
+		if len(records.Errors) > 0 {
+			message = records.Errors[0].Message
+		}
+		return nil, errors.Errorf("failed to list the DNS records of Cloudflare zone %s with status code %d: %s", zoneID, resp.StatusCode, message)
+	}
+
+	return &records, nil
+}
+
+// recordSet converts a Cloudflare DNS record to a Route53 record set.
+func recordSet(record dnsRecord) *route53types.ResourceRecordSet {
+	return &route53types.ResourceRecordSet{
+		Name:            aws.String(strings.TrimSuffix(record.Name, ".") + "."),
+		Type:            route53types.RRType(record.Type),
+		TTL:             aws.Int64(record.TTL),
+		SetIdentifier:   aws.String("cloudflare-" + record.ID),
+		ResourceRecords: []route53types.ResourceRecord{{Value: aws.String(record.Content)}},
+	}
+}
@@ -0,0 +1,43 @@
+package discovery
+
+import "fmt"
+
+// AccountLabel is the label holding the AWS account of the hosted zone a
+// target was discovered in, so targets can be filtered by account in Prometheus.
+const AccountLabel = "aws_account"
+
+// labelAccounts adds the account label to the targets discovered in the
+// hosted zones that belong to a named AWS account.
+func labelAccounts(targets []Target, zones []Zone) {
+	accounts := map[string]string{}
+	for _, zone := range zones {
+		if len(zone.Account) > 0 {
+			accounts[fmt.Sprintf("route53:%s", zone.ID)] = zone.Account
+		}
+	}
+	if len(accounts) == 0 {
+		return
+	}
+
+	for i := range targets {
+		if account, ok := accounts[targets[i].Source]; ok {
+			targets[i].Labels = withAccount(targets[i].Labels, account)
+		}
+	}
+}
+
+// withAccount returns a copy of the labels with the account label, or the
+// labels unchanged if the account is empty.
+func withAccount(labels map[string]string, account string) map[string]string {
+	if len(account) == 0 {
+		return labels
+	}
+
+	labeled := make(map[string]string, len(labels)+1)
+	for name, value := range labels {
+		labeled[name] = value
+	}
+	labeled[AccountLabel] = account
+
+	return labeled
+}
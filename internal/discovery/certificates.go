@@ -0,0 +1,45 @@
+package discovery
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CertificateJobName is the scrape job of the cert-manager Certificate hosts.
+const CertificateJobName = "blackbox-tls"
+
+// Certificate is a cert-manager Certificate and the DNS names it covers.
+type Certificate struct {
+	Namespace string
+	Name      string
+	DNSNames  []string
+}
+
+// CertificateTargets returns an https target for every DNS name of the
+// Certificates that is selected by the target filters, probed with the given
+// TLS verifying module so the expiry of every managed certificate is
+// monitored. Wildcard names cannot be probed and are skipped.
+func CertificateTargets(certificates []Certificate, module string, options Options) []Target {
+	var targets []Target
+	seen := map[string]bool{}
+	for _, certificate := range certificates {
+		for _, dnsName := range certificate.DNSNames {
+			if strings.Contains(dnsName, "*") || !options.selects(dnsName) {
+				continue
+			}
+			target := fmt.Sprintf("https://%s", dnsName)
+			if seen[target] {
+				continue
+			}
+			seen[target] = true
+			targets = append(targets, Target{
+				Target: target,
+				Source: fmt.Sprintf("certificate:%s/%s", certificate.Namespace, certificate.Name),
+				Job:    CertificateJobName,
+				Module: module,
+			})
+		}
+	}
+
+	return targets
+}
@@ -0,0 +1,53 @@
+package discovery
+
+import (
+	"fmt"
+	"net"
+)
+
+// ServiceInstance is an instance registered in an AWS Cloud Map service.
+type ServiceInstance struct {
+	Namespace string
+	Service   string
+	Host      string
+	Port      string
+}
+
+// CloudMapTargets returns the tcp_connect targets of the Cloud Map service
+// instances that are selected by the target filters.
+func CloudMapTargets(instances []ServiceInstance, options Options) []Target {
+	var targets []Target
+	seen := map[string]bool{}
+	for _, instance := range instances {
+		if !options.selects(instance.Host) {
+			continue
+		}
+		address := net.JoinHostPort(instance.Host, instance.Port)
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+		targets = append(targets, newTCPTarget(address, fmt.Sprintf("cloudmap:%s/%s", instance.Namespace, instance.Service), nil))
+	}
+
+	return targets
+}
+
+// MergeTargets appends the targets of another source to the targets,
+// skipping those already probed in the same job.
+func MergeTargets(targets, additional []Target) []Target {
+	seen := map[string]bool{}
+	for _, target := range targets {
+		seen[target.Job+"\x00"+target.Target] = true
+	}
+	for _, target := range additional {
+		key := target.Job + "\x00" + target.Target
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		targets = append(targets, target)
+	}
+
+	return targets
+}
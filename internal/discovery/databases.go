@@ -0,0 +1,36 @@
+package discovery
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// DatabaseEndpoint is an endpoint of an RDS instance or Aurora cluster.
+type DatabaseEndpoint struct {
+	Database string
+	Host     string
+	Port     int64
+}
+
+// DatabaseTargets returns the tcp_connect targets of the database endpoints
+// whose hosts are selected by the target filters.
+func DatabaseTargets(endpoints []DatabaseEndpoint, options Options) []Target {
+	var targets []Target
+	seen := map[string]bool{}
+	for _, endpoint := range endpoints {
+		host := strings.TrimSuffix(endpoint.Host, ".")
+		if len(host) == 0 || !options.selects(host) {
+			continue
+		}
+		address := net.JoinHostPort(host, strconv.FormatInt(endpoint.Port, 10))
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+		targets = append(targets, newTCPTarget(address, fmt.Sprintf("rds:%s", endpoint.Database), nil))
+	}
+
+	return targets
+}
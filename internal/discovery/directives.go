@@ -0,0 +1,86 @@
+package discovery
+
+import (
+	"strings"
+
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// directiveRecordPrefix marks TXT records with probing directives for the
+// record name following the prefix.
+const directiveRecordPrefix = "_blackbox."
+
+// Directive controls the probing of a record from DNS. It is declared by a TXT
+// record named _blackbox.<record name> with space-separated key=value pairs:
+// probe=false stops probing the record and module=<name> probes it with
+// another Blackbox module.
+type Directive struct {
+	Name   string
+	Skip   bool
+	Module string
+}
+
+// DirectivesFromRecords returns the directives declared by TXT records, by the
+// name of the record they apply to. Invalid directives are skipped with a
+// warning, so a mistake in one record does not stop the discovery.
+func DirectivesFromRecords(records []*route53types.ResourceRecordSet) map[string]Directive {
+	directives := map[string]Directive{}
+	for _, record := range records {
+		if record.Type != route53types.RRTypeTxt || !strings.HasPrefix(*record.Name, directiveRecordPrefix) {
+			continue
+		}
+		directive := Directive{Name: strings.TrimSuffix(strings.TrimPrefix(*record.Name, directiveRecordPrefix), ".")}
+		valid := true
+		for _, value := range record.ResourceRecords {
+			if value.Value == nil {
+				continue
+			}
+			for _, pair := range strings.Fields(strings.Trim(*value.Value, "\"")) {
+				parts := strings.SplitN(pair, "=", 2)
+				switch {
+				case len(parts) == 2 && parts[0] == "probe" && (parts[1] == "true" || parts[1] == "false"):
+					directive.Skip = parts[1] == "false"
+				case len(parts) == 2 && parts[0] == "module" && len(parts[1]) > 0:
+					directive.Module = parts[1]
+				default:
+					log.Warnf("Ignoring directive record %s with invalid directive %q, expected probe=false or module=<name>", *record.Name, pair)
+					valid = false
+				}
+			}
+		}
+		if valid {
+			directives[directive.Name] = directive
+		}
+	}
+
+	return directives
+}
+
+// ApplyDirectives removes the targets whose host has a probe=false directive
+// and sets the module of the targets whose host has a module directive. It
+// returns the kept and the removed targets.
+func ApplyDirectives(targets []Target, directives map[string]Directive) ([]Target, []Target) {
+	if len(directives) == 0 {
+		return targets, nil
+	}
+
+	var kept, skipped []Target
+	for _, target := range targets {
+		directive, ok := directives[targetHost(target.Target)]
+		if !ok {
+			kept = append(kept, target)
+			continue
+		}
+		if directive.Skip {
+			skipped = append(skipped, target)
+			continue
+		}
+		if len(directive.Module) > 0 {
+			target.Module = directive.Module
+		}
+		kept = append(kept, target)
+	}
+
+	return kept, skipped
+}
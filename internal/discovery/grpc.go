@@ -0,0 +1,119 @@
+package discovery
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"text/template"
+
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// GRPCJobName is the scrape job of targets probed with the gRPC health checking protocol.
+	GRPCJobName = "blackbox-grpc"
+	// DefaultPrivateRecordRules probes the -grpc. private records on port 9090.
+	DefaultPrivateRecordRules = "*-grpc.*=9090"
+)
+
+// PrivateRecordRule selects the private records matching the pattern. With a
+// port the records are probed as gRPC endpoints on that port, with a template
+// they are probed as HTTP targets at the rendered URL.
+type PrivateRecordRule struct {
+	Pattern  string
+	Port     string
+	Template *template.Template
+}
+
+// defaultPrivateRecordRules are used when no private record rules are configured.
+var defaultPrivateRecordRules, _ = ParsePrivateRecordRules(DefaultPrivateRecordRules)
+
+// ParsePrivateRecordRules parses semicolon-separated pattern=port or
+// pattern=template rules. The patterns are record names, globs or re: regular
+// expressions.
+func ParsePrivateRecordRules(value string) ([]PrivateRecordRule, error) {
+	entries, err := splitRules(value, "port or pattern=template")
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []PrivateRecordRule
+	for _, entry := range entries {
+		rule := PrivateRecordRule{Pattern: entry[0]}
+		if port, err := strconv.Atoi(entry[1]); err == nil {
+			if port < 1 || port > 65535 {
+				return nil, errors.Errorf("invalid port %d in private record rule %s", port, entry[0])
+			}
+			rule.Port = entry[1]
+		} else {
+			rule.Template, err = ParseTargetURLTemplate(entry[1])
+			if err != nil {
+				return nil, err
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// privateTarget returns the target of a private record for the first matching
+// rule. It returns false if no rule matches.
+func privateTarget(record *route53types.ResourceRecordSet, zoneID string, options Options) (Target, bool) {
+	rules := options.PrivateRecordRules
+	if len(rules) == 0 {
+		rules = defaultPrivateRecordRules
+	}
+
+	for _, rule := range rules {
+		if !MatchesTargetPattern(rule.Pattern, *record.Name) {
+			continue
+		}
+		if len(rule.Port) > 0 {
+			return grpcTarget(record, zoneID, rule.Port, options), true
+		}
+
+		target, err := renderTarget(rule.Template, TargetURLData{Name: strings.TrimSuffix(*record.Name, "."), ZoneID: zoneID})
+		if err != nil {
+			log.WithError(err).Warnf("Failed to render the target of private record %s, skipping it", *record.Name)
+			return Target{}, false
+		}
+		return Target{
+			Target: target,
+			Source: fmt.Sprintf("route53:%s", zoneID),
+			Job:    DefaultJobName,
+			Module: DefaultModule,
+			Labels: regionLabels(record, options),
+		}, true
+	}
+
+	return Target{}, false
+}
+
+// grpcTarget returns the Blackbox target for a private gRPC record. When a gRPC probe
+// module is configured the target is probed with the gRPC health checking protocol.
+func grpcTarget(record *route53types.ResourceRecordSet, zoneID, port string, options Options) Target {
+	target := Target{
+		Target: net.JoinHostPort(*record.Name, port),
+		Source: fmt.Sprintf("route53:%s", zoneID),
+		Job:    DefaultJobName,
+		Module: DefaultModule,
+		Labels: regionLabels(record, options),
+	}
+
+	if len(options.GRPCProbeModule) > 0 {
+		target.Job = GRPCJobName
+		target.Module = options.GRPCProbeModule
+		if len(options.GRPCHealthService) > 0 {
+			if target.Labels == nil {
+				target.Labels = map[string]string{}
+			}
+			target.Labels["grpc_service"] = options.GRPCHealthService
+		}
+	}
+
+	return target
+}
@@ -0,0 +1,48 @@
+package discovery
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProbeAnnotation opts Kubernetes resources in to be probed when set to "true".
+const ProbeAnnotation = "blackbox.mattermost.com/probe"
+
+// IngressHost is a host served by a Kubernetes Ingress.
+type IngressHost struct {
+	Namespace string
+	Ingress   string
+	Host      string
+	// TLS is set if the Ingress terminates TLS for the host.
+	TLS bool
+}
+
+// IngressTargets returns the targets of the Ingress hosts that are selected
+// by the target filters. Hosts with TLS are probed over https, the others
+// over http. Wildcard hosts cannot be probed and are skipped.
+func IngressTargets(hosts []IngressHost, options Options) []Target {
+	var targets []Target
+	seen := map[string]bool{}
+	for _, host := range hosts {
+		if len(host.Host) == 0 || strings.Contains(host.Host, "*") || !options.selects(host.Host) {
+			continue
+		}
+		scheme := "http"
+		if host.TLS {
+			scheme = "https"
+		}
+		target := fmt.Sprintf("%s://%s", scheme, host.Host)
+		if seen[target] {
+			continue
+		}
+		seen[target] = true
+		targets = append(targets, Target{
+			Target: target,
+			Source: fmt.Sprintf("ingress:%s/%s", host.Namespace, host.Ingress),
+			Job:    DefaultJobName,
+			Module: DefaultModule,
+		})
+	}
+
+	return targets
+}
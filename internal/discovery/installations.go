@@ -0,0 +1,99 @@
+package discovery
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// InstallationIDLabel is the label holding the provisioner installation ID.
+	InstallationIDLabel = "installation_id"
+	// InstallationGroupLabel is the label holding the customer group of the installation.
+	InstallationGroupLabel = "installation_group"
+
+	// InstallationStateStable is the state of installations that are expected to serve traffic.
+	InstallationStateStable = "stable"
+)
+
+// inactiveInstallationStates are the provisioner states of installations
+// that are hibernated or being deleted, whose records remain in DNS but
+// are not expected to answer probes.
+var inactiveInstallationStates = map[string]bool{
+	"hibernation-requested":   true,
+	"hibernation-in-progress": true,
+	"hibernating":             true,
+	"hibernated":              true,
+	"deletion-requested":      true,
+	"deletion-in-progress":    true,
+	"deletion-final-cleanup":  true,
+	"deletion-pending":        true,
+	"deleted":                 true,
+}
+
+// Installation is a Mattermost installation known to the provisioning server.
+type Installation struct {
+	ID string
+	// GroupID is the customer group of the installation, if any.
+	GroupID     string
+	DomainNames []string
+	State       string
+}
+
+// InstallationTargets returns the ping targets of the domain names of the
+// stable installations that are selected by the target filters, labeled with
+// the installation ID and group.
+func InstallationTargets(installations []Installation, options Options) []Target {
+	var targets []Target
+	for _, installation := range installations {
+		if installation.State != InstallationStateStable {
+			continue
+		}
+		for _, domainName := range installation.DomainNames {
+			if !options.selects(domainName) {
+				continue
+			}
+			labels := map[string]string{InstallationIDLabel: installation.ID}
+			if len(installation.GroupID) > 0 {
+				labels[InstallationGroupLabel] = installation.GroupID
+			}
+			targets = append(targets, Target{
+				Target: pingTargetURL(strings.TrimSuffix(domainName, ".")+".", "", options),
+				Source: fmt.Sprintf("provisioner:%s", installation.ID),
+				Job:    DefaultJobName,
+				Module: DefaultModule,
+				Labels: labels,
+			})
+		}
+	}
+
+	return AssignModules(targets, options.ModuleRules)
+}
+
+// DropInactiveInstallations removes the targets whose host is a domain name
+// of a hibernated or deleting installation. It returns the remaining targets
+// and the removed ones.
+func DropInactiveInstallations(targets []Target, installations []Installation) ([]Target, []Target) {
+	inactive := map[string]bool{}
+	for _, installation := range installations {
+		if !inactiveInstallationStates[installation.State] {
+			continue
+		}
+		for _, domainName := range installation.DomainNames {
+			inactive[strings.ToLower(strings.TrimSuffix(domainName, "."))] = true
+		}
+	}
+	if len(inactive) == 0 {
+		return targets, nil
+	}
+
+	var kept, dropped []Target
+	for _, target := range targets {
+		if inactive[strings.ToLower(targetHost(target.Target))] {
+			dropped = append(dropped, target)
+			continue
+		}
+		kept = append(kept, target)
+	}
+
+	return kept, dropped
+}
@@ -0,0 +1,31 @@
+package discovery
+
+import (
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+)
+
+const (
+	// IPVersion4 only probes the IPv4 addresses of the records.
+	IPVersion4 = "ipv4"
+	// IPVersion6 only probes the IPv6 addresses of the records.
+	IPVersion6 = "ipv6"
+	// IPVersionBoth probes dual-stack records over IPv4, falling back to IPv6.
+	IPVersionBoth = "both"
+)
+
+// acceptsRecordType reports whether a private record of the given type is
+// probed with the IP version. A and AAAA records of the same name become the
+// same target, so with one version the records of the other are skipped.
+func (o Options) acceptsRecordType(record *route53types.ResourceRecordSet) bool {
+	if len(record.Type) == 0 {
+		return true
+	}
+	switch o.IPVersion {
+	case IPVersion4:
+		return record.Type != route53types.RRTypeAaaa
+	case IPVersion6:
+		return record.Type != route53types.RRTypeA
+	}
+
+	return true
+}
@@ -0,0 +1,82 @@
+package discovery
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// JobRule moves the targets whose host matches the pattern into a separate
+// scrape job, probed with its own module and at its own interval.
+type JobRule struct {
+	Pattern string
+	JobName string
+	Module  string
+	// Interval is the scrape interval of the job. The interval of the
+	// template is kept if it is empty.
+	Interval string
+}
+
+// ParseJobRules parses semicolon-separated pattern=job_name,module[,interval]
+// rules. The patterns are record names, globs or re: regular expressions.
+func ParseJobRules(value string) ([]JobRule, error) {
+	entries, err := splitRules(value, "job_name,module[,interval]")
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []JobRule
+	jobModules := map[string]string{}
+	for _, entry := range entries {
+		parts := strings.Split(entry[1], ",")
+		if len(parts) < 2 || len(parts) > 3 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return nil, errors.Errorf("invalid job rule %q, expected pattern=job_name,module[,interval]", entry[0]+"="+entry[1])
+		}
+		rule := JobRule{Pattern: entry[0], JobName: parts[0], Module: parts[1]}
+		if len(parts) == 3 {
+			interval, err := time.ParseDuration(parts[2])
+			if err != nil || interval <= 0 {
+				return nil, errors.Errorf("invalid interval %q of job rule %s", parts[2], entry[0])
+			}
+			rule.Interval = parts[2]
+		}
+		if module, ok := jobModules[rule.JobName]; ok && module != rule.Module {
+			return nil, errors.Errorf("job %s is assigned both the %s and the %s module", rule.JobName, module, rule.Module)
+		}
+		jobModules[rule.JobName] = rule.Module
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// AssignJobs moves every target whose host matches a rule into the job of
+// the rule and probes it with the module of the rule. The first matching rule
+// wins. A target that ends up in a job twice is only kept once.
+func AssignJobs(targets []Target, rules []JobRule) []Target {
+	if len(rules) == 0 {
+		return targets
+	}
+
+	var assigned []Target
+	seen := map[string]bool{}
+	for _, target := range targets {
+		host := targetHost(target.Target)
+		for _, rule := range rules {
+			if MatchesTargetPattern(rule.Pattern, host) {
+				target.Job = rule.JobName
+				target.Module = rule.Module
+				break
+			}
+		}
+		key := target.Job + "/" + target.Target
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		assigned = append(assigned, target)
+	}
+
+	return assigned
+}
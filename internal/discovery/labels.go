@@ -0,0 +1,167 @@
+package discovery
+
+import (
+	"strconv"
+	"strings"
+
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ZoneLabel is the label holding the hosted zone a target was discovered in.
+	ZoneLabel = "zone"
+	// RecordTypeLabel is the label holding the DNS record type of a target.
+	RecordTypeLabel = "record_type"
+	// EnvironmentLabel is the label holding the configured environment.
+	EnvironmentLabel = "environment"
+	// TargetClassLabel is the label holding the kind of endpoint a target is.
+	TargetClassLabel = "target_class"
+	// RecordTTLLabel is the label holding the TTL in seconds of the DNS
+	// record of a target. Alias records have no TTL.
+	RecordTTLLabel = "record_ttl"
+	// RoutingPolicyLabel is the label holding the Route53 routing policy of
+	// the record of a target.
+	RoutingPolicyLabel = "routing_policy"
+	// FailoverLabel is the label holding whether a failover record is the
+	// primary or the secondary record. It is added with RoutingPolicyLabel.
+	FailoverLabel = "failover"
+
+	// classInstallation is the class of the installation ping targets.
+	classInstallation = "installation"
+	// classInternal is the class of the targets of private records.
+	classInternal = "internal"
+)
+
+// enrichmentLabels are the labels that can be attached to every target.
+var enrichmentLabels = []string{ZoneLabel, RecordTypeLabel, EnvironmentLabel, TargetClassLabel, RecordTTLLabel, RoutingPolicyLabel}
+
+// ParseTargetLabels parses a comma-separated list of enrichment labels.
+func ParseTargetLabels(value string) ([]string, error) {
+	var labels []string
+	for _, label := range strings.Split(value, ",") {
+		label = strings.TrimSpace(label)
+		if len(label) == 0 {
+			continue
+		}
+		if !isEnrichmentLabel(label) {
+			return nil, errors.Errorf("unknown target label %q, expected one of %s", label, strings.Join(enrichmentLabels, ", "))
+		}
+		labels = append(labels, label)
+	}
+
+	return labels, nil
+}
+
+func isEnrichmentLabel(label string) bool {
+	for _, known := range enrichmentLabels {
+		if label == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// wantsLabel reports whether an enrichment label is enabled.
+func (o Options) wantsLabel(label string) bool {
+	for _, enabled := range o.TargetLabels {
+		if enabled == label {
+			return true
+		}
+	}
+
+	return false
+}
+
+// recordLabels adds the enabled record type, TTL, routing policy and target
+// class labels of a record to the labels of a target.
+func recordLabels(labels map[string]string, record *route53types.ResourceRecordSet, class string, options Options) map[string]string {
+	if options.wantsLabel(RecordTypeLabel) && len(record.Type) > 0 {
+		labels = withLabel(labels, RecordTypeLabel, string(record.Type))
+	}
+	if options.wantsLabel(RecordTTLLabel) && record.TTL != nil {
+		labels = withLabel(labels, RecordTTLLabel, strconv.FormatInt(*record.TTL, 10))
+	}
+	if options.wantsLabel(RoutingPolicyLabel) {
+		labels = withLabel(labels, RoutingPolicyLabel, routingPolicy(record))
+		if len(record.Failover) > 0 {
+			labels = withLabel(labels, FailoverLabel, strings.ToLower(string(record.Failover)))
+		}
+	}
+	if options.wantsLabel(TargetClassLabel) {
+		labels = withLabel(labels, TargetClassLabel, class)
+	}
+
+	return labels
+}
+
+// routingPolicy returns the Route53 routing policy of a record, inferred from
+// the routing fields it sets.
+func routingPolicy(record *route53types.ResourceRecordSet) string {
+	switch {
+	case len(record.Failover) > 0:
+		return "failover"
+	case record.Weight != nil:
+		return "weighted"
+	case len(record.Region) > 0:
+		return "latency"
+	case record.GeoLocation != nil:
+		return "geolocation"
+	case record.MultiValueAnswer != nil && *record.MultiValueAnswer:
+		return "multivalue"
+	}
+
+	return "simple"
+}
+
+// LabelTargets adds the enabled zone, environment and target class labels to
+// the targets, so targets of different kinds are grouped into separate static
+// configs and alerts can be routed per class. Labels a target already has are
+// kept.
+func LabelTargets(targets []Target, options Options) []Target {
+	if len(options.TargetLabels) == 0 {
+		return targets
+	}
+
+	for i, target := range targets {
+		labels := target.Labels
+		if options.wantsLabel(ZoneLabel) && strings.HasPrefix(target.Source, "route53:") {
+			labels = withLabel(labels, ZoneLabel, strings.TrimPrefix(target.Source, "route53:"))
+		}
+		if options.wantsLabel(EnvironmentLabel) && len(options.Environment) > 0 {
+			labels = withLabel(labels, EnvironmentLabel, options.Environment)
+		}
+		if options.wantsLabel(TargetClassLabel) {
+			labels = withLabel(labels, TargetClassLabel, targetClass(target))
+		}
+		targets[i].Labels = labels
+	}
+
+	return targets
+}
+
+// targetClass returns the kind of endpoint of a target that was not
+// classified while listing the records: installation for the installations
+// of the provisioner, and the name of the source for the other targets.
+func targetClass(target Target) string {
+	if strings.HasPrefix(target.Source, "provisioner:") {
+		return classInstallation
+	}
+
+	return strings.SplitN(target.Source, ":", 2)[0]
+}
+
+// withLabel returns a copy of the labels with the label set, unless it is
+// already set.
+func withLabel(labels map[string]string, name, value string) map[string]string {
+	if _, ok := labels[name]; ok {
+		return labels
+	}
+	copied := map[string]string{name: value}
+	for key, existing := range labels {
+		copied[key] = existing
+	}
+
+	return copied
+}
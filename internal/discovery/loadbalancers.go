@@ -0,0 +1,55 @@
+package discovery
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// LoadBalancerListener is a listener of an Elastic Load Balancing v2 load balancer.
+type LoadBalancerListener struct {
+	LoadBalancer string
+	DNSName      string
+	Protocol     string
+	Port         int64
+}
+
+// LoadBalancerTargets returns the targets of the load balancer listeners
+// whose DNS names are selected by the target filters. HTTP and HTTPS
+// listeners are probed like the installations, TCP and TLS listeners with
+// the tcp_connect module. UDP listeners are skipped.
+func LoadBalancerTargets(listeners []LoadBalancerListener, options Options) []Target {
+	var targets []Target
+	seen := map[string]bool{}
+	for _, listener := range listeners {
+		host := strings.TrimSuffix(listener.DNSName, ".")
+		if !options.selects(host) {
+			continue
+		}
+		source := fmt.Sprintf("elbv2:%s", listener.LoadBalancer)
+		address := net.JoinHostPort(host, strconv.FormatInt(listener.Port, 10))
+
+		var target Target
+		switch listener.Protocol {
+		case "HTTP", "HTTPS":
+			target = Target{
+				Target: fmt.Sprintf("%s://%s", strings.ToLower(listener.Protocol), address),
+				Source: source,
+				Job:    DefaultJobName,
+				Module: DefaultModule,
+			}
+		case "TCP", "TLS", "TCP_UDP":
+			target = newTCPTarget(address, source, nil)
+		default:
+			continue
+		}
+		if seen[target.Target] {
+			continue
+		}
+		seen[target.Target] = true
+		targets = append(targets, target)
+	}
+
+	return targets
+}
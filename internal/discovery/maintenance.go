@@ -0,0 +1,170 @@
+package discovery
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"strings"
+	"time"
+
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MaintenanceLabel is set to true on targets in an active maintenance window.
+	MaintenanceLabel = "maintenance"
+	// MaintenanceActionLabel labels targets in maintenance instead of removing them.
+	MaintenanceActionLabel = "label"
+	// MaintenanceActionRemove removes targets in maintenance until the window ends.
+	MaintenanceActionRemove = "remove"
+
+	// maintenanceRecordPrefix marks TXT records declaring a maintenance window
+	// for the record name following the prefix.
+	maintenanceRecordPrefix = "_maintenance."
+)
+
+// MaintenanceWindow is a period during which the targets matching a host name
+// pattern are in planned maintenance.
+type MaintenanceWindow struct {
+	Pattern string
+	Start   time.Time
+	End     time.Time
+}
+
+// ParseMaintenanceWindows parses semicolon-separated pattern@start/end entries
+// with RFC 3339 times, e.g. customer-*.example.com@2021-03-01T22:00:00Z/2021-03-02T02:00:00Z.
+func ParseMaintenanceWindows(value string) ([]MaintenanceWindow, error) {
+	var windows []MaintenanceWindow
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		parts := strings.SplitN(entry, "@", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("invalid maintenance window %s, expected pattern@start/end", entry)
+		}
+		window, err := parseMaintenancePeriod(parts[0], parts[1])
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, window)
+	}
+
+	return windows, nil
+}
+
+// parseMaintenancePeriod parses the start/end period of a maintenance window.
+func parseMaintenancePeriod(pattern, period string) (MaintenanceWindow, error) {
+	_, err := path.Match(pattern, "")
+	if err != nil || len(pattern) == 0 {
+		return MaintenanceWindow{}, errors.Errorf("invalid maintenance window pattern %q", pattern)
+	}
+
+	times := strings.SplitN(period, "/", 2)
+	if len(times) != 2 {
+		return MaintenanceWindow{}, errors.Errorf("invalid maintenance window period %s, expected start/end", period)
+	}
+	start, err := time.Parse(time.RFC3339, strings.TrimSpace(times[0]))
+	if err != nil {
+		return MaintenanceWindow{}, errors.Wrapf(err, "invalid maintenance window start for %s", pattern)
+	}
+	end, err := time.Parse(time.RFC3339, strings.TrimSpace(times[1]))
+	if err != nil {
+		return MaintenanceWindow{}, errors.Wrapf(err, "invalid maintenance window end for %s", pattern)
+	}
+	if !end.After(start) {
+		return MaintenanceWindow{}, errors.Errorf("maintenance window for %s ends before it starts", pattern)
+	}
+
+	return MaintenanceWindow{Pattern: pattern, Start: start, End: end}, nil
+}
+
+// MaintenanceWindowsFromRecords returns the maintenance windows declared by TXT
+// records named _maintenance.<record name> with a "start/end" value.
+func MaintenanceWindowsFromRecords(records []*route53types.ResourceRecordSet) ([]MaintenanceWindow, error) {
+	var windows []MaintenanceWindow
+	for _, record := range records {
+		if record.Type != route53types.RRTypeTxt || !strings.HasPrefix(*record.Name, maintenanceRecordPrefix) {
+			continue
+		}
+		pattern := strings.TrimSuffix(strings.TrimPrefix(*record.Name, maintenanceRecordPrefix), ".")
+		for _, value := range record.ResourceRecords {
+			if value.Value == nil {
+				continue
+			}
+			window, err := parseMaintenancePeriod(pattern, strings.Trim(*value.Value, "\""))
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid maintenance record %s", *record.Name)
+			}
+			windows = append(windows, window)
+		}
+	}
+
+	return windows, nil
+}
+
+// ApplyMaintenance labels or removes the targets whose host matches a
+// maintenance window that is active at the given time.
+func ApplyMaintenance(targets []Target, windows []MaintenanceWindow, now time.Time, action string) []Target {
+	var active []MaintenanceWindow
+	for _, window := range windows {
+		if !now.Before(window.Start) && now.Before(window.End) {
+			active = append(active, window)
+		}
+	}
+	if len(active) == 0 {
+		return targets
+	}
+
+	result := make([]Target, 0, len(targets))
+	for _, target := range targets {
+		if !inMaintenance(targetHost(target.Target), active) {
+			result = append(result, target)
+			continue
+		}
+		if action == MaintenanceActionRemove {
+			continue
+		}
+
+		labels := map[string]string{MaintenanceLabel: "true"}
+		for name, value := range target.Labels {
+			labels[name] = value
+		}
+		target.Labels = labels
+		result = append(result, target)
+	}
+
+	return result
+}
+
+func inMaintenance(host string, windows []MaintenanceWindow) bool {
+	for _, window := range windows {
+		matched, _ := path.Match(window.Pattern, host)
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// targetHost returns the host name of a target, without scheme, path, port or trailing dot.
+func targetHost(target string) string {
+	if i := strings.Index(target, "://"); i >= 0 {
+		target = target[i+3:]
+	}
+	host := strings.SplitN(target, "/", 2)[0]
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+
+	return strings.TrimSuffix(host, ".")
+}
+
+// String returns the window in the pattern@start/end format.
+func (w MaintenanceWindow) String() string {
+	return fmt.Sprintf("%s@%s/%s", w.Pattern, w.Start.Format(time.RFC3339), w.End.Format(time.RFC3339))
+}
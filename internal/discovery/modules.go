@@ -0,0 +1,85 @@
+package discovery
+
+import (
+	"path"
+)
+
+// ModuleRule probes the HTTP targets whose host matches one of the patterns
+// with a different Blackbox module.
+type ModuleRule struct {
+	Module   string
+	Patterns []string
+}
+
+// AssignModules sets the module of every HTTP ping target matching a rule.
+// The first matching rule wins. Targets of other jobs keep their module.
+func AssignModules(targets []Target, rules []ModuleRule) []Target {
+	if len(rules) == 0 {
+		return targets
+	}
+
+	for i, target := range targets {
+		if target.Job != DefaultJobName || target.Module != DefaultModule {
+			continue
+		}
+		host := targetHost(target.Target)
+		for _, rule := range rules {
+			if matchesAny(rule.Patterns, host) {
+				targets[i].Module = rule.Module
+				break
+			}
+		}
+	}
+
+	return targets
+}
+
+func matchesAny(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		matched, _ := path.Match(pattern, host)
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ModulePattern probes the targets whose host matches the pattern with a
+// Blackbox module, whatever their job.
+type ModulePattern struct {
+	Pattern string
+	Module  string
+}
+
+// ParseModulePatterns parses semicolon-separated pattern=module rules. The
+// patterns are record names, globs or re: regular expressions.
+func ParseModulePatterns(value string) ([]ModulePattern, error) {
+	entries, err := splitRules(value, "module")
+	if err != nil {
+		return nil, err
+	}
+
+	var patterns []ModulePattern
+	for _, entry := range entries {
+		patterns = append(patterns, ModulePattern{Pattern: entry[0], Module: entry[1]})
+	}
+
+	return patterns, nil
+}
+
+// SelectModules sets the module of every target whose host matches a
+// pattern. The first matching pattern wins.
+func SelectModules(targets []Target, patterns []ModulePattern) []Target {
+	for i, target := range targets {
+		host := targetHost(target.Target)
+		for _, pattern := range patterns {
+			if MatchesTargetPattern(pattern.Pattern, host) {
+				targets[i].Module = pattern.Module
+				break
+			}
+		}
+	}
+
+	return targets
+}
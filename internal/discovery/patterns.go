@@ -0,0 +1,70 @@
+package discovery
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// RegexPrefix marks a target pattern as a regular expression.
+const RegexPrefix = "re:"
+
+// compiledPatterns caches the regular expressions of target patterns, which
+// are matched against every record of every run.
+var compiledPatterns = struct {
+	sync.Mutex
+	regexps map[string]*regexp.Regexp
+}{regexps: map[string]*regexp.Regexp{}}
+
+// ValidateTargetPattern checks that a target pattern is a valid regular
+// expression or glob.
+func ValidateTargetPattern(pattern string) error {
+	if strings.HasPrefix(pattern, RegexPrefix) {
+		_, err := regexp.Compile(strings.TrimPrefix(pattern, RegexPrefix))
+		if err != nil {
+			return errors.Wrapf(err, "invalid target pattern %q", pattern)
+		}
+		return nil
+	}
+	_, err := path.Match(pattern, "")
+	if err != nil {
+		return errors.Errorf("invalid target pattern %q", pattern)
+	}
+
+	return nil
+}
+
+// MatchesTargetPattern reports whether a record name matches a target
+// pattern. Patterns starting with re: are regular expressions, patterns
+// containing *, ? or [ are globs and any other pattern must match the name
+// exactly. Regular expressions and globs are matched against the name
+// without its trailing dot.
+func MatchesTargetPattern(pattern, name string) bool {
+	switch {
+	case strings.HasPrefix(pattern, RegexPrefix):
+		re := compilePattern(strings.TrimPrefix(pattern, RegexPrefix))
+		return re != nil && re.MatchString(strings.TrimSuffix(name, "."))
+	case strings.ContainsAny(pattern, "*?["):
+		matched, _ := path.Match(strings.TrimSuffix(pattern, "."), strings.TrimSuffix(name, "."))
+		return matched
+	default:
+		return pattern == name
+	}
+}
+
+// compilePattern returns the cached regular expression, or nil if it is invalid.
+func compilePattern(expression string) *regexp.Regexp {
+	compiledPatterns.Lock()
+	defer compiledPatterns.Unlock()
+
+	re, ok := compiledPatterns.regexps[expression]
+	if !ok {
+		re, _ = regexp.Compile(expression)
+		compiledPatterns.regexps[expression] = re
+	}
+
+	return re
+}
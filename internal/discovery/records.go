@@ -0,0 +1,85 @@
+package discovery
+
+import (
+	"strings"
+
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/pkg/errors"
+)
+
+// RecordFilter limits the records of the hosted zones that are considered to
+// the given types and name prefixes. Empty lists select every record. The TXT
+// records declaring maintenance windows and probing directives are always kept.
+type RecordFilter struct {
+	Types        []string
+	NamePrefixes []string
+}
+
+// ParseRecordTypes parses a comma-separated list of DNS record types.
+func ParseRecordTypes(value string) ([]string, error) {
+	var types []string
+	for _, recordType := range strings.Split(value, ",") {
+		recordType = strings.ToUpper(strings.TrimSpace(recordType))
+		if len(recordType) == 0 {
+			continue
+		}
+		if strings.Trim(recordType, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789") != "" {
+			return nil, errors.Errorf("invalid record type %q", recordType)
+		}
+		types = append(types, recordType)
+	}
+
+	return types, nil
+}
+
+// Apply returns the records selected by the filter.
+func (f RecordFilter) Apply(records []*route53types.ResourceRecordSet) []*route53types.ResourceRecordSet {
+	if len(f.Types) == 0 && len(f.NamePrefixes) == 0 {
+		return records
+	}
+
+	var selected []*route53types.ResourceRecordSet
+	for _, record := range records {
+		if isControlRecord(record) || (f.selectsType(record) && f.selectsName(record)) {
+			selected = append(selected, record)
+		}
+	}
+
+	return selected
+}
+
+func (f RecordFilter) selectsType(record *route53types.ResourceRecordSet) bool {
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, recordType := range f.Types {
+		if string(record.Type) == recordType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f RecordFilter) selectsName(record *route53types.ResourceRecordSet) bool {
+	if len(f.NamePrefixes) == 0 {
+		return true
+	}
+	for _, prefix := range f.NamePrefixes {
+		if record.Name != nil && strings.HasPrefix(strings.ToLower(*record.Name), strings.ToLower(prefix)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isControlRecord reports whether a record declares a maintenance window or
+// probing directives instead of being a target.
+func isControlRecord(record *route53types.ResourceRecordSet) bool {
+	if record.Type != route53types.RRTypeTxt || record.Name == nil {
+		return false
+	}
+
+	return strings.HasPrefix(*record.Name, maintenanceRecordPrefix) || strings.HasPrefix(*record.Name, directiveRecordPrefix)
+}
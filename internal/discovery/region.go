@@ -0,0 +1,55 @@
+package discovery
+
+import (
+	"regexp"
+
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+)
+
+// awsRegionPattern matches AWS region names embedded in DNS names, e.g. in ELB alias targets.
+var awsRegionPattern = regexp.MustCompile(`\b([a-z]{2}(?:-gov)?-(?:north|south|east|west|central|northeast|northwest|southeast|southwest)-[0-9])\b`)
+
+// regionLabels returns the region and latency budget labels for a Route53 record.
+// The region is taken from the record's latency routing region, the configured
+// record name pattern or the alias target DNS name, in that order.
+func regionLabels(record *route53types.ResourceRecordSet, options Options) map[string]string {
+	region := recordRegion(record, options.RegionNamePattern)
+	if len(region) == 0 {
+		return nil
+	}
+
+	labels := map[string]string{"region": region}
+	if budget, ok := options.LatencyBudgets[region]; ok {
+		labels["latency_budget"] = budget
+	} else if len(options.DefaultLatencyBudget) > 0 {
+		labels["latency_budget"] = options.DefaultLatencyBudget
+	}
+
+	return labels
+}
+
+// recordRegion infers the serving region of a Route53 record.
+func recordRegion(record *route53types.ResourceRecordSet, namePattern *regexp.Regexp) string {
+	if len(record.Region) > 0 {
+		return string(record.Region)
+	}
+
+	if namePattern != nil && record.Name != nil {
+		match := namePattern.FindStringSubmatch(*record.Name)
+		if match != nil {
+			region := match[namePattern.SubexpIndex("region")]
+			if len(region) > 0 {
+				return region
+			}
+		}
+	}
+
+	if record.AliasTarget != nil && record.AliasTarget.DNSName != nil {
+		match := awsRegionPattern.FindStringSubmatch(*record.AliasTarget.DNSName)
+		if match != nil {
+			return match[1]
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,61 @@
+package discovery
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// ProbePortsAnnotation lists the ports of a Service that are probed, as
+// port or port/scheme with the scheme tcp, http or https.
+const ProbePortsAnnotation = "blackbox.mattermost.com/probe-ports"
+
+// ServiceEndpoint is an external address and port of a Kubernetes
+// LoadBalancer Service.
+type ServiceEndpoint struct {
+	Namespace string
+	Service   string
+	// Address is the hostname or IP address of the load balancer.
+	Address string
+	Port    int64
+	// Scheme is tcp, http or https.
+	Scheme string
+}
+
+// ServiceTargets returns the targets of the LoadBalancer Service endpoints
+// whose addresses are selected by the target filters. HTTP and HTTPS
+// endpoints are probed like the installations, TCP endpoints with the
+// tcp_connect module.
+func ServiceTargets(endpoints []ServiceEndpoint, options Options) []Target {
+	var targets []Target
+	seen := map[string]bool{}
+	for _, endpoint := range endpoints {
+		if !options.selects(endpoint.Address) {
+			continue
+		}
+		source := fmt.Sprintf("service:%s/%s", endpoint.Namespace, endpoint.Service)
+		address := net.JoinHostPort(endpoint.Address, strconv.FormatInt(endpoint.Port, 10))
+
+		var target Target
+		switch endpoint.Scheme {
+		case "http", "https":
+			target = Target{
+				Target: fmt.Sprintf("%s://%s", endpoint.Scheme, address),
+				Source: source,
+				Job:    DefaultJobName,
+				Module: DefaultModule,
+			}
+		case "tcp":
+			target = newTCPTarget(address, source, nil)
+		default:
+			continue
+		}
+		if seen[target.Target] {
+			continue
+		}
+		seen[target.Target] = true
+		targets = append(targets, target)
+	}
+
+	return targets
+}
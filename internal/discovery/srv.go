@@ -0,0 +1,107 @@
+package discovery
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// SRVServiceLabel is the label holding the service of an SRV record, such as
+// _grpc._tcp.
+const SRVServiceLabel = "srv_service"
+
+// srvTarget is a target of an SRV record value.
+type srvTarget struct {
+	Priority int
+	Weight   int
+	Port     int
+	Host     string
+}
+
+// parseSRVValue parses an SRV record value of the form
+// "priority weight port target".
+func parseSRVValue(value string) (srvTarget, bool) {
+	fields := strings.Fields(value)
+	if len(fields) != 4 {
+		return srvTarget{}, false
+	}
+
+	var numbers [3]int
+	for i := range numbers {
+		n, err := strconv.Atoi(fields[i])
+		if err != nil || n < 0 || n > 65535 {
+			return srvTarget{}, false
+		}
+		numbers[i] = n
+	}
+
+	return srvTarget{
+		Priority: numbers[0],
+		Weight:   numbers[1],
+		Port:     numbers[2],
+		Host:     strings.TrimSuffix(fields[3], "."),
+	}, true
+}
+
+// srvTargets returns a host:port target for every target of the SRV records
+// of the zones. Targets of _grpc services are probed in the gRPC job when a
+// gRPC probe module is configured, other targets with tcp_connect.
+func srvTargets(zones []Zone, options Options) []Target {
+	var targets []Target
+	seen := map[string]bool{}
+	for _, zone := range zones {
+		for _, record := range zone.Records {
+			if record.Type != route53types.RRTypeSrv || !options.selects(*record.Name) {
+				continue
+			}
+			service := srvService(*record.Name)
+			for _, value := range record.ResourceRecords {
+				if value.Value == nil {
+					continue
+				}
+				srv, ok := parseSRVValue(*value.Value)
+				if !ok {
+					log.Warnf("Skipping invalid value %q of SRV record %s", *value.Value, *record.Name)
+					continue
+				}
+				// A target of . means the service is not available at the name.
+				if len(srv.Host) == 0 || srv.Port == 0 {
+					continue
+				}
+
+				address := net.JoinHostPort(srv.Host, strconv.Itoa(srv.Port))
+				labels := withAccount(regionLabels(record, options), zone.Account)
+				labels = withLabel(labels, SRVServiceLabel, service)
+				target := newTCPTarget(address, fmt.Sprintf("route53:%s", zone.ID), labels)
+				if strings.HasPrefix(service, "_grpc.") && len(options.GRPCProbeModule) > 0 {
+					target.Job = GRPCJobName
+					target.Module = options.GRPCProbeModule
+					if len(options.GRPCHealthService) > 0 {
+						target.Labels = withLabel(target.Labels, "grpc_service", options.GRPCHealthService)
+					}
+				}
+				if seen[target.Job+"\x00"+target.Target] {
+					continue
+				}
+				seen[target.Job+"\x00"+target.Target] = true
+				targets = append(targets, target)
+			}
+		}
+	}
+
+	return targets
+}
+
+// srvService returns the _service._proto labels of an SRV record name.
+func srvService(name string) string {
+	labels := strings.SplitN(name, ".", 3)
+	if len(labels) < 3 {
+		return strings.TrimSuffix(name, ".")
+	}
+
+	return labels[0] + "." + labels[1]
+}
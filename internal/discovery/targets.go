@@ -0,0 +1,197 @@
+package discovery
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// SourceAdditional is the source of targets listed in the additional targets.
+	SourceAdditional = "additional"
+
+	// DefaultJobName is the scrape job of the discovered HTTP targets.
+	DefaultJobName = "blackbox"
+	// DefaultModule is the Blackbox module the discovered HTTP targets are probed with.
+	DefaultModule = "http_2xx"
+)
+
+// Target is a discovered probe target together with where it was found.
+type Target struct {
+	Target string            `json:"target"`
+	Source string            `json:"source"`
+	Job    string            `json:"job"`
+	Module string            `json:"module"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Zone is a Route53 hosted zone together with its records.
+type Zone struct {
+	ID string
+	// Account is the name of the AWS account the zone was discovered in, if
+	// the zones of several accounts are aggregated.
+	Account string
+	Records []*route53types.ResourceRecordSet
+}
+
+// Records returns the records of all zones.
+func Records(zones []Zone) []*route53types.ResourceRecordSet {
+	var records []*route53types.ResourceRecordSet
+	for _, zone := range zones {
+		records = append(records, zone.Records...)
+	}
+
+	return records
+}
+
+// Options configures which records become Blackbox targets and how they are labeled.
+type Options struct {
+	ExcludedTargets []string
+	// IncludedTargetPatterns limits the records that become targets to those
+	// matching one of the patterns. All records are selected if it is empty.
+	IncludedTargetPatterns []string
+	AdditionalTargets      []string
+	RegionNamePattern      *regexp.Regexp
+	LatencyBudgets         map[string]string
+	DefaultLatencyBudget   string
+	GRPCProbeModule        string
+	GRPCHealthService      string
+	TCPProbes              []TCPProbe
+	// WebSocketProbeModule enables a WebSocket endpoint target per installation.
+	WebSocketProbeModule   string
+	WebSocketTargetPattern string
+	// ModuleRules probe matching HTTP targets with other modules.
+	ModuleRules []ModuleRule
+	// TargetURLTemplate renders the targets of public records, and
+	// TargetURLRules override it for matching records.
+	TargetURLTemplate *template.Template
+	TargetURLRules    []TargetURLRule
+	// PrivateRecordRules select the private records that become targets.
+	// DefaultPrivateRecordRules are used if it is empty.
+	PrivateRecordRules []PrivateRecordRule
+	// TargetLabels are the enrichment labels added to every target, and
+	// Environment is the value of the environment label.
+	TargetLabels []string
+	Environment  string
+	// IPVersion selects the A or AAAA private records, IPVersionBoth
+	// selects both.
+	IPVersion string
+	// DiscoverSRVRecords probes the host:port targets of the SRV records.
+	DiscoverSRVRecords bool
+}
+
+// GetTargets is used to get all Blackbox target that need to be registered.
+func GetTargets(publicZones, privateZones []Zone, options Options) []Target {
+	targets := []Target{}
+	for _, zone := range publicZones {
+		for _, record := range zone.Records {
+			if record.SetIdentifier != nil {
+				if options.selects(*record.Name) && !strings.HasPrefix(*record.Name, "_") && !strings.Contains(*record.SetIdentifier, "[hibernating]") {
+					targets = append(targets, Target{
+						Target: pingTargetURL(*record.Name, zone.ID, options),
+						Source: fmt.Sprintf("route53:%s", zone.ID),
+						Job:    DefaultJobName,
+						Module: DefaultModule,
+						Labels: recordLabels(regionLabels(record, options), record, classInstallation, options),
+					})
+					if len(options.WebSocketProbeModule) > 0 {
+						targets = append(targets, webSocketTarget(record, zone.ID, options))
+					}
+				}
+			}
+		}
+	}
+
+	// The A and AAAA records of a dual-stack name share their target.
+	privateSeen := map[string]bool{}
+	for _, zone := range privateZones {
+		for _, record := range zone.Records {
+			if options.selects(*record.Name) && !strings.HasPrefix(*record.Name, "_") && options.acceptsRecordType(record) {
+				if target, ok := privateTarget(record, zone.ID, options); ok && !privateSeen[target.Job+"\x00"+target.Target] {
+					privateSeen[target.Job+"\x00"+target.Target] = true
+					target.Labels = recordLabels(target.Labels, record, classInternal, options)
+					targets = append(targets, target)
+				}
+			}
+		}
+	}
+
+	zones := append(append([]Zone{}, publicZones...), privateZones...)
+	labelAccounts(targets, zones)
+	targets = append(targets, tcpTargets(zones, options)...)
+	if options.DiscoverSRVRecords {
+		targets = append(targets, srvTargets(zones, options)...)
+	}
+
+	for _, target := range options.AdditionalTargets {
+		log.Infof("Adding additional target %s", target)
+		targets = append(targets, Target{
+			Target: target,
+			Source: SourceAdditional,
+			Job:    DefaultJobName,
+			Module: DefaultModule,
+		})
+	}
+	targets = AssignModules(targets, options.ModuleRules)
+	log.Info("Returning Blackbox targets")
+
+	return targets
+}
+
+// UniqueTargets returns the targets without the targets already probed in the
+// same job, such as the records of a weighted or latency routed name or
+// additional targets that are also discovered. The first occurrence is kept.
+func UniqueTargets(targets []Target) []Target {
+	return MergeTargets(nil, targets)
+}
+
+// SortTargets returns a copy of the targets sorted by target and job, so the
+// same targets always render identically whatever the discovery order.
+func SortTargets(targets []Target) []Target {
+	sorted := append([]Target{}, targets...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Target != sorted[j].Target {
+			return sorted[i].Target < sorted[j].Target
+		}
+		return sorted[i].Job < sorted[j].Job
+	})
+
+	return sorted
+}
+
+// IsExcludedTarget checks if a Route53 record matches one of the excluded
+// targets, which can be exact names, globs or re: regular expressions.
+func IsExcludedTarget(excludedTargets []string, record string) bool {
+	for _, target := range excludedTargets {
+		if MatchesTargetPattern(target, record) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsIncludedTarget checks if a Route53 record matches one of the included
+// target patterns. Every record is included if there are no patterns.
+func IsIncludedTarget(includedPatterns []string, record string) bool {
+	if len(includedPatterns) == 0 {
+		return true
+	}
+	for _, pattern := range includedPatterns {
+		if MatchesTargetPattern(pattern, record) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// selects reports whether a record is included and not excluded.
+func (o Options) selects(record string) bool {
+	return IsIncludedTarget(o.IncludedTargetPatterns, record) && !IsExcludedTarget(o.ExcludedTargets, record)
+}
@@ -0,0 +1,78 @@
+package discovery_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/fake"
+)
+
+const zonesFixture = "../harness/testdata/zones.json"
+
+// jobTarget is a target together with the job it is probed in.
+type jobTarget struct {
+	Job    string
+	Target string
+}
+
+// TestGetTargets discovers the targets of the fixture hosted zones with
+// different options.
+func TestGetTargets(t *testing.T) {
+	zones, err := fake.LoadZones(zonesFixture)
+	if err != nil {
+		t.Fatalf("failed to load fixtures: %v", err)
+	}
+	publicZones := []discovery.Zone{{ID: "ZPUBLIC", Records: zones["ZPUBLIC"]}}
+	privateZones := []discovery.Zone{{ID: "ZPRIVATE", Records: zones["ZPRIVATE"]}}
+
+	tests := []struct {
+		name    string
+		options discovery.Options
+		want    []jobTarget
+	}{
+		{
+			name: "defaults",
+			want: []jobTarget{
+				{"blackbox", "customer-a.cloud.example.com/api/v4/system/ping"},
+				{"blackbox", "customer-b.cloud.example.com/api/v4/system/ping"},
+				{"blackbox", "provisioner-grpc.internal.example.com.:9090"},
+			},
+		},
+		{
+			name:    "excluded target",
+			options: discovery.Options{ExcludedTargets: []string{"customer-a.cloud.example.com."}},
+			want: []jobTarget{
+				{"blackbox", "customer-b.cloud.example.com/api/v4/system/ping"},
+				{"blackbox", "provisioner-grpc.internal.example.com.:9090"},
+			},
+		},
+		{
+			name:    "included pattern",
+			options: discovery.Options{IncludedTargetPatterns: []string{"customer-*"}},
+			want: []jobTarget{
+				{"blackbox", "customer-a.cloud.example.com/api/v4/system/ping"},
+				{"blackbox", "customer-b.cloud.example.com/api/v4/system/ping"},
+			},
+		},
+		{
+			name:    "additional target",
+			options: discovery.Options{ExcludedTargets: []string{"*"}, AdditionalTargets: []string{"https://status.example.com"}},
+			want: []jobTarget{
+				{"blackbox", "https://status.example.com"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []jobTarget
+			for _, target := range discovery.GetTargets(publicZones, privateZones, test.options) {
+				got = append(got, jobTarget{Job: target.Job, Target: target.Target})
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got targets %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package discovery
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// TCPJobName is the scrape job of the configured TCP probes.
+	TCPJobName = "blackbox-tcp"
+	// TCPProbeModule is the Blackbox module the configured TCP probes use.
+	TCPProbeModule = "tcp_connect"
+)
+
+// TCPProbe is a configured TCP probe. The host is either a fixed host name or
+// a glob pattern matched against the Route53 record names.
+type TCPProbe struct {
+	Host string
+	Port string
+}
+
+// ParseTCPProbes parses comma-separated host:port or pattern:port entries.
+func ParseTCPProbes(value string) ([]TCPProbe, error) {
+	var probes []TCPProbe
+	for _, entry := range strings.Split(value, ",") {
+		host, port, err := net.SplitHostPort(strings.TrimSpace(entry))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid TCP probe %s", entry)
+		}
+		if len(host) == 0 || len(port) == 0 {
+			return nil, errors.Errorf("invalid TCP probe %s, expected host:port", entry)
+		}
+		_, err = path.Match(host, "")
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid TCP probe pattern %s", host)
+		}
+		probes = append(probes, TCPProbe{Host: host, Port: port})
+	}
+
+	return probes, nil
+}
+
+func (p TCPProbe) isPattern() bool {
+	return strings.ContainsAny(p.Host, "*?[")
+}
+
+// tcpTargets returns the tcp_connect targets for the configured TCP probes.
+// Patterns are matched against the names of the records of the given zones.
+func tcpTargets(zones []Zone, options Options) []Target {
+	var targets []Target
+	seen := map[string]bool{}
+	for _, probe := range options.TCPProbes {
+		if !probe.isPattern() {
+			targets = append(targets, newTCPTarget(net.JoinHostPort(probe.Host, probe.Port), "tcp-probes", nil))
+			continue
+		}
+
+		for _, zone := range zones {
+			for _, record := range zone.Records {
+				name := strings.TrimSuffix(*record.Name, ".")
+				if !options.selects(*record.Name) {
+					continue
+				}
+				matched, _ := path.Match(probe.Host, name)
+				if !matched {
+					continue
+				}
+				address := net.JoinHostPort(name, probe.Port)
+				if seen[address] {
+					continue
+				}
+				seen[address] = true
+				labels := withAccount(regionLabels(record, options), zone.Account)
+				targets = append(targets, newTCPTarget(address, fmt.Sprintf("tcp-probes:%s", probe.Host), labels))
+			}
+		}
+	}
+
+	return targets
+}
+
+func newTCPTarget(address, source string, labels map[string]string) Target {
+	return Target{
+		Target: address,
+		Source: source,
+		Job:    TCPJobName,
+		Module: TCPProbeModule,
+		Labels: labels,
+	}
+}
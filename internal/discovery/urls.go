@@ -0,0 +1,125 @@
+package discovery
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultTargetURLTemplate is the ping endpoint of a Mattermost installation.
+const DefaultTargetURLTemplate = "{{ .Name }}/api/v4/system/ping"
+
+// TargetURLData is passed to target URL templates.
+type TargetURLData struct {
+	// Name is the record name without its trailing dot.
+	Name string
+	// ZoneID is the hosted zone of the record.
+	ZoneID string
+}
+
+// TargetURLRule probes the public records matching the pattern at the URL of the template.
+type TargetURLRule struct {
+	Pattern  string
+	Template *template.Template
+}
+
+// ParseTargetURLTemplate parses a target URL template, such as
+// https://{{ .Name }}/api/v4/system/ping, and checks that it renders a target.
+func ParseTargetURLTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("target").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid target URL template %q", text)
+	}
+
+	var target bytes.Buffer
+	err = tmpl.Execute(&target, TargetURLData{Name: "example.com", ZoneID: "Z1"})
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid target URL template %q", text)
+	}
+	if len(strings.TrimSpace(target.String())) == 0 {
+		return nil, errors.Errorf("target URL template %q renders an empty target", text)
+	}
+
+	return tmpl, nil
+}
+
+// ParseTargetURLRules parses semicolon-separated pattern=template rules. The
+// patterns are record names, globs or re: regular expressions.
+func ParseTargetURLRules(value string) ([]TargetURLRule, error) {
+	entries, err := splitRules(value, "template")
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []TargetURLRule
+	for _, entry := range entries {
+		tmpl, err := ParseTargetURLTemplate(entry[1])
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, TargetURLRule{Pattern: entry[0], Template: tmpl})
+	}
+
+	return rules, nil
+}
+
+// splitRules splits semicolon-separated pattern=value rules and validates
+// their patterns.
+func splitRules(value, valueName string) ([][2]string, error) {
+	var entries [][2]string
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return nil, errors.Errorf("invalid rule %q, expected pattern=%s", entry, valueName)
+		}
+		err := ValidateTargetPattern(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, [2]string{parts[0], parts[1]})
+	}
+
+	return entries, nil
+}
+
+// pingTargetURL returns the target of a public record, rendered with the
+// first matching rule or the default template.
+func pingTargetURL(recordName, zoneID string, options Options) string {
+	data := TargetURLData{Name: strings.TrimSuffix(recordName, "."), ZoneID: zoneID}
+	tmpl := options.TargetURLTemplate
+	for _, rule := range options.TargetURLRules {
+		if MatchesTargetPattern(rule.Pattern, recordName) {
+			tmpl = rule.Template
+			break
+		}
+	}
+	if tmpl == nil {
+		return fmt.Sprintf("%s/api/v4/system/ping", data.Name)
+	}
+
+	target, err := renderTarget(tmpl, data)
+	if err != nil {
+		log.WithError(err).Warnf("Failed to render the target URL of %s, using the ping endpoint", recordName)
+		return fmt.Sprintf("%s/api/v4/system/ping", data.Name)
+	}
+
+	return target
+}
+
+func renderTarget(tmpl *template.Template, data TargetURLData) (string, error) {
+	var target bytes.Buffer
+	err := tmpl.Execute(&target, data)
+	if err != nil {
+		return "", err
+	}
+
+	return target.String(), nil
+}
@@ -0,0 +1,50 @@
+package discovery
+
+import (
+	"fmt"
+	"strings"
+
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// WebSocketJobName is the scrape job of the installation WebSocket endpoints.
+	WebSocketJobName = "blackbox-websocket"
+	// DefaultWebSocketTargetPattern is the WebSocket endpoint of a Mattermost installation.
+	DefaultWebSocketTargetPattern = "https://{host}/api/v4/websocket"
+
+	// webSocketHostPlaceholder is replaced with the installation host name in the target pattern.
+	webSocketHostPlaceholder = "{host}"
+)
+
+// ValidateWebSocketTargetPattern checks that a WebSocket target pattern is an
+// HTTP URL containing the host placeholder.
+func ValidateWebSocketTargetPattern(pattern string) error {
+	if !strings.Contains(pattern, webSocketHostPlaceholder) {
+		return errors.Errorf("WebSocket target pattern %s does not contain %s", pattern, webSocketHostPlaceholder)
+	}
+	if !strings.HasPrefix(pattern, "http://") && !strings.HasPrefix(pattern, "https://") {
+		return errors.Errorf("WebSocket target pattern %s must be an http or https URL, the upgrade is requested by the probe module", pattern)
+	}
+
+	return nil
+}
+
+// webSocketTarget returns the WebSocket endpoint target of an installation record,
+// probed separately from the HTTP ping so real-time connectivity failures are
+// detected on their own.
+func webSocketTarget(record *route53types.ResourceRecordSet, zoneID string, options Options) Target {
+	pattern := options.WebSocketTargetPattern
+	if len(pattern) == 0 {
+		pattern = DefaultWebSocketTargetPattern
+	}
+
+	return Target{
+		Target: strings.ReplaceAll(pattern, webSocketHostPlaceholder, strings.TrimSuffix(*record.Name, ".")),
+		Source: fmt.Sprintf("route53:%s", zoneID),
+		Job:    WebSocketJobName,
+		Module: options.WebSocketProbeModule,
+		Labels: regionLabels(record, options),
+	}
+}
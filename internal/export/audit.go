@@ -0,0 +1,82 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// AuditRecord records the target changes applied to a Prometheus secret.
+type AuditRecord struct {
+	Timestamp  time.Time     `json:"timestamp"`
+	RunID      string        `json:"run_id"`
+	Operator   string        `json:"operator"`
+	Namespace  string        `json:"namespace"`
+	SecretName string        `json:"secret_name"`
+	Added      []AuditTarget `json:"added"`
+	Removed    []AuditTarget `json:"removed"`
+}
+
+// AuditTarget is a target added to or removed from a scrape job.
+type AuditTarget struct {
+	Job    string `json:"job"`
+	Target string `json:"target"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// NewAuditRecord builds the audit record of the target changes of a scrape
+// config. Changes of job settings are not recorded.
+func NewAuditRecord(runID, operator, namespace, secretName string, changes []render.Change) AuditRecord {
+	record := AuditRecord{
+		Timestamp:  time.Now().UTC(),
+		RunID:      runID,
+		Operator:   operator,
+		Namespace:  namespace,
+		SecretName: secretName,
+		Added:      []AuditTarget{},
+		Removed:    []AuditTarget{},
+	}
+	for _, change := range changes {
+		if len(change.Target) == 0 {
+			continue
+		}
+		target := AuditTarget{Job: change.Job, Target: change.Target, Reason: change.Reason}
+		switch change.Action {
+		case render.ActionAdd:
+			record.Added = append(record.Added, target)
+		case render.ActionRemove:
+			record.Removed = append(record.Removed, target)
+		}
+	}
+
+	return record
+}
+
+// AuditLog writes audit records to an object store, one object per record.
+type AuditLog struct {
+	Store  ObjectStore
+	Bucket string
+	Prefix string
+}
+
+// Write uploads an audit record under a key made of its date, timestamp and run ID.
+func (a *AuditLog) Write(record AuditRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal audit record")
+	}
+
+	key := path.Join(a.Prefix, record.Timestamp.Format("2006/01/02"), fmt.Sprintf("%s-%s.json", record.Timestamp.Format("20060102T150405Z"), record.RunID))
+	err = a.Store.PutObject(a.Bucket, key, data, "application/json")
+	if err != nil {
+		return errors.Wrapf(err, "failed to upload audit record to s3://%s/%s", a.Bucket, key)
+	}
+	log.Infof("Uploaded audit record to s3://%s/%s", a.Bucket, key)
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ObjectStore stores objects in a bucket.
+type ObjectStore interface {
+	PutObject(bucket, key string, data []byte, contentType string) error
+	DeleteObjectsBefore(bucket, prefix string, cutoff time.Time) (int, error)
+}
+
+// TargetInventory is the published list of Blackbox targets of a run.
+type TargetInventory struct {
+	GeneratedAt time.Time          `json:"generated_at"`
+	Targets     []discovery.Target `json:"targets"`
+}
+
+// inventoryPointer is the content of the latest pointer object.
+type inventoryPointer struct {
+	Key         string    `json:"key"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// InventoryPublisher publishes versioned target inventories to an object store.
+type InventoryPublisher struct {
+	Store         ObjectStore
+	Bucket        string
+	Prefix        string
+	RetentionDays int
+}
+
+// Publish uploads the target inventory under a timestamped key, updates the
+// latest pointer and removes versions older than the retention period.
+func (p *InventoryPublisher) Publish(targets []discovery.Target) error {
+	inventory := TargetInventory{
+		GeneratedAt: time.Now().UTC(),
+		Targets:     targets,
+	}
+	data, err := json.MarshalIndent(inventory, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal target inventory")
+	}
+
+	key := path.Join(p.Prefix, "versions", inventory.GeneratedAt.Format("2006/01/02"), fmt.Sprintf("%s.json", inventory.GeneratedAt.Format("20060102T150405Z")))
+	err = p.Store.PutObject(p.Bucket, key, data, "application/json")
+	if err != nil {
+		return errors.Wrapf(err, "failed to upload inventory to s3://%s/%s", p.Bucket, key)
+	}
+	log.Infof("Uploaded target inventory to s3://%s/%s", p.Bucket, key)
+
+	pointer, err := json.Marshal(inventoryPointer{Key: key, GeneratedAt: inventory.GeneratedAt})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal latest inventory pointer")
+	}
+	latestKey := path.Join(p.Prefix, "latest.json")
+	err = p.Store.PutObject(p.Bucket, latestKey, pointer, "application/json")
+	if err != nil {
+		return errors.Wrapf(err, "failed to update s3://%s/%s", p.Bucket, latestKey)
+	}
+
+	if p.RetentionDays > 0 {
+		deleted, err := p.Store.DeleteObjectsBefore(p.Bucket, path.Join(p.Prefix, "versions")+"/", time.Now().AddDate(0, 0, -p.RetentionDays))
+		if err != nil {
+			return errors.Wrap(err, "failed to expire old inventory versions")
+		}
+		if deleted > 0 {
+			log.Infof("Deleted %d expired inventory versions", deleted)
+		}
+	}
+
+	return nil
+}
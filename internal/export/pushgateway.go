@@ -0,0 +1,48 @@
+package export
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const pushgatewayRequestTimeout = 30 * time.Second
+
+// PushMetrics replaces the metrics of a group on a Prometheus Pushgateway
+// with metrics in the Prometheus text exposition format. The group is
+// identified by the job and the grouping labels.
+func PushMetrics(pushgatewayURL, job string, grouping map[string]string, metrics string) error {
+	path := "/metrics/job/" + url.PathEscape(job)
+	var names []string
+	for name := range grouping {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path += "/" + url.PathEscape(name) + "/" + url.PathEscape(grouping[name])
+	}
+
+	req, err := http.NewRequest(http.MethodPut, strings.TrimSuffix(pushgatewayURL, "/")+path, bytes.NewBufferString(metrics))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	client := &http.Client{Timeout: pushgatewayRequestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send HTTP request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
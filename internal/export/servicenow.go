@@ -0,0 +1,223 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	serviceNowManagedBy       = "blackbox-target-discovery"
+	serviceNowStatusInstalled = "1"
+	serviceNowStatusRetired   = "7"
+	serviceNowRequestTimeout  = 30 * time.Second
+	serviceNowRecordPageSize  = 1000
+)
+
+// serviceNowRecord is the representation of a Blackbox target in the ServiceNow CMDB table.
+type serviceNowRecord struct {
+	SysID         string `json:"sys_id,omitempty"`
+	Name          string `json:"name"`
+	InstallStatus string `json:"install_status"`
+	Source        string `json:"u_discovery_source"`
+	Labels        string `json:"u_labels"`
+	ManagedBy     string `json:"u_managed_by"`
+	LastSeen      string `json:"u_last_discovered,omitempty"`
+}
+
+type serviceNowRecordList struct {
+	Result []serviceNowRecord `json:"result"`
+}
+
+// ServiceNow exports the target inventory to a ServiceNow CMDB table.
+type ServiceNow struct {
+	URL      string
+	Username string
+	Password string
+	Table    string
+	client   *http.Client
+}
+
+// NewServiceNow creates a ServiceNow exporter.
+func NewServiceNow(url, username, password, table string) *ServiceNow {
+	return &ServiceNow{
+		URL:      url,
+		Username: username,
+		Password: password,
+		Table:    table,
+		client:   &http.Client{Timeout: serviceNowRequestTimeout},
+	}
+}
+
+// Export keeps the ServiceNow CMDB table in sync with the discovered Blackbox targets.
+// Targets that are no longer discovered are marked as retired instead of being deleted.
+// Records that already match their target are not written, so their last
+// discovered time is the time the target last changed.
+func (s *ServiceNow) Export(targets []discovery.Target) error {
+	existing, err := s.listRecords()
+	if err != nil {
+		return errors.Wrap(err, "failed to list the existing ServiceNow records")
+	}
+
+	now := time.Now().UTC().Format("2006-01-02 15:04:05")
+	discovered := map[string]bool{}
+	unchanged := 0
+	for _, target := range targets {
+		discovered[target.Target] = true
+
+		labels, err := json.Marshal(inventoryLabels(target))
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal labels for target %s", target.Target)
+		}
+		record := serviceNowRecord{
+			Name:          target.Target,
+			InstallStatus: serviceNowStatusInstalled,
+			Source:        target.Source,
+			Labels:        string(labels),
+			ManagedBy:     serviceNowManagedBy,
+			LastSeen:      now,
+		}
+
+		current, ok := existing[target.Target]
+		if ok && sameServiceNowRecord(current, record) {
+			unchanged++
+			continue
+		}
+		if ok {
+			err = s.sendRequest(http.MethodPatch, fmt.Sprintf("%s/%s", s.tableURL(), current.SysID), record)
+		} else {
+			err = s.sendRequest(http.MethodPost, s.tableURL(), record)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to export target %s", target.Target)
+		}
+	}
+
+	for name, record := range existing {
+		if discovered[name] || record.InstallStatus == serviceNowStatusRetired {
+			continue
+		}
+		log.Infof("Retiring ServiceNow record for target %s", name)
+		record.InstallStatus = serviceNowStatusRetired
+		err = s.sendRequest(http.MethodPatch, fmt.Sprintf("%s/%s", s.tableURL(), record.SysID), record)
+		if err != nil {
+			return errors.Wrapf(err, "failed to retire target %s", name)
+		}
+	}
+	log.Infof("Exported %d targets to ServiceNow table %s, %d were unchanged", len(targets), s.Table, unchanged)
+
+	return nil
+}
+
+// sameServiceNowRecord reports whether the stored record already has the
+// fields of the wanted one, ignoring the last discovered time.
+func sameServiceNowRecord(current, wanted serviceNowRecord) bool {
+	return current.Name == wanted.Name &&
+		current.InstallStatus == wanted.InstallStatus &&
+		current.Source == wanted.Source &&
+		current.Labels == wanted.Labels &&
+		current.ManagedBy == wanted.ManagedBy
+}
+
+// listRecords returns the ServiceNow records managed by this tool keyed by target name.
+// The records are requested a page at a time until a short page is returned.
+func (s *ServiceNow) listRecords() (map[string]serviceNowRecord, error) {
+	records := map[string]serviceNowRecord{}
+	for offset := 0; ; offset += serviceNowRecordPageSize {
+		page, err := s.listRecordsPage(offset)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list records at offset %d", offset)
+		}
+		for _, record := range page {
+			records[record.Name] = record
+		}
+		if len(page) < serviceNowRecordPageSize {
+			break
+		}
+	}
+
+	return records, nil
+}
+
+// listRecordsPage returns a single page of the ServiceNow records managed by this tool.
+func (s *ServiceNow) listRecordsPage(offset int) ([]serviceNowRecord, error) {
+	query := url.Values{}
+	query.Set("sysparm_query", fmt.Sprintf("u_managed_by=%s^ORDERBYsys_id", serviceNowManagedBy))
+	query.Set("sysparm_limit", fmt.Sprintf("%d", serviceNowRecordPageSize))
+	query.Set("sysparm_offset", fmt.Sprintf("%d", offset))
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s?%s", s.tableURL(), query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(s.Username, s.Password)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send HTTP request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var list serviceNowRecordList
+	err = json.NewDecoder(resp.Body).Decode(&list)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode ServiceNow response")
+	}
+
+	return list.Result, nil
+}
+
+// sendRequest sends a record to the ServiceNow Table API.
+func (s *ServiceNow) sendRequest(method, requestURL string, record serviceNowRecord) error {
+	record.SysID = ""
+	body, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(method, requestURL, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.Username, s.Password)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send HTTP request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// inventoryLabels returns the labels of a target including its job and module.
+func inventoryLabels(target discovery.Target) map[string]string {
+	labels := map[string]string{"job": target.Job, "module": target.Module}
+	for name, value := range target.Labels {
+		labels[name] = value
+	}
+
+	return labels
+}
+
+func (s *ServiceNow) tableURL() string {
+	return fmt.Sprintf("%s/api/now/table/%s", s.URL, s.Table)
+}
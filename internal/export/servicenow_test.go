@@ -0,0 +1,114 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+)
+
+// fakeServiceNow is an in-memory ServiceNow Table API serving one table.
+type fakeServiceNow struct {
+	mu      sync.Mutex
+	records []serviceNowRecord
+	offsets []int
+	writes  []string
+}
+
+func (f *fakeServiceNow) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const tablePath = "/api/now/table/cmdb_ci_endpoint"
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == tablePath:
+		offset, _ := strconv.Atoi(r.URL.Query().Get("sysparm_offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("sysparm_limit"))
+		f.offsets = append(f.offsets, offset)
+		end := offset + limit
+		if end > len(f.records) {
+			end = len(f.records)
+		}
+		page := serviceNowRecordList{Result: []serviceNowRecord{}}
+		if offset < end {
+			page.Result = f.records[offset:end]
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	case r.Method == http.MethodPost && r.URL.Path == tablePath:
+		var record serviceNowRecord
+		_ = json.NewDecoder(r.Body).Decode(&record)
+		record.SysID = fmt.Sprintf("new-%d", len(f.records))
+		f.records = append(f.records, record)
+		f.writes = append(f.writes, "create "+record.Name)
+	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, tablePath+"/"):
+		sysID := strings.TrimPrefix(r.URL.Path, tablePath+"/")
+		var record serviceNowRecord
+		_ = json.NewDecoder(r.Body).Decode(&record)
+		for i := range f.records {
+			if f.records[i].SysID == sysID {
+				record.SysID = sysID
+				f.records[i] = record
+				f.writes = append(f.writes, fmt.Sprintf("update %s %s", record.Name, record.InstallStatus))
+			}
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// TestServiceNowExport exports targets to a table spanning two pages and
+// checks that only new, changed and removed targets are written.
+func TestServiceNowExport(t *testing.T) {
+	unchanged := discovery.Target{Target: "unchanged.example.com", Source: "route53:ZPUBLIC", Job: "blackbox", Module: "http_2xx"}
+	changed := discovery.Target{Target: "changed.example.com", Source: "route53:ZPUBLIC", Job: "blackbox", Module: "http_2xx"}
+	created := discovery.Target{Target: "created.example.com", Source: "route53:ZPUBLIC", Job: "blackbox", Module: "http_2xx"}
+	labels, err := json.Marshal(inventoryLabels(unchanged))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeServiceNow{}
+	// Fill more than a page with retired records, so the records of the
+	// targets are only found on the second page.
+	for i := 0; i < serviceNowRecordPageSize; i++ {
+		fake.records = append(fake.records, serviceNowRecord{
+			SysID:         fmt.Sprintf("retired-%d", i),
+			Name:          fmt.Sprintf("retired-%d.example.com", i),
+			InstallStatus: serviceNowStatusRetired,
+			ManagedBy:     serviceNowManagedBy,
+		})
+	}
+	fake.records = append(fake.records,
+		serviceNowRecord{SysID: "unchanged", Name: unchanged.Target, InstallStatus: serviceNowStatusInstalled, Source: unchanged.Source, Labels: string(labels), ManagedBy: serviceNowManagedBy, LastSeen: "2020-01-01 00:00:00"},
+		serviceNowRecord{SysID: "changed", Name: changed.Target, InstallStatus: serviceNowStatusInstalled, Source: "route53:ZOLD", Labels: string(labels), ManagedBy: serviceNowManagedBy},
+		serviceNowRecord{SysID: "removed", Name: "removed.example.com", InstallStatus: serviceNowStatusInstalled, ManagedBy: serviceNowManagedBy},
+	)
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	err = NewServiceNow(server.URL, "user", "password", "cmdb_ci_endpoint").Export([]discovery.Target{unchanged, changed, created})
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+
+	if want := []int{0, serviceNowRecordPageSize}; fmt.Sprint(fake.offsets) != fmt.Sprint(want) {
+		t.Errorf("listed pages at offsets %v, want %v", fake.offsets, want)
+	}
+	writes := append([]string{}, fake.writes...)
+	sort.Strings(writes)
+	want := []string{
+		"create created.example.com",
+		"update changed.example.com " + serviceNowStatusInstalled,
+		"update removed.example.com " + serviceNowStatusRetired,
+	}
+	if fmt.Sprint(writes) != fmt.Sprint(want) {
+		t.Errorf("got writes %q, want %q", writes, want)
+	}
+}
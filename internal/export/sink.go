@@ -0,0 +1,42 @@
+package export
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// EncryptingObjectStore uploads objects encrypted with server-side encryption
+// using a KMS key.
+type EncryptingObjectStore interface {
+	PutEncryptedObject(bucket, key string, data []byte, contentType, kmsKeyID string) error
+}
+
+// ConfigSink uploads the rendered scrape config or file_sd targets to a
+// fixed object, for Prometheus instances that sync their config from S3.
+type ConfigSink struct {
+	Store  ObjectStore
+	Bucket string
+	Key    string
+	// KMSKeyID encrypts the object with SSE-KMS using the key, if set.
+	KMSKeyID string
+}
+
+// Upload replaces the object with the data.
+func (s *ConfigSink) Upload(data []byte, contentType string) error {
+	var err error
+	if len(s.KMSKeyID) > 0 {
+		store, ok := s.Store.(EncryptingObjectStore)
+		if !ok {
+			return errors.New("the object store does not support SSE-KMS encryption")
+		}
+		err = store.PutEncryptedObject(s.Bucket, s.Key, data, contentType, s.KMSKeyID)
+	} else {
+		err = s.Store.PutObject(s.Bucket, s.Key, data, contentType)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to upload to s3://%s/%s", s.Bucket, s.Key)
+	}
+	log.Infof("Uploaded %d bytes to s3://%s/%s", len(data), s.Bucket, s.Key)
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package fake
+
+import (
+	"sync"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/notify"
+)
+
+// Notification is an error notification sent to the fake notifier.
+type Notification struct {
+	Message string
+	Error   string
+}
+
+// Notifier records the notifications it receives.
+type Notifier struct {
+	mu            sync.Mutex
+	notifications []Notification
+	changes       []*notify.ChangeEvent
+}
+
+// SendChanges records a change summary.
+func (n *Notifier) SendChanges(event *notify.ChangeEvent) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.changes = append(n.changes, event)
+
+	return nil
+}
+
+// Changes returns the change summaries received so far.
+func (n *Notifier) Changes() []*notify.ChangeEvent {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return append([]*notify.ChangeEvent{}, n.changes...)
+}
+
+// SendError records an error notification.
+func (n *Notifier) SendError(err error, message string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.notifications = append(n.notifications, Notification{Message: message, Error: err.Error()})
+
+	return nil
+}
+
+// Notifications returns the notifications received so far.
+func (n *Notifier) Notifications() []Notification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return append([]Notification{}, n.notifications...)
+}
@@ -0,0 +1,68 @@
+package fake
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Object is an object stored in the fake object store.
+type Object struct {
+	Data         []byte
+	ContentType  string
+	LastModified time.Time
+	// KMSKeyID is the KMS key the object was encrypted with, if any.
+	KMSKeyID string
+}
+
+// ObjectStore is an in-memory object store keyed by bucket and key.
+type ObjectStore struct {
+	mu      sync.Mutex
+	objects map[string]map[string]Object
+}
+
+// PutObject stores an object.
+func (s *ObjectStore) PutObject(bucket, key string, data []byte, contentType string) error {
+	return s.PutEncryptedObject(bucket, key, data, contentType, "")
+}
+
+// PutEncryptedObject stores an object together with its KMS key.
+func (s *ObjectStore) PutEncryptedObject(bucket, key string, data []byte, contentType, kmsKeyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.objects == nil {
+		s.objects = map[string]map[string]Object{}
+	}
+	if s.objects[bucket] == nil {
+		s.objects[bucket] = map[string]Object{}
+	}
+	s.objects[bucket][key] = Object{Data: data, ContentType: contentType, LastModified: time.Now(), KMSKeyID: kmsKeyID}
+
+	return nil
+}
+
+// DeleteObjectsBefore deletes the objects under the prefix last modified before the cutoff.
+func (s *ObjectStore) DeleteObjectsBefore(bucket, prefix string, cutoff time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	for key, object := range s.objects[bucket] {
+		if strings.HasPrefix(key, prefix) && object.LastModified.Before(cutoff) {
+			delete(s.objects[bucket], key)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// Object returns a stored object.
+func (s *ObjectStore) Object(bucket, key string) (Object, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	object, ok := s.objects[bucket][key]
+	return object, ok
+}
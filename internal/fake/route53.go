@@ -0,0 +1,120 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/pkg/errors"
+)
+
+const defaultRoute53PageSize = 100
+
+// Route53 is an in-memory implementation of the Route53 API serving fixture hosted zones.
+type Route53 struct {
+	// PageSize is the number of record sets returned per page.
+	PageSize int
+	// Errors maps hosted zone IDs to the error returned when listing them.
+	Errors map[string]error
+
+	mu    sync.Mutex
+	zones map[string][]*route53types.ResourceRecordSet
+	calls int
+}
+
+// NewRoute53 creates a fake Route53 API serving the given hosted zones.
+func NewRoute53(zones map[string][]*route53types.ResourceRecordSet) *Route53 {
+	f := &Route53{
+		PageSize: defaultRoute53PageSize,
+		Errors:   map[string]error{},
+		zones:    map[string][]*route53types.ResourceRecordSet{},
+	}
+	for zoneID, records := range zones {
+		f.SetRecords(zoneID, records)
+	}
+
+	return f
+}
+
+// LoadZones reads hosted zone fixtures from a JSON file mapping hosted zone IDs to record sets.
+func LoadZones(path string) (map[string][]*route53types.ResourceRecordSet, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read zone fixtures %s", path)
+	}
+
+	zones := map[string][]*route53types.ResourceRecordSet{}
+	err = json.Unmarshal(data, &zones)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse zone fixtures %s", path)
+	}
+
+	return zones, nil
+}
+
+// SetRecords replaces the record sets of a hosted zone.
+func (f *Route53) SetRecords(zoneID string, records []*route53types.ResourceRecordSet) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sorted := append([]*route53types.ResourceRecordSet{}, records...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return recordKey(sorted[i]) < recordKey(sorted[j])
+	})
+	f.zones[zoneID] = sorted
+}
+
+// Calls returns the number of ListResourceRecordSets calls made.
+func (f *Route53) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.calls
+}
+
+// ListResourceRecordSets returns a page of record sets starting at the requested record.
+func (f *Route53) ListResourceRecordSets(ctx context.Context, input *route53.ListResourceRecordSetsInput, opts ...func(*route53.Options)) (*route53.ListResourceRecordSetsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+
+	zoneID := aws.ToString(input.HostedZoneId)
+	if err, ok := f.Errors[zoneID]; ok {
+		return nil, err
+	}
+	records, ok := f.zones[zoneID]
+	if !ok {
+		return nil, errors.Errorf("NoSuchHostedZone: hosted zone %s not found", zoneID)
+	}
+
+	start := aws.ToString(input.StartRecordName) + "\x00" + string(input.StartRecordType) + "\x00" + aws.ToString(input.StartRecordIdentifier)
+	i := sort.Search(len(records), func(i int) bool {
+		return recordKey(records[i]) >= start
+	})
+
+	end := i + f.PageSize
+	output := &route53.ListResourceRecordSetsOutput{}
+	if end < len(records) {
+		next := records[end]
+		output.IsTruncated = true
+		output.NextRecordName = next.Name
+		output.NextRecordType = next.Type
+		output.NextRecordIdentifier = next.SetIdentifier
+	} else {
+		end = len(records)
+	}
+	for _, record := range records[i:end] {
+		output.ResourceRecordSets = append(output.ResourceRecordSets, *record)
+	}
+
+	return output, nil
+}
+
+func recordKey(record *route53types.ResourceRecordSet) string {
+	return aws.ToString(record.Name) + "\x00" + string(record.Type) + "\x00" + aws.ToString(record.SetIdentifier)
+}
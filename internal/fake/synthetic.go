@@ -0,0 +1,56 @@
+package fake
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+)
+
+var syntheticRegions = []string{"us-east-1", "us-west-2", "eu-west-1", "ap-southeast-1"}
+
+// SyntheticZones generates hosted zones of the given sizes for performance
+// testing. The public zone holds latency routed installation records spread
+// over several regions, every tenth of them hibernating. Every fifth record
+// of the private zone is a gRPC endpoint.
+func SyntheticZones(publicZoneID string, publicRecords int, privateZoneID string, privateRecords int) map[string][]*route53types.ResourceRecordSet {
+	public := make([]*route53types.ResourceRecordSet, 0, publicRecords)
+	for i := 0; i < publicRecords; i++ {
+		region := syntheticRegions[i%len(syntheticRegions)]
+		identifier := fmt.Sprintf("installation-%07d", i)
+		if i%10 == 9 {
+			identifier += " [hibernating]"
+		}
+		public = append(public, &route53types.ResourceRecordSet{
+			Name:          aws.String(fmt.Sprintf("installation-%07d.cloud.example.com.", i)),
+			Type:          route53types.RRType("CNAME"),
+			TTL:           aws.Int64(60),
+			SetIdentifier: aws.String(identifier),
+			Region:        route53types.ResourceRecordSetRegion(region),
+			ResourceRecords: []route53types.ResourceRecord{
+				{Value: aws.String(fmt.Sprintf("lb-%d.%s.elb.amazonaws.com", i%50, region))},
+			},
+		})
+	}
+
+	private := make([]*route53types.ResourceRecordSet, 0, privateRecords)
+	for i := 0; i < privateRecords; i++ {
+		name := fmt.Sprintf("service-%06d.internal.example.com.", i)
+		if i%5 == 4 {
+			name = fmt.Sprintf("service-%06d-grpc.internal.example.com.", i)
+		}
+		private = append(private, &route53types.ResourceRecordSet{
+			Name: aws.String(name),
+			Type: route53types.RRType("CNAME"),
+			TTL:  aws.Int64(60),
+			ResourceRecords: []route53types.ResourceRecord{
+				{Value: aws.String(fmt.Sprintf("internal-lb-%d.us-east-1.elb.amazonaws.com", i%20))},
+			},
+		})
+	}
+
+	return map[string][]*route53types.ResourceRecordSet{
+		publicZoneID:  public,
+		privateZoneID: private,
+	}
+}
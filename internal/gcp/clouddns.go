@@ -0,0 +1,228 @@
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+)
+
+const (
+	// DefaultAPIURL is the Cloud DNS API v1 endpoint.
+	DefaultAPIURL = "https://dns.googleapis.com/dns/v1"
+
+	// readOnlyScope allows reading the Cloud DNS managed zones and records.
+	readOnlyScope = "https://www.googleapis.com/auth/ndev.clouddns.readonly"
+	// metadataTokenURL returns tokens of the service account of the workload.
+	metadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+	// defaultTokenURL signs in service accounts whose key file has no token URI.
+	defaultTokenURL = "https://oauth2.googleapis.com/token"
+
+	requestTimeout = 30 * time.Second
+)
+
+// Client lists the record sets of Cloud DNS managed zones.
+type Client struct {
+	// Project is the Google Cloud project of the managed zones.
+	Project string
+	// APIURL is the Cloud DNS API endpoint, DefaultAPIURL if empty.
+	APIURL string
+
+	httpClient *http.Client
+}
+
+// serviceAccountKey is the part of a service account key file used to sign tokens.
+type serviceAccountKey struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// NewClient creates a Cloud DNS client. It authenticates with the service
+// account key file if one is given, and otherwise with the service account
+// of the workload from the metadata server, such as with GKE workload identity.
+func NewClient(project, credentialsFile string) (*Client, error) {
+	tokenSource := oauth2.ReuseTokenSource(nil, metadataTokenSource{})
+	if len(credentialsFile) > 0 {
+		data, err := ioutil.ReadFile(credentialsFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read the Google Cloud credentials %s", credentialsFile)
+		}
+		var key serviceAccountKey
+		err = json.Unmarshal(data, &key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the Google Cloud credentials %s", credentialsFile)
+		}
+		if key.Type != "service_account" {
+			return nil, errors.Errorf("the Google Cloud credentials %s are not a service account key", credentialsFile)
+		}
+		if len(key.TokenURI) == 0 {
+			key.TokenURI = defaultTokenURL
+		}
+		config := &jwt.Config{
+			Email:        key.ClientEmail,
+			PrivateKey:   []byte(key.PrivateKey),
+			PrivateKeyID: key.PrivateKeyID,
+			Scopes:       []string{readOnlyScope},
+			TokenURL:     key.TokenURI,
+		}
+		tokenSource = config.TokenSource(context.Background())
+	}
+
+	httpClient := oauth2.NewClient(context.Background(), tokenSource)
+	httpClient.Timeout = requestTimeout
+
+	return &Client{Project: project, httpClient: httpClient}, nil
+}
+
+// metadataTokenSource gets access tokens of the service account of the
+// workload from the metadata server.
+type metadataTokenSource struct{}
+
+// Token requests an access token from the metadata server.
+func (metadataTokenSource) Token() (*oauth2.Token, error) {
+	req, err := http.NewRequest(http.MethodGet, metadataTokenURL+"?scopes="+url.QueryEscape(readOnlyScope), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	client := &http.Client{Timeout: requestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get a token from the metadata server")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to get a token from the metadata server with status code %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+		TokenType   string `json:"token_type"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&token)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode the metadata server token")
+	}
+
+	return &oauth2.Token{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		Expiry:      time.Now().Add(time.Duration(token.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// resourceRecordSet is a record set of the Cloud DNS API.
+type resourceRecordSet struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	TTL     int64    `json:"ttl"`
+	RRDatas []string `json:"rrdatas"`
+}
+
+// resourceRecordSetsResponse is a page of the record sets of a managed zone.
+type resourceRecordSetsResponse struct {
+	RRSets        []resourceRecordSet `json:"rrsets"`
+	NextPageToken string              `json:"nextPageToken"`
+	Error         *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// ListAllRecordSets returns the CNAME records of a managed zone as Route53
+// record sets, so they are discovered like the records of a public hosted
+// zone. Every CNAME record counts as an installation record and is given
+// its name as the set identifier.
+func (c *Client) ListAllRecordSets(managedZone string) ([]*route53types.ResourceRecordSet, error) {
+	var rrsets []*route53types.ResourceRecordSet
+	pageToken := ""
+	for {
+		resp, err := c.listRecordSets(managedZone, pageToken)
+		if err != nil {
+			return nil, err
+		}
+		for _, rrset := range resp.RRSets {
+			// The API only filters by type together with a record name.
+			if rrset.Type == "CNAME" {
+				rrsets = append(rrsets, recordSet(rrset))
+			}
+		}
+		if len(resp.NextPageToken) == 0 {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+
+	return rrsets, nil
+}
+
+// CheckHostedZone verifies that the credentials can read the records of a managed zone.
+func (c *Client) CheckHostedZone(managedZone string) error {
+	_, err := c.listRecordSets(managedZone, "")
+
+	return err
+}
+
+// listRecordSets returns a page of the record sets of a managed zone.
+func (c *Client) listRecordSets(managedZone, pageToken string) (*resourceRecordSetsResponse, error) {
+	apiURL := c.APIURL
+	if len(apiURL) == 0 {
+		apiURL = DefaultAPIURL
+	}
+	query := url.Values{}
+	if len(pageToken) > 0 {
+		query.Set("pageToken", pageToken)
+	}
+	requestURL := fmt.Sprintf("%s/projects/%s/managedZones/%s/rrsets?%s", strings.TrimSuffix(apiURL, "/"), url.PathEscape(c.Project), url.PathEscape(managedZone), query.Encode())
+
+	resp, err := c.httpClient.Get(requestURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send HTTP request")
+	}
+	defer resp.Body.Close()
+
+	var rrsets resourceRecordSetsResponse
+	err = json.NewDecoder(resp.Body).Decode(&rrsets)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode the record sets of managed zone %s with status code %d", managedZone, resp.StatusCode)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message := http.StatusText(resp.StatusCode)
+		if rrsets.Error != nil {
+			message = rrsets.Error.Message
+		}
+		return nil, errors.Errorf("failed to list the record sets of managed zone %s with status code %d: %s", managedZone, resp.StatusCode, message)
+	}
+
+	return &rrsets, nil
+}
+
+// recordSet converts a Cloud DNS record set to a Route53 record set.
+func recordSet(rrset resourceRecordSet) *route53types.ResourceRecordSet {
+	records := make([]route53types.ResourceRecord, 0, len(rrset.RRDatas))
+	for _, value := range rrset.RRDatas {
+		records = append(records, route53types.ResourceRecord{Value: aws.String(value)})
+	}
+
+	return &route53types.ResourceRecordSet{
+		Name:            aws.String(rrset.Name),
+		Type:            route53types.RRType(rrset.Type),
+		TTL:             aws.Int64(rrset.TTL),
+		SetIdentifier:   aws.String("clouddns-" + strings.TrimSuffix(rrset.Name, ".")),
+		ResourceRecords: records,
+	}
+}
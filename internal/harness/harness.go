@@ -0,0 +1,131 @@
+// Package harness runs the full Blackbox target discovery reconcile path
+// against fake AWS, Kubernetes and notification backends.
+package harness
+
+import (
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	awsclient "github.com/mattermost/cloud-blackbox-target-discovery/internal/aws"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/fake"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/k8s"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/reconcile"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+	"github.com/pkg/errors"
+
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	// PublicZoneID is the public hosted zone of the default fixtures.
+	PublicZoneID = "ZPUBLIC"
+	// PrivateZoneID is the private hosted zone of the default fixtures.
+	PrivateZoneID = "ZPRIVATE"
+)
+
+// Environment is a reconciler wired to fake backends.
+type Environment struct {
+	Config     *reconcile.Config
+	Route53    *fake.Route53
+	Clientset  *k8sfake.Clientset
+	Objects    *fake.ObjectStore
+	Notifier   *fake.Notifier
+	Reconciler *reconcile.Reconciler
+}
+
+// DefaultConfig returns a configuration matching the default fixtures. The
+// scrape config template path is relative to the caller's working directory.
+func DefaultConfig(scrapeConfigTemplate string) *reconcile.Config {
+	return &reconcile.Config{
+		PublicHostedZoneIDs:  []string{PublicZoneID},
+		PrivateHostedZoneIDs: []string{PrivateZoneID},
+		PrometheusNamespace:  "prometheus",
+		PrometheusSecretName: "blackbox-scrape-config",
+		DevMode:              "false",
+		ScrapeConfigTemplate: scrapeConfigTemplate,
+		StateConfigMapName:   "blackbox-target-discovery-state",
+		StatusConfigMapName:  "blackbox-target-discovery-status",
+		OutputKind:           reconcile.OutputSecret,
+		LatencyBudgets:       map[string]string{},
+	}
+}
+
+// NewEnvironment creates an environment serving the given hosted zones.
+func NewEnvironment(config *reconcile.Config, zones map[string][]*route53types.ResourceRecordSet) *Environment {
+	env := &Environment{
+		Config:    config,
+		Route53:   fake.NewRoute53(zones),
+		Clientset: k8sfake.NewSimpleClientset(),
+		Objects:   &fake.ObjectStore{},
+		Notifier:  &fake.Notifier{},
+	}
+
+	kubeClient := k8s.NewClientFromClientset(env.Clientset)
+	env.Reconciler = reconcile.New(config, &reconcile.Clients{
+		Records:    awsclient.NewClientWithAPIs(env.Route53, nil),
+		Objects:    env.Objects,
+		Secrets:    kubeClient,
+		ConfigMaps: kubeClient,
+		Notifier:   env.Notifier,
+	})
+
+	return env
+}
+
+// NewEnvironmentFromFixtures creates an environment serving the hosted zones of a fixture file.
+func NewEnvironmentFromFixtures(config *reconcile.Config, zonesFixture string) (*Environment, error) {
+	zones, err := fake.LoadZones(zonesFixture)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEnvironment(config, zones), nil
+}
+
+// Reconcile runs the reconcile path the same way a scheduled run does,
+// including run tracking and error notification.
+func (e *Environment) Reconcile() (*reconcile.Report, error) {
+	report := reconcile.NewReport()
+	err := e.Reconciler.Run(report)
+	report.Complete(err)
+
+	trackErr := e.Reconciler.TrackRunOutcome(report)
+	if trackErr != nil {
+		return report, errors.Wrap(trackErr, "failed to track the run outcome")
+	}
+
+	if err != nil {
+		sendErr := e.Notifier.SendError(err, "The Blackbox target discovery failed")
+		if sendErr != nil {
+			return report, sendErr
+		}
+	}
+
+	return report, err
+}
+
+// ScrapeConfig returns the scrape config currently stored in the Prometheus secret.
+func (e *Environment) ScrapeConfig() (render.Config, error) {
+	data, err := e.Reconciler.CurrentScrapeConfig(e.Config.PrometheusNamespace, e.Config.PrometheusSecretName)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, errors.New("the Prometheus secret has not been created")
+	}
+
+	return render.Parse(data)
+}
+
+// JobTargets returns the targets of a scrape job in the Prometheus secret.
+func (e *Environment) JobTargets(jobName string) ([]string, error) {
+	config, err := e.ScrapeConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	i := config.FindJob(jobName)
+	if i < 0 {
+		return nil, errors.Errorf("scrape config has no job named %s", jobName)
+	}
+
+	return config[i].Targets(), nil
+}
@@ -0,0 +1,148 @@
+package harness
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/fake"
+	"github.com/pkg/errors"
+)
+
+const (
+	scrapeConfigTemplate = "../../scrapeconfig.yml"
+	zonesFixture         = "testdata/zones.json"
+)
+
+// TestReconcile runs the reconcile path against the default fixtures and
+// checks the targets of the jobs in the Prometheus secret.
+func TestReconcile(t *testing.T) {
+	tests := []struct {
+		name        string
+		bindServers []string
+		zoneErrors  map[string]error
+		wantErr     bool
+		wantJobs    map[string][]string
+	}{
+		{
+			name: "fixtures",
+			wantJobs: map[string][]string{
+				"blackbox": {
+					"customer-a.cloud.example.com/api/v4/system/ping",
+					"customer-b.cloud.example.com/api/v4/system/ping",
+					"provisioner-grpc.internal.example.com.:9090",
+				},
+				"bind-server-1": nil,
+				"bind-server-2": nil,
+				"bind-server-3": nil,
+			},
+		},
+		{
+			name:        "bind servers",
+			bindServers: []string{"10.0.0.1:53", "10.0.0.2:53"},
+			wantJobs: map[string][]string{
+				"bind-server-1": {"10.0.0.1:53"},
+				"bind-server-2": {"10.0.0.2:53"},
+				"bind-server-3": nil,
+			},
+		},
+		{
+			name:        "all bind servers",
+			bindServers: []string{"10.0.0.1:53", "10.0.0.2:53", "10.0.0.3:53"},
+			wantJobs: map[string][]string{
+				"bind-server-1": {"10.0.0.1:53"},
+				"bind-server-2": {"10.0.0.2:53"},
+				"bind-server-3": {"10.0.0.3:53"},
+			},
+		},
+		{
+			name:       "failed zone",
+			zoneErrors: map[string]error{PublicZoneID: errors.New("AccessDenied")},
+			wantErr:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := DefaultConfig(scrapeConfigTemplate)
+			config.BindServers = test.bindServers
+			env, err := NewEnvironmentFromFixtures(config, zonesFixture)
+			if err != nil {
+				t.Fatalf("failed to create environment: %v", err)
+			}
+			for zoneID, zoneErr := range test.zoneErrors {
+				env.Route53.Errors[zoneID] = zoneErr
+			}
+
+			report, err := env.Reconcile()
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("expected the run to fail")
+				}
+				if report.Success {
+					t.Error("expected the report to record the failure")
+				}
+				if len(env.Notifier.Notifications()) != 1 {
+					t.Errorf("expected one error notification, got %d", len(env.Notifier.Notifications()))
+				}
+				if _, err = env.ScrapeConfig(); err == nil {
+					t.Error("expected no Prometheus secret after a failed first run")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to reconcile: %v", err)
+			}
+			if !report.Success {
+				t.Errorf("expected a successful report, got error %q", report.Error)
+			}
+
+			for jobName, want := range test.wantJobs {
+				targets, err := env.JobTargets(jobName)
+				if err != nil {
+					t.Fatalf("failed to get the targets of job %s: %v", jobName, err)
+				}
+				if len(targets) == 0 && len(want) == 0 {
+					continue
+				}
+				if !reflect.DeepEqual(targets, want) {
+					t.Errorf("job %s has targets %v, want %v", jobName, targets, want)
+				}
+			}
+		})
+	}
+}
+
+// TestReconcileRecordChanges checks that a second run drops the target of a
+// record removed from a hosted zone.
+func TestReconcileRecordChanges(t *testing.T) {
+	env, err := NewEnvironmentFromFixtures(DefaultConfig(scrapeConfigTemplate), zonesFixture)
+	if err != nil {
+		t.Fatalf("failed to create environment: %v", err)
+	}
+	_, err = env.Reconcile()
+	if err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+
+	zones, err := fake.LoadZones(zonesFixture)
+	if err != nil {
+		t.Fatalf("failed to load fixtures: %v", err)
+	}
+	env.Route53.SetRecords(PublicZoneID, zones[PublicZoneID][1:])
+
+	_, err = env.Reconcile()
+	if err != nil {
+		t.Fatalf("failed to reconcile after the change: %v", err)
+	}
+	targets, err := env.JobTargets("blackbox")
+	if err != nil {
+		t.Fatalf("failed to get the blackbox targets: %v", err)
+	}
+	want := []string{
+		"customer-b.cloud.example.com/api/v4/system/ping",
+		"provisioner-grpc.internal.example.com.:9090",
+	}
+	if !reflect.DeepEqual(targets, want) {
+		t.Errorf("blackbox job has targets %v, want %v", targets, want)
+	}
+}
@@ -0,0 +1,86 @@
+package httpsd
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+)
+
+// Group is a target group in the Prometheus HTTP service discovery format.
+type Group struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels,omitempty"`
+}
+
+// Groups groups the targets sharing a job, module and labels. The job of a
+// target is set as its job label, so a single http_sd_configs block keeps the
+// targets in the same jobs as the scrape config secret.
+func Groups(targets []discovery.Target) []Group {
+	jobs := map[string][]discovery.Target{}
+	var jobNames []string
+	for _, target := range targets {
+		if _, ok := jobs[target.Job]; !ok {
+			jobNames = append(jobNames, target.Job)
+		}
+		jobs[target.Job] = append(jobs[target.Job], target)
+	}
+	sort.Strings(jobNames)
+
+	groups := []Group{}
+	for _, jobName := range jobNames {
+		jobTargets := jobs[jobName]
+		sort.SliceStable(jobTargets, func(i, j int) bool {
+			return jobTargets[i].Target < jobTargets[j].Target
+		})
+		for _, staticConfig := range render.GroupStaticConfigs(map[string]string{"job": jobName}, jobTargets) {
+			groups = append(groups, Group{Targets: staticConfig.Targets, Labels: staticConfig.Labels})
+		}
+	}
+
+	return groups
+}
+
+// Server serves the most recently discovered targets to Prometheus.
+type Server struct {
+	mu      sync.RWMutex
+	groups  []Group
+	updated time.Time
+}
+
+// NewServer creates a Server without targets.
+func NewServer() *Server {
+	return &Server{}
+}
+
+// Update replaces the served targets.
+func (s *Server) Update(targets []discovery.Target) {
+	groups := Groups(targets)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.groups = groups
+	s.updated = time.Now()
+}
+
+// ServeHTTP serves the target groups as JSON. It responds with 503 Service
+// Unavailable until the first discovery has completed, so Prometheus does not
+// drop its targets while the server starts.
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.RLock()
+	groups, updated := s.groups, s.updated
+	s.mu.RUnlock()
+
+	if updated.IsZero() {
+		http.Error(w, "no targets discovered yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Last-Modified", updated.UTC().Format(http.TimeFormat))
+	json.NewEncoder(w).Encode(groups)
+}
@@ -0,0 +1,43 @@
+package k8s
+
+import (
+	"context"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// certificateResource is the cert-manager Certificate custom resource.
+var certificateResource = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+
+// ListCertificates returns the cert-manager Certificates in all namespaces
+// with the DNS names they cover.
+func (c *Client) ListCertificates() ([]discovery.Certificate, error) {
+	if c.dynamic == nil {
+		return nil, errors.New("the Kubernetes client does not support custom resources")
+	}
+
+	list, err := c.dynamic.Resource(certificateResource).Namespace(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the cert-manager certificates")
+	}
+
+	var certificates []discovery.Certificate
+	for _, item := range list.Items {
+		dnsNames, _, err := unstructured.NestedStringSlice(item.Object, "spec", "dnsNames")
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid dnsNames in certificate %s/%s", item.GetNamespace(), item.GetName())
+		}
+		certificates = append(certificates, discovery.Certificate{
+			Namespace: item.GetNamespace(),
+			Name:      item.GetName(),
+			DNSNames:  dnsNames,
+		})
+	}
+
+	return certificates, nil
+}
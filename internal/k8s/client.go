@@ -0,0 +1,111 @@
+package k8s
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Client provides access to the Kubernetes resources managed by the Blackbox target discovery.
+type Client struct {
+	clientset kubernetes.Interface
+	dynamic   dynamic.Interface
+	// serverSideApply writes secrets with server-side apply, which fake
+	// clientsets don't support.
+	serverSideApply bool
+	forceConflicts  bool
+}
+
+// NewClient creates a client from the in-cluster config, or in developer mode
+// from the given context of the local kubeconfig. An empty context selects the
+// kubeconfig's current context.
+func NewClient(devMode bool, context string) (*Client, error) {
+	config, err := getConfig(devMode, context)
+	if err != nil {
+		return nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{clientset: clientset, dynamic: dynamicClient, serverSideApply: true}, nil
+}
+
+// NewClientFromClientset creates a client using an existing clientset.
+// Custom resources cannot be managed by such a client, and secrets are
+// written with updates instead of server-side apply.
+func NewClientFromClientset(clientset kubernetes.Interface) *Client {
+	return &Client{clientset: clientset}
+}
+
+// SetForceConflicts makes server-side apply take over the fields of the
+// secrets that another field manager changed, instead of failing.
+func (c *Client) SetForceConflicts(force bool) {
+	c.forceConflicts = force
+}
+
+// ResolveContext returns the name of the local kubeconfig context that a
+// developer mode client created with the given context would use.
+func ResolveContext(context string) (string, error) {
+	rawConfig, err := localConfig(context).RawConfig()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to load the local kubeconfig")
+	}
+	if len(context) > 0 {
+		if _, ok := rawConfig.Contexts[context]; !ok {
+			return "", errors.Errorf("context %s not found in the local kubeconfig", context)
+		}
+		return context, nil
+	}
+
+	return rawConfig.CurrentContext, nil
+}
+
+// ListContexts returns the names of the contexts of the local kubeconfig.
+func ListContexts() ([]string, error) {
+	rawConfig, err := localConfig("").RawConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load the local kubeconfig")
+	}
+
+	var contexts []string
+	for name := range rawConfig.Contexts {
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+
+	return contexts, nil
+}
+
+// localConfig returns the local kubeconfig, honoring the KUBECONFIG environment
+// variable, with the given context selected.
+func localConfig(context string) clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if len(os.Getenv("KUBECONFIG")) == 0 {
+		loadingRules.ExplicitPath = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+	}
+
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{CurrentContext: context})
+}
+
+// getConfig gets the k8s client config
+func getConfig(devMode bool, context string) (*rest.Config, error) {
+	if devMode {
+		return localConfig(context).ClientConfig()
+	}
+
+	return rest.InClusterConfig()
+}
@@ -0,0 +1,85 @@
+package k8s
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+// GetConfigMap gets a ConfigMap, returning nil if it doesn't exist
+func (c *Client) GetConfigMap(namespace, name string) (*corev1.ConfigMap, error) {
+	configMap, err := c.clientset.CoreV1().ConfigMaps(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil && k8sErrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return configMap, nil
+}
+
+// CreateOrUpdateConfigMap creates or update a ConfigMap. Like secrets, an
+// existing ConfigMap that already has the same data, labels and annotations
+// is left untouched. Writes that conflict with a concurrent change, including
+// a create racing another one, are retried with the latest version of the
+// ConfigMap.
+func (c *Client) CreateOrUpdateConfigMap(namespace string, configMap *corev1.ConfigMap) (metav1.Object, error) {
+	ctx := context.TODO()
+	var result *corev1.ConfigMap
+	err := retry.OnError(retry.DefaultRetry, isWriteConflict, func() error {
+		var err error
+		result, err = c.updateConfigMap(ctx, namespace, configMap)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// updateConfigMap creates the ConfigMap or updates the latest version of it.
+func (c *Client) updateConfigMap(ctx context.Context, namespace string, configMap *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	existing, err := c.clientset.CoreV1().ConfigMaps(namespace).Get(ctx, configMap.Name, metav1.GetOptions{})
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	if err != nil && k8sErrors.IsNotFound(err) {
+		return c.clientset.CoreV1().ConfigMaps(namespace).Create(ctx, configMap, metav1.CreateOptions{})
+	}
+
+	if sameStringData(existing.Data, configMap.Data) && hasValues(existing.Labels, configMap.Labels) && hasValues(existing.Annotations, configMap.Annotations) {
+		log.Infof("No changes detected in ConfigMap %s/%s, skipping update", namespace, configMap.Name)
+		return existing, nil
+	}
+
+	updated := configMap.DeepCopy()
+	updated.ResourceVersion = existing.ResourceVersion
+	return c.clientset.CoreV1().ConfigMaps(namespace).Update(ctx, updated, metav1.UpdateOptions{})
+}
+
+// isWriteConflict reports whether a write failed because the object was
+// created or changed concurrently, so it can be read again and retried.
+func isWriteConflict(err error) bool {
+	return k8sErrors.IsConflict(err) || k8sErrors.IsAlreadyExists(err)
+}
+
+func sameStringData(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || value != other {
+			return false
+		}
+	}
+
+	return true
+}
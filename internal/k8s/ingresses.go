@@ -0,0 +1,41 @@
+package k8s
+
+import (
+	"context"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ListProbedIngressHosts returns the hosts of the Ingresses in all namespaces
+// that carry the probe annotation set to "true".
+func (c *Client) ListProbedIngressHosts() ([]discovery.IngressHost, error) {
+	ingresses, err := c.clientset.NetworkingV1().Ingresses(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var hosts []discovery.IngressHost
+	for _, ingress := range ingresses.Items {
+		if ingress.Annotations[discovery.ProbeAnnotation] != "true" {
+			continue
+		}
+		tlsHosts := map[string]bool{}
+		for _, tls := range ingress.Spec.TLS {
+			for _, host := range tls.Hosts {
+				tlsHosts[host] = true
+			}
+		}
+		for _, rule := range ingress.Spec.Rules {
+			hosts = append(hosts, discovery.IngressHost{
+				Namespace: ingress.Namespace,
+				Ingress:   ingress.Name,
+				Host:      rule.Host,
+				TLS:       tlsHosts[rule.Host],
+			})
+		}
+	}
+
+	return hosts, nil
+}
@@ -0,0 +1,63 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// SyncResources makes the resources of a kind carrying the owner label match
+// the given objects. Missing objects are created, existing ones are updated
+// and the labeled resources that are no longer wanted are deleted. Every
+// object must carry the owner label.
+func (c *Client) SyncResources(namespace string, resource schema.GroupVersionResource, ownerLabel, owner string, objects []*unstructured.Unstructured) error {
+	if c.dynamic == nil {
+		return errors.New("the Kubernetes client does not support custom resources")
+	}
+
+	ctx := context.TODO()
+	client := c.dynamic.Resource(resource).Namespace(namespace)
+	existing, err := client.List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", ownerLabel, owner)})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the %s", resource.Resource)
+	}
+
+	wanted := map[string]bool{}
+	for _, object := range objects {
+		wanted[object.GetName()] = true
+		current, err := client.Get(ctx, object.GetName(), metav1.GetOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to get %s %s", resource.Resource, object.GetName())
+		}
+		if err != nil {
+			_, err = client.Create(ctx, object, metav1.CreateOptions{})
+			if err != nil {
+				return errors.Wrapf(err, "failed to create %s %s", resource.Resource, object.GetName())
+			}
+			continue
+		}
+
+		object.SetResourceVersion(current.GetResourceVersion())
+		_, err = client.Update(ctx, object, metav1.UpdateOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to update %s %s", resource.Resource, object.GetName())
+		}
+	}
+
+	for _, item := range existing.Items {
+		if wanted[item.GetName()] {
+			continue
+		}
+		err = client.Delete(ctx, item.GetName(), metav1.DeleteOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete %s %s", resource.Resource, item.GetName())
+		}
+	}
+
+	return nil
+}
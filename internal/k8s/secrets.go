@@ -0,0 +1,163 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+// GetSecret gets a secret, returning nil if it doesn't exist
+func (c *Client) GetSecret(namespace, name string) (*corev1.Secret, error) {
+	secret, err := c.clientset.CoreV1().Secrets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil && k8sErrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+// FieldManager is the field manager of the secrets applied by the Blackbox
+// target discovery.
+const FieldManager = "blackbox-target-discovery"
+
+// CreateOrUpdateSecret creates or update a secret with server-side apply as
+// FieldManager. Only the data, labels and annotations of the secret are owned,
+// so the fields of other controllers are kept, and fields changed by another
+// manager, such as a manual edit, fail with a conflict unless conflicts are
+// forced. A secret that already has the same data, labels and annotations is
+// neither applied nor updated, so its resourceVersion doesn't change and no
+// configuration reload is triggered. Clients without server-side apply update
+// the latest version of the secret, and an update that conflicts with a
+// concurrent change is retried after reading the secret again.
+func (c *Client) CreateOrUpdateSecret(namespace string, secret *corev1.Secret) (metav1.Object, error) {
+	ctx := context.TODO()
+	if c.serverSideApply {
+		return c.applySecret(ctx, namespace, secret)
+	}
+
+	var result *corev1.Secret
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var err error
+		result, err = c.updateSecret(ctx, namespace, secret)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// updateSecret creates the secret or updates the latest version of it.
+func (c *Client) updateSecret(ctx context.Context, namespace string, secret *corev1.Secret) (*corev1.Secret, error) {
+	existing, err := c.clientset.CoreV1().Secrets(namespace).Get(ctx, secret.Name, metav1.GetOptions{})
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	if err != nil && k8sErrors.IsNotFound(err) {
+		return c.clientset.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	}
+
+	if unchangedSecret(existing, secret) {
+		log.Infof("No changes detected in secret %s/%s, skipping update", namespace, secret.Name)
+		return existing, nil
+	}
+
+	updated := secret.DeepCopy()
+	updated.ResourceVersion = existing.ResourceVersion
+	return c.clientset.CoreV1().Secrets(namespace).Update(ctx, updated, metav1.UpdateOptions{})
+}
+
+// applySecret applies the name, labels, annotations, type and data of a secret.
+// The secret is not applied when the existing one already has them.
+func (c *Client) applySecret(ctx context.Context, namespace string, secret *corev1.Secret) (*corev1.Secret, error) {
+	existing, err := c.clientset.CoreV1().Secrets(namespace).Get(ctx, secret.Name, metav1.GetOptions{})
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return nil, err
+	}
+	if err == nil && unchangedSecret(existing, secret) {
+		log.Infof("No changes detected in secret %s/%s, skipping update", namespace, secret.Name)
+		return existing, nil
+	}
+
+	applied := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        secret.Name,
+			Namespace:   namespace,
+			Labels:      secret.Labels,
+			Annotations: secret.Annotations,
+		},
+		Type: secret.Type,
+		Data: secret.Data,
+	}
+	patch, err := json.Marshal(applied)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the secret")
+	}
+
+	force := c.forceConflicts
+	result, err := c.clientset.CoreV1().Secrets(namespace).Patch(ctx, secret.Name, types.ApplyPatchType, patch, metav1.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        &force,
+	})
+	if k8sErrors.IsConflict(err) {
+		return nil, errors.Wrapf(err, "secret %s/%s was changed by another field manager, revert the change or force the conflicts to take it over", namespace, secret.Name)
+	}
+
+	return result, err
+}
+
+// unchangedSecret reports whether the existing secret already has the data,
+// labels and annotations of the wanted one.
+func unchangedSecret(existing, wanted *corev1.Secret) bool {
+	return sameData(existing.Data, wanted.Data) && hasValues(existing.Labels, wanted.Labels) && hasValues(existing.Annotations, wanted.Annotations)
+}
+
+func sameData(a, b map[string][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		other, ok := b[key]
+		if !ok || !bytes.Equal(value, other) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasValues reports whether all the wanted values are set in values.
+func hasValues(values, wanted map[string]string) bool {
+	for key, value := range wanted {
+		if current, ok := values[key]; !ok || current != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// DeleteSecret deletes a secret, ignoring secrets that don't exist
+func (c *Client) DeleteSecret(namespace, name string) error {
+	err := c.clientset.CoreV1().Secrets(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+	if err != nil && k8sErrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
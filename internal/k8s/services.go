@@ -0,0 +1,100 @@
+package k8s
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// servicePort is a port of a Service and the scheme it is probed with.
+type servicePort struct {
+	port   int64
+	scheme string
+}
+
+// ListProbedServiceEndpoints returns the external addresses of the
+// LoadBalancer Services in all namespaces that carry the probe annotation set
+// to "true", with the ports listed in the probe ports annotation. Without
+// that annotation, all TCP ports of the Service are probed with tcp.
+func (c *Client) ListProbedServiceEndpoints() ([]discovery.ServiceEndpoint, error) {
+	services, err := c.clientset.CoreV1().Services(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []discovery.ServiceEndpoint
+	for _, service := range services.Items {
+		if service.Spec.Type != corev1.ServiceTypeLoadBalancer || service.Annotations[discovery.ProbeAnnotation] != "true" {
+			continue
+		}
+		ports, err := probedServicePorts(service)
+		if err != nil {
+			log.WithError(err).Warnf("Skipping Service %s/%s", service.Namespace, service.Name)
+			continue
+		}
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			address := ingress.Hostname
+			if len(address) == 0 {
+				address = ingress.IP
+			}
+			if len(address) == 0 {
+				continue
+			}
+			for _, port := range ports {
+				endpoints = append(endpoints, discovery.ServiceEndpoint{
+					Namespace: service.Namespace,
+					Service:   service.Name,
+					Address:   address,
+					Port:      port.port,
+					Scheme:    port.scheme,
+				})
+			}
+		}
+	}
+
+	return endpoints, nil
+}
+
+// probedServicePorts returns the ports of the Service listed in the probe
+// ports annotation, or all its TCP ports if it has none.
+func probedServicePorts(service corev1.Service) ([]servicePort, error) {
+	annotation, ok := service.Annotations[discovery.ProbePortsAnnotation]
+	if !ok {
+		var ports []servicePort
+		for _, port := range service.Spec.Ports {
+			if port.Protocol == corev1.ProtocolTCP || len(port.Protocol) == 0 {
+				ports = append(ports, servicePort{port: int64(port.Port), scheme: "tcp"})
+			}
+		}
+		return ports, nil
+	}
+
+	var ports []servicePort
+	for _, entry := range strings.Split(annotation, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		portPart, scheme := entry, "tcp"
+		if i := strings.Index(entry, "/"); i >= 0 {
+			portPart, scheme = entry[:i], strings.ToLower(entry[i+1:])
+		}
+		port, err := strconv.ParseInt(portPart, 10, 64)
+		if err != nil || port < 1 || port > 65535 {
+			return nil, errors.Errorf("invalid port %q in the %s annotation", entry, discovery.ProbePortsAnnotation)
+		}
+		if scheme != "tcp" && scheme != "http" && scheme != "https" {
+			return nil, errors.Errorf("invalid scheme %q in the %s annotation, expected tcp, http or https", entry, discovery.ProbePortsAnnotation)
+		}
+		ports = append(ports, servicePort{port: port, scheme: scheme})
+	}
+
+	return ports, nil
+}
@@ -0,0 +1,81 @@
+package k8s
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// watchRetryInterval is the time to wait before re-establishing a failed watch.
+const watchRetryInterval = 10 * time.Second
+
+// WatchConfigMap watches a ConfigMap until stop is closed and signals on the
+// returned channel whenever it is created, changed or deleted. Changes that
+// happen while a signal is pending are coalesced into it. Watches that end or
+// fail are re-established.
+func (c *Client) WatchConfigMap(namespace, name string, stop <-chan struct{}) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+	go func() {
+		for {
+			err := c.watchConfigMap(namespace, name, stop, changes)
+			if err != nil {
+				log.WithError(err).Warnf("Watch of ConfigMap %s/%s failed, retrying", namespace, name)
+			}
+			select {
+			case <-stop:
+				return
+			case <-time.After(watchRetryInterval):
+			}
+		}
+	}()
+
+	return changes
+}
+
+// watchConfigMap watches a ConfigMap from its current version until the
+// watch ends or stop is closed.
+func (c *Client) watchConfigMap(namespace, name string, stop <-chan struct{}, changes chan<- struct{}) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	selector := fields.OneTermEqualSelector("metadata.name", name).String()
+	list, err := c.clientset.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		return err
+	}
+	watcher, err := c.clientset.CoreV1().ConfigMaps(namespace).Watch(ctx, metav1.ListOptions{
+		FieldSelector:   selector,
+		ResourceVersion: list.ResourceVersion,
+	})
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return nil
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified, watch.Deleted:
+				log.Infof("ConfigMap %s/%s changed", namespace, name)
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			case watch.Error:
+				return errors.Errorf("watch error: %v", event.Object)
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// AnnotatePodTemplate sets annotations on the pod template of a StatefulSet or
+// Deployment. Changing an annotation rolls out the workload's pods, setting
+// it to its current value leaves them untouched.
+func (c *Client) AnnotatePodTemplate(namespace, kind, name string, annotations map[string]string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": annotations,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the pod template patch")
+	}
+
+	ctx := context.TODO()
+	switch kind {
+	case "statefulset":
+		_, err = c.clientset.AppsV1().StatefulSets(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	case "deployment":
+		_, err = c.clientset.AppsV1().Deployments(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	default:
+		return errors.Errorf("unsupported workload kind %s", kind)
+	}
+
+	return err
+}
@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"text/template"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/version"
+	"github.com/pkg/errors"
+)
+
+const (
+	// EventError is the event of a failure notification.
+	EventError = "error"
+	// EventChanges is the event of a change summary.
+	EventChanges = "changes"
+
+	genericWebhookTimeout = 30 * time.Second
+)
+
+// DefaultWebhookTemplate is the payload sent to the generic webhook if no template is configured.
+const DefaultWebhookTemplate = `{"event": {{ json .Event }}, "message": {{ json .Message }}, "error": {{ json .Error }}, "changes": {{ json .Changes }}, "build": {{ json .Build }}}`
+
+// WebhookData is the data the payload template of the generic webhook is rendered with.
+type WebhookData struct {
+	// Event is EventError or EventChanges.
+	Event string
+	// Message describes what failed, it is empty for change summaries.
+	Message string
+	// Error is the error of a failure, it is empty for change summaries.
+	Error string
+	// Changes are the added and removed targets, they are nil for failures.
+	Changes *ChangeEvent
+	// Build is the build of the Blackbox target discovery.
+	Build version.Info
+}
+
+// Webhook posts notifications rendered from a user template to an arbitrary
+// endpoint, so incident tooling can be integrated without a dedicated notifier.
+type Webhook struct {
+	url     string
+	payload *template.Template
+}
+
+// ParseWebhookTemplate parses a payload template of the generic webhook. The
+// json function renders a value as JSON, so strings are quoted and escaped.
+func ParseWebhookTemplate(text string) (*template.Template, error) {
+	if len(text) == 0 {
+		text = DefaultWebhookTemplate
+	}
+	payload, err := template.New("webhook").Funcs(template.FuncMap{
+		"json": func(value interface{}) (string, error) {
+			data, err := json.Marshal(value)
+			return string(data), err
+		},
+	}).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the webhook payload template")
+	}
+
+	return payload, nil
+}
+
+// NewWebhook creates a generic webhook notifier posting the payloads rendered
+// from the template, or from DefaultWebhookTemplate if it is empty, to the URL.
+func NewWebhook(url, payloadTemplate string) (*Webhook, error) {
+	payload, err := ParseWebhookTemplate(payloadTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Webhook{url: url, payload: payload}, nil
+}
+
+// SendError posts an error notification to the webhook.
+func (w *Webhook) SendError(errorMessage error, message string) error {
+	return w.post(WebhookData{Event: EventError, Message: message, Error: errorMessage.Error(), Build: version.Get()})
+}
+
+// SendChanges posts a change summary to the webhook.
+func (w *Webhook) SendChanges(event *ChangeEvent) error {
+	return w.post(WebhookData{Event: EventChanges, Changes: event, Build: version.Get()})
+}
+
+// post renders the payload and posts it, refusing to send a payload that is not valid JSON.
+func (w *Webhook) post(data WebhookData) error {
+	var body bytes.Buffer
+	err := w.payload.Execute(&body, data)
+	if err != nil {
+		return errors.Wrap(err, "failed to render the webhook payload")
+	}
+	if !json.Valid(body.Bytes()) {
+		return errors.New("the rendered webhook payload is not valid JSON")
+	}
+
+	client := &http.Client{Timeout: genericWebhookTimeout}
+	resp, err := client.Post(w.url, "application/json", &body)
+	if err != nil {
+		return errors.Wrap(err, "failed to send the webhook payload")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/version"
+	model "github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	notificationUsername = "Blackbox Target Discovery"
+	notificationIconURL  = "https://upload.wikimedia.org/wikipedia/commons/thumb/3/38/Prometheus_software_logo.svg/1200px-Prometheus_software_logo.svg.png"
+)
+
+// Mattermost sends notifications to a Mattermost incoming webhook.
+type Mattermost struct {
+	webhookURL string
+}
+
+// NewMattermost creates a Mattermost notifier for the given webhook.
+func NewMattermost(webhookURL string) *Mattermost {
+	return &Mattermost{webhookURL: webhookURL}
+}
+
+func send(webhookURL string, payload interface{}) error {
+	marshalContent, _ := json.Marshal(payload)
+	var jsonStr = []byte(marshalContent)
+	req, err := http.NewRequest("POST", webhookURL, bytes.NewBuffer(jsonStr))
+	if err != nil {
+		return errors.Wrap(err, "failed to create HTTP request")
+	}
+	req.Header.Set("X-Custom-Header", "aws-sns")
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed tο send HTTP request")
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// changesAttachment formats a change event as a message attachment.
+func changesAttachment(event *ChangeEvent) *model.SlackAttachment {
+	return &model.SlackAttachment{
+		Color: "#00C853",
+		Fields: []*model.SlackAttachmentField{
+			{Title: ChangeTitle(event), Short: false},
+			{Title: fmt.Sprintf("Added (%d)", len(event.Added)), Value: SummarizeTargets(event.Added), Short: true},
+			{Title: fmt.Sprintf("Removed (%d)", len(event.Removed)), Value: SummarizeTargets(event.Removed), Short: true},
+			{Title: "Build", Value: event.Build.String(), Short: false},
+		},
+	}
+}
+
+// errorAttachment formats an error as a message attachment.
+func errorAttachment(errorMessage error, message string) *model.SlackAttachment {
+	return &model.SlackAttachment{
+		Color: "#FF0000",
+		Fields: []*model.SlackAttachmentField{
+			{Title: message, Short: false},
+			{Title: "Error Message", Value: errorMessage.Error(), Short: false},
+			{Title: "Build", Value: version.Get().String(), Short: false},
+		},
+	}
+}
+
+// SendChanges sends a summary of the added and removed targets to Mattermost.
+func (m *Mattermost) SendChanges(event *ChangeEvent) error {
+	err := m.sendAttachment(changesAttachment(event))
+	if err != nil {
+		return errors.Wrap(err, "failed tο send Mattermost change payload")
+	}
+
+	return nil
+}
+
+// SendError sends an error notification to Mattermost.
+func (m *Mattermost) SendError(errorMessage error, message string) error {
+	err := m.sendAttachment(errorAttachment(errorMessage, message))
+	if err != nil {
+		return errors.Wrap(err, "failed tο send Mattermost error payload")
+	}
+
+	return nil
+}
+
+func (m *Mattermost) sendAttachment(attachment *model.SlackAttachment) error {
+	payload := model.CommandResponse{
+		Username:    notificationUsername,
+		IconURL:     notificationIconURL,
+		Attachments: []*model.SlackAttachment{attachment},
+	}
+
+	return send(m.webhookURL, payload)
+}
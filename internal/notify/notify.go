@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Notifier sends notifications about Blackbox target discovery runs.
+type Notifier interface {
+	SendError(err error, message string) error
+	SendChanges(event *ChangeEvent) error
+}
+
+// TicketOpener opens tickets in an issue tracker and returns their reference.
+// A ticket that was created but not completed is returned with the error.
+type TicketOpener interface {
+	OpenTicket(title, description string, attachment []byte) (string, error)
+}
+
+// Multi sends every notification to all of its notifiers. A failing notifier
+// does not keep the notification from the others.
+type Multi []Notifier
+
+// SendError sends an error notification to all notifiers.
+func (m Multi) SendError(err error, message string) error {
+	var errs []string
+	for _, notifier := range m {
+		sendErr := notifier.SendError(err, message)
+		if sendErr != nil {
+			errs = append(errs, sendErr.Error())
+		}
+	}
+
+	return joinErrors(errs)
+}
+
+// SendChanges sends a change summary to all notifiers.
+func (m Multi) SendChanges(event *ChangeEvent) error {
+	var errs []string
+	for _, notifier := range m {
+		err := notifier.SendChanges(event)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	return joinErrors(errs)
+}
+
+func joinErrors(errs []string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errors.New(strings.Join(errs, "; "))
+}
+
+// Incident describes a persistent failure of the Blackbox target discovery.
+type Incident struct {
+	// Key identifies the incident, so it is raised once and resolved later.
+	Key     string
+	Summary string
+	Details map[string]string
+}
+
+// IncidentNotifier raises an incident for persistent failures and resolves it
+// once the Blackbox target discovery recovers.
+type IncidentNotifier interface {
+	TriggerIncident(incident Incident) error
+	ResolveIncident(key string) error
+}
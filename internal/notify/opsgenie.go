@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// OpsgenieAPIURL is the Opsgenie API endpoint of the US region.
+	OpsgenieAPIURL = "https://api.opsgenie.com"
+	// opsgenieMessageLimit is the maximum length of an Opsgenie alert message.
+	opsgenieMessageLimit = 130
+)
+
+// Opsgenie creates and closes Opsgenie alerts through the Alert API.
+type Opsgenie struct {
+	APIKey string
+	// APIURL overrides OpsgenieAPIURL, for example with the EU endpoint.
+	APIURL string
+	// Priority is the alert priority, P1 to P5.
+	Priority string
+}
+
+// TriggerIncident creates an Opsgenie alert with the incident key as alias,
+// so Opsgenie deduplicates it while it is open.
+func (o *Opsgenie) TriggerIncident(incident Incident) error {
+	message := incident.Summary
+	if len(message) > opsgenieMessageLimit {
+		message = message[:opsgenieMessageLimit]
+	}
+	alert := map[string]interface{}{
+		"message":     message,
+		"alias":       incident.Key,
+		"description": incident.Details["error"],
+		"details":     incident.Details,
+		"priority":    o.Priority,
+		"source":      notificationUsername,
+	}
+
+	err := o.send("/v2/alerts", alert)
+	if err != nil {
+		return errors.Wrap(err, "failed to create Opsgenie alert")
+	}
+
+	return nil
+}
+
+// ResolveIncident closes the Opsgenie alert with the incident key as alias.
+func (o *Opsgenie) ResolveIncident(key string) error {
+	path := fmt.Sprintf("/v2/alerts/%s/close?identifierType=alias", url.PathEscape(key))
+	err := o.send(path, map[string]interface{}{
+		"source": notificationUsername,
+		"note":   "The Blackbox target discovery succeeded again",
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to close Opsgenie alert")
+	}
+
+	return nil
+}
+
+func (o *Opsgenie) send(path string, payload map[string]interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	apiURL := o.APIURL
+	if len(apiURL) == 0 {
+		apiURL = OpsgenieAPIURL
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(apiURL, "/")+path, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "GenieKey "+o.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	return sendTicketRequest(req, nil)
+}
@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// PagerDutyEventsURL is the endpoint of the PagerDuty Events API v2.
+const PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDuty triggers and resolves PagerDuty incidents through the Events API v2.
+type PagerDuty struct {
+	RoutingKey string
+	// URL overrides PagerDutyEventsURL.
+	URL string
+}
+
+// pagerDutyEvent is an event of the PagerDuty Events API v2.
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+// TriggerIncident triggers a critical PagerDuty incident deduplicated by the incident key.
+func (p *PagerDuty) TriggerIncident(incident Incident) error {
+	err := p.send(pagerDutyEvent{
+		RoutingKey:  p.RoutingKey,
+		EventAction: "trigger",
+		DedupKey:    incident.Key,
+		Payload: &pagerDutyPayload{
+			Summary:       incident.Summary,
+			Source:        notificationUsername,
+			Severity:      "critical",
+			CustomDetails: incident.Details,
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to trigger PagerDuty incident")
+	}
+
+	return nil
+}
+
+// ResolveIncident resolves the PagerDuty incident with the given key.
+func (p *PagerDuty) ResolveIncident(key string) error {
+	err := p.send(pagerDutyEvent{
+		RoutingKey:  p.RoutingKey,
+		EventAction: "resolve",
+		DedupKey:    key,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to resolve PagerDuty incident")
+	}
+
+	return nil
+}
+
+func (p *PagerDuty) send(event pagerDutyEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	url := p.URL
+	if len(url) == 0 {
+		url = PagerDutyEventsURL
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return sendTicketRequest(req, nil)
+}
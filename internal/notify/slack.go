@@ -0,0 +1,52 @@
+package notify
+
+import (
+	model "github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// Slack sends notifications to a Slack incoming webhook, formatted like the
+// Mattermost notifications.
+type Slack struct {
+	webhookURL string
+}
+
+// slackPayload is the message accepted by Slack incoming webhooks.
+type slackPayload struct {
+	Username    string                   `json:"username"`
+	IconURL     string                   `json:"icon_url"`
+	Attachments []*model.SlackAttachment `json:"attachments"`
+}
+
+// NewSlack creates a Slack notifier for the given webhook.
+func NewSlack(webhookURL string) *Slack {
+	return &Slack{webhookURL: webhookURL}
+}
+
+// SendChanges sends a summary of the added and removed targets to Slack.
+func (s *Slack) SendChanges(event *ChangeEvent) error {
+	err := s.sendAttachment(changesAttachment(event))
+	if err != nil {
+		return errors.Wrap(err, "failed to send Slack change payload")
+	}
+
+	return nil
+}
+
+// SendError sends an error notification to Slack.
+func (s *Slack) SendError(errorMessage error, message string) error {
+	err := s.sendAttachment(errorAttachment(errorMessage, message))
+	if err != nil {
+		return errors.Wrap(err, "failed to send Slack error payload")
+	}
+
+	return nil
+}
+
+func (s *Slack) sendAttachment(attachment *model.SlackAttachment) error {
+	return send(s.webhookURL, slackPayload{
+		Username:    notificationUsername,
+		IconURL:     notificationIconURL,
+		Attachments: []*model.SlackAttachment{attachment},
+	})
+}
@@ -0,0 +1,33 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+)
+
+// summaryTargetLimit is the number of targets listed in a change summary.
+const summaryTargetLimit = 20
+
+// ChangeTitle returns the title of the summary of a change event.
+func ChangeTitle(event *ChangeEvent) string {
+	return fmt.Sprintf("Blackbox targets of %s/%s updated: %d added, %d removed", event.Namespace, event.SecretName, len(event.Added), len(event.Removed))
+}
+
+// SummarizeTargets lists the job and target of the changed targets, one per
+// line, up to a limit after which only the number of remaining targets is given.
+func SummarizeTargets(targets []ChangedTarget) string {
+	if len(targets) == 0 {
+		return "None"
+	}
+
+	var lines []string
+	for i, target := range targets {
+		if i == summaryTargetLimit {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(targets)-summaryTargetLimit))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%s %s", target.Job, target.Target))
+	}
+
+	return strings.Join(lines, "\n")
+}
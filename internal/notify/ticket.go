@@ -0,0 +1,140 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const ticketRequestTimeout = 30 * time.Second
+
+// Jira opens tickets as Jira issues.
+type Jira struct {
+	URL        string
+	Username   string
+	APIToken   string
+	ProjectKey string
+	IssueType  string
+}
+
+// GitHub opens tickets as GitHub issues.
+type GitHub struct {
+	Token      string
+	Repository string
+}
+
+// OpenTicket creates a Jira issue with the attachment attached to it. If the
+// attachment fails, the key of the created issue is returned with the error.
+func (j *Jira) OpenTicket(title, description string, attachment []byte) (string, error) {
+	issue := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]string{"key": j.ProjectKey},
+			"summary":     title,
+			"description": description,
+			"issuetype":   map[string]string{"name": j.IssueType},
+		},
+	}
+	body, err := json.Marshal(issue)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/rest/api/2/issue", j.URL), bytes.NewBuffer(body))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(j.Username, j.APIToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	var created struct {
+		Key string `json:"key"`
+	}
+	err = sendTicketRequest(req, &created)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create Jira issue")
+	}
+
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	part, err := writer.CreateFormFile("file", "run-report.json")
+	if err != nil {
+		return created.Key, err
+	}
+	_, err = part.Write(attachment)
+	if err != nil {
+		return created.Key, err
+	}
+	err = writer.Close()
+	if err != nil {
+		return created.Key, err
+	}
+
+	req, err = http.NewRequest(http.MethodPost, fmt.Sprintf("%s/rest/api/2/issue/%s/attachments", j.URL, created.Key), &form)
+	if err != nil {
+		return created.Key, err
+	}
+	req.SetBasicAuth(j.Username, j.APIToken)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("X-Atlassian-Token", "no-check")
+
+	err = sendTicketRequest(req, nil)
+	if err != nil {
+		return created.Key, errors.Wrapf(err, "failed to attach file to Jira issue %s", created.Key)
+	}
+
+	return created.Key, nil
+}
+
+// OpenTicket creates a GitHub issue with the attachment in its body.
+func (g *GitHub) OpenTicket(title, description string, attachment []byte) (string, error) {
+	issue := map[string]string{
+		"title": title,
+		"body":  fmt.Sprintf("%s\n\n### Run report\n\n```json\n%s\n```", description, attachment),
+	}
+	body, err := json.Marshal(issue)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("https://api.github.com/repos/%s/issues", g.Repository), bytes.NewBuffer(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("token %s", g.Token))
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Content-Type", "application/json")
+
+	var created struct {
+		HTMLURL string `json:"html_url"`
+	}
+	err = sendTicketRequest(req, &created)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create GitHub issue")
+	}
+
+	return created.HTMLURL, nil
+}
+
+func sendTicketRequest(req *http.Request, response interface{}) error {
+	client := &http.Client{Timeout: ticketRequestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send HTTP request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	if response == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(response)
+}
@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/version"
+	"github.com/pkg/errors"
+)
+
+const changeWebhookTimeout = 30 * time.Second
+
+// ChangeEvent is the payload sent to the change webhook when the target set changes.
+type ChangeEvent struct {
+	Timestamp  time.Time       `json:"timestamp"`
+	Namespace  string          `json:"namespace"`
+	SecretName string          `json:"secret_name"`
+	Added      []ChangedTarget `json:"added"`
+	Removed    []ChangedTarget `json:"removed"`
+	Build      version.Info    `json:"build"`
+}
+
+// ChangedTarget is a target that was added to or removed from a scrape job.
+type ChangedTarget struct {
+	Job    string            `json:"job"`
+	Target string            `json:"target"`
+	Source string            `json:"source,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// NewChangeEvent builds a change event from the target changes of a scrape config.
+// It returns nil if no targets were added or removed.
+func NewChangeEvent(namespace, secretName string, changes []render.Change, blackBoxTargets []discovery.Target) *ChangeEvent {
+	discovered := map[string]discovery.Target{}
+	for _, target := range blackBoxTargets {
+		discovered[target.Target] = target
+	}
+
+	event := &ChangeEvent{
+		Timestamp:  time.Now().UTC(),
+		Namespace:  namespace,
+		SecretName: secretName,
+		Added:      []ChangedTarget{},
+		Removed:    []ChangedTarget{},
+		Build:      version.Get(),
+	}
+	for _, change := range changes {
+		if len(change.Target) == 0 {
+			continue
+		}
+		changed := ChangedTarget{Job: change.Job, Target: change.Target}
+		switch change.Action {
+		case render.ActionAdd:
+			if target, ok := discovered[change.Target]; ok {
+				changed.Source = target.Source
+				changed.Labels = target.Labels
+			}
+			event.Added = append(event.Added, changed)
+		case render.ActionRemove:
+			event.Removed = append(event.Removed, changed)
+		}
+	}
+
+	if len(event.Added) == 0 && len(event.Removed) == 0 {
+		return nil
+	}
+
+	return event
+}
+
+// SendChangeEvent posts the change event to the change webhook.
+func SendChangeEvent(webhookURL string, event *ChangeEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: changeWebhookTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send HTTP request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package orchestrate
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/reconcile"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ClientFactory creates the clients of a run from its configuration.
+type ClientFactory func(config *reconcile.Config) (*reconcile.Clients, error)
+
+// RunResult is the outcome of a single run.
+type RunResult struct {
+	Name   string            `json:"name"`
+	Report *reconcile.Report `json:"report"`
+}
+
+// Result aggregates the outcome of every run.
+type Result struct {
+	Runs []RunResult `json:"runs"`
+}
+
+// Failed returns the runs that failed.
+func (r *Result) Failed() []RunResult {
+	var failed []RunResult
+	for _, run := range r.Runs {
+		if !run.Report.Success {
+			failed = append(failed, run)
+		}
+	}
+
+	return failed
+}
+
+// Err returns an error summarizing the failed runs, or nil if all succeeded.
+func (r *Result) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+
+	messages := make([]string, 0, len(failed))
+	for _, run := range failed {
+		messages = append(messages, fmt.Sprintf("%s: %s", run.Name, run.Report.Error))
+	}
+
+	return errors.Errorf("%d of %d runs failed: %s", len(failed), len(r.Runs), strings.Join(messages, "; "))
+}
+
+// Run executes every run of the spec with at most spec.Parallelism runs at
+// the same time. A failing run does not stop the others.
+func Run(spec *Spec, base *reconcile.Config, newClients ClientFactory) *Result {
+	result := &Result{Runs: make([]RunResult, len(spec.Runs))}
+	semaphore := make(chan struct{}, spec.Parallelism)
+
+	var wg sync.WaitGroup
+	for i, run := range spec.Runs {
+		wg.Add(1)
+		go func(i int, run RunSpec) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			result.Runs[i] = RunResult{Name: run.Name, Report: execute(spec, base, run, newClients)}
+		}(i, run)
+	}
+	wg.Wait()
+
+	return result
+}
+
+// execute runs the reconcile path of a single run and tracks its outcome.
+func execute(spec *Spec, base *reconcile.Config, run RunSpec, newClients ClientFactory) *reconcile.Report {
+	logger := log.WithField("run", run.Name)
+	report := reconcile.NewReport()
+	config := spec.RunConfig(base, run)
+
+	clients, err := newClients(config)
+	if err != nil {
+		report.Complete(errors.Wrap(err, "failed to create clients"))
+		logger.WithError(err).Error("Failed to create clients")
+		return report
+	}
+
+	reconciler := reconcile.New(config, clients)
+	err = reconciler.Run(report)
+	report.Complete(err)
+	if err != nil {
+		logger.WithError(err).Error("Run failed")
+	} else {
+		logger.Infof("Run succeeded with %d targets", report.TargetCount)
+	}
+
+	trackErr := reconciler.TrackRunOutcome(report)
+	if trackErr != nil {
+		logger.WithError(trackErr).Error("Failed to track the run outcome")
+	}
+
+	return report
+}
@@ -0,0 +1,223 @@
+// Package orchestrate runs the Blackbox target discovery for several
+// combinations of hosted zones, target filters and Prometheus secrets
+// described in a single declarative file.
+package orchestrate
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/reconcile"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const defaultParallelism = 4
+
+// Spec is the orchestration file.
+type Spec struct {
+	// Parallelism is the maximum number of runs executed at the same time.
+	Parallelism  int                    `yaml:"parallelism"`
+	Sources      map[string]Source      `yaml:"sources"`
+	Filters      map[string]Filter      `yaml:"filters"`
+	Destinations map[string]Destination `yaml:"destinations"`
+	Runs         []RunSpec              `yaml:"runs"`
+}
+
+// Source is a pair of hosted zones targets are discovered in.
+type Source struct {
+	PublicHostedZoneID  string `yaml:"public_hosted_zone_id"`
+	PrivateHostedZoneID string `yaml:"private_hosted_zone_id"`
+}
+
+// Filter selects and extends the targets discovered in a source.
+type Filter struct {
+	ExcludedTargets        []string `yaml:"excluded_targets"`
+	IncludedTargetPatterns []string `yaml:"included_target_patterns"`
+	AdditionalTargets      []string `yaml:"additional_targets"`
+	RegionNamePattern      string   `yaml:"region_name_pattern"`
+	TCPProbeTargets        string   `yaml:"tcp_probe_targets"`
+}
+
+// Destination is the Prometheus secret the targets are written to.
+type Destination struct {
+	// KubeContext is the kubeconfig context of the cluster in developer mode.
+	// Without developer mode the in-cluster config is used.
+	KubeContext          string   `yaml:"kube_context"`
+	Namespace            string   `yaml:"namespace"`
+	SecretName           string   `yaml:"secret_name"`
+	ScrapeConfigTemplate string   `yaml:"scrape_config_template"`
+	BindServers          []string `yaml:"bind_servers"`
+	// MinTargets overrides MIN_TARGETS for the secret.
+	MinTargets int `yaml:"min_targets"`
+}
+
+// RunSpec combines a source, a filter and a destination.
+type RunSpec struct {
+	Name        string `yaml:"name"`
+	Source      string `yaml:"source"`
+	Filter      string `yaml:"filter"`
+	Destination string `yaml:"destination"`
+}
+
+// Load reads and validates an orchestration file.
+func Load(path string) (*Spec, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read orchestration file %s", path)
+	}
+
+	var spec Spec
+	err = yaml.UnmarshalStrict(data, &spec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse orchestration file %s", path)
+	}
+	if spec.Parallelism == 0 {
+		spec.Parallelism = defaultParallelism
+	}
+
+	problems := spec.Validate()
+	if len(problems) > 0 {
+		messages := make([]string, 0, len(problems))
+		for _, problem := range problems {
+			messages = append(messages, problem.Error())
+		}
+		return nil, errors.Errorf("orchestration file %s is invalid: %s", path, strings.Join(messages, "; "))
+	}
+
+	return &spec, nil
+}
+
+// Validate returns every problem of the orchestration spec.
+func (s *Spec) Validate() []error {
+	var problems []error
+	if s.Parallelism < 1 {
+		problems = append(problems, errors.New("parallelism must be positive"))
+	}
+	if len(s.Runs) == 0 {
+		problems = append(problems, errors.New("no runs defined"))
+	}
+
+	names := map[string]bool{}
+	for i, run := range s.Runs {
+		if len(run.Name) == 0 {
+			problems = append(problems, errors.Errorf("run %d has no name", i+1))
+			continue
+		}
+		if names[run.Name] {
+			problems = append(problems, errors.Errorf("run %s is defined more than once", run.Name))
+		}
+		names[run.Name] = true
+
+		source, ok := s.Sources[run.Source]
+		if !ok {
+			problems = append(problems, errors.Errorf("run %s references unknown source %q", run.Name, run.Source))
+		} else if len(source.PublicHostedZoneID) == 0 || len(source.PrivateHostedZoneID) == 0 {
+			problems = append(problems, errors.Errorf("source %s must set public_hosted_zone_id and private_hosted_zone_id", run.Source))
+		}
+		if _, ok := s.Filters[run.Filter]; len(run.Filter) > 0 && !ok {
+			problems = append(problems, errors.Errorf("run %s references unknown filter %q", run.Name, run.Filter))
+		}
+		destination, ok := s.Destinations[run.Destination]
+		if !ok {
+			problems = append(problems, errors.Errorf("run %s references unknown destination %q", run.Name, run.Destination))
+		} else if len(destination.Namespace) == 0 || len(destination.SecretName) == 0 {
+			problems = append(problems, errors.Errorf("destination %s must set namespace and secret_name", run.Destination))
+		} else if destination.MinTargets < 0 {
+			problems = append(problems, errors.Errorf("destination %s min_targets must not be negative", run.Destination))
+		}
+	}
+
+	var filterNames []string
+	for name := range s.Filters {
+		filterNames = append(filterNames, name)
+	}
+	sort.Strings(filterNames)
+	for _, name := range filterNames {
+		filter := s.Filters[name]
+		if len(filter.RegionNamePattern) > 0 {
+			pattern, err := regexp.Compile(filter.RegionNamePattern)
+			if err != nil {
+				problems = append(problems, errors.Wrapf(err, "filter %s has an invalid region_name_pattern", name))
+			} else if pattern.SubexpIndex("region") < 0 {
+				problems = append(problems, errors.Errorf("filter %s region_name_pattern must contain a named group called region", name))
+			}
+		}
+		for _, target := range filter.ExcludedTargets {
+			err := discovery.ValidateTargetPattern(target)
+			if err != nil {
+				problems = append(problems, errors.Wrapf(err, "filter %s has invalid excluded_targets", name))
+			}
+		}
+		for _, pattern := range filter.IncludedTargetPatterns {
+			err := discovery.ValidateTargetPattern(pattern)
+			if err != nil {
+				problems = append(problems, errors.Wrapf(err, "filter %s has invalid included_target_patterns", name))
+			}
+		}
+		if len(filter.TCPProbeTargets) > 0 {
+			_, err := discovery.ParseTCPProbes(filter.TCPProbeTargets)
+			if err != nil {
+				problems = append(problems, errors.Wrapf(err, "filter %s has invalid tcp_probe_targets", name))
+			}
+		}
+	}
+
+	return problems
+}
+
+// RunConfig returns the configuration of a run, based on the shared settings
+// of the base configuration. Each run keeps its state and status in its own ConfigMaps.
+func (s *Spec) RunConfig(base *reconcile.Config, run RunSpec) *reconcile.Config {
+	config := *base
+	source := s.Sources[run.Source]
+	destination := s.Destinations[run.Destination]
+
+	config.PublicHostedZoneIDs = reconcile.ParseHostedZoneIDs(source.PublicHostedZoneID)
+	config.PrivateHostedZoneIDs = reconcile.ParseHostedZoneIDs(source.PrivateHostedZoneID)
+	// Runs only discover the hosted zones of their source.
+	config.HostedZoneTagKey = ""
+	config.HostedZoneTagValue = ""
+	config.PrometheusNamespace = destination.Namespace
+	config.PrometheusSecretName = destination.SecretName
+	config.KubeContext = destination.KubeContext
+	config.StateConfigMapName = base.StateConfigMapName + "-" + run.Name
+	config.StatusConfigMapName = base.StatusConfigMapName + "-" + run.Name
+	if len(base.HistoryConfigMapName) > 0 {
+		config.HistoryConfigMapName = base.HistoryConfigMapName + "-" + run.Name
+	}
+	if len(base.FileSDConfigMapName) > 0 {
+		config.FileSDConfigMapName = base.FileSDConfigMapName + "-" + run.Name
+	}
+	if len(base.FileSDPath) > 0 {
+		extension := filepath.Ext(base.FileSDPath)
+		config.FileSDPath = strings.TrimSuffix(base.FileSDPath, extension) + "-" + run.Name + extension
+	}
+	if len(destination.ScrapeConfigTemplate) > 0 {
+		config.ScrapeConfigTemplate = destination.ScrapeConfigTemplate
+	}
+	if destination.BindServers != nil {
+		config.BindServers = destination.BindServers
+	}
+	if destination.MinTargets > 0 {
+		config.MinTargets = destination.MinTargets
+	}
+
+	if filter, ok := s.Filters[run.Filter]; ok {
+		config.ExcludedTargets = filter.ExcludedTargets
+		config.IncludedTargetPatterns = filter.IncludedTargetPatterns
+		config.AdditionalTargets = filter.AdditionalTargets
+		if len(filter.RegionNamePattern) > 0 {
+			config.RegionNamePattern = regexp.MustCompile(filter.RegionNamePattern)
+		}
+		if len(filter.TCPProbeTargets) > 0 {
+			config.TCPProbes, _ = discovery.ParseTCPProbes(filter.TCPProbeTargets)
+		}
+	}
+
+	return &config
+}
@@ -0,0 +1,101 @@
+package provisioner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+)
+
+const (
+	requestTimeout       = 30 * time.Second
+	installationsPerPage = 100
+)
+
+// Client lists the installations of a Mattermost Cloud provisioning server.
+type Client struct {
+	// ServerURL is the address of the provisioning server API.
+	ServerURL string
+}
+
+// installation is an installation of the provisioning server API.
+type installation struct {
+	ID      string
+	GroupID *string
+	// DNS is the domain name of installations created before DNSRecords
+	// were introduced.
+	DNS        string
+	DNSRecords []struct {
+		DomainName string
+	}
+	State string
+}
+
+// ListInstallations returns the installations that have not been deleted,
+// together with their domain names and states.
+func (c *Client) ListInstallations() ([]discovery.Installation, error) {
+	var installations []discovery.Installation
+	for page := 0; ; page++ {
+		items, err := c.listInstallations(page)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			installations = append(installations, convert(item))
+		}
+		if len(items) < installationsPerPage {
+			break
+		}
+	}
+
+	return installations, nil
+}
+
+// listInstallations returns a page of the installations.
+func (c *Client) listInstallations(page int) ([]installation, error) {
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(page))
+	query.Set("per_page", strconv.Itoa(installationsPerPage))
+	query.Set("include_deleted", "false")
+	requestURL := fmt.Sprintf("%s/api/installations?%s", strings.TrimSuffix(c.ServerURL, "/"), query.Encode())
+
+	client := &http.Client{Timeout: requestTimeout}
+	resp, err := client.Get(requestURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send HTTP request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, errors.Errorf("failed to list the provisioner installations with status code %d", resp.StatusCode)
+	}
+	var items []installation
+	err = json.NewDecoder(resp.Body).Decode(&items)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode the provisioner installations")
+	}
+
+	return items, nil
+}
+
+// convert returns the discovery view of a provisioner installation.
+func convert(item installation) discovery.Installation {
+	result := discovery.Installation{ID: item.ID, State: item.State}
+	if item.GroupID != nil {
+		result.GroupID = *item.GroupID
+	}
+	for _, record := range item.DNSRecords {
+		result.DomainNames = append(result.DomainNames, record.DomainName)
+	}
+	if len(result.DomainNames) == 0 && len(item.DNS) > 0 {
+		result.DomainNames = []string{item.DNS}
+	}
+
+	return result
+}
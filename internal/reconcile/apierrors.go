@@ -0,0 +1,90 @@
+package reconcile
+
+import (
+	"net/http"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/retry"
+	"github.com/pkg/errors"
+
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// APIAWS identifies errors returned by the AWS APIs.
+	APIAWS = "aws"
+	// APIKubernetes identifies errors returned by the Kubernetes API.
+	APIKubernetes = "kubernetes"
+)
+
+// FailedAPI returns the API whose error caused err, APIAWS or APIKubernetes,
+// or an empty string if the error did not come from either API.
+func FailedAPI(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return APIAWS
+	}
+	if _, ok := errors.Cause(err).(k8sErrors.APIStatus); ok {
+		return APIKubernetes
+	}
+
+	return ""
+}
+
+// Retryable reports whether an error of the AWS or Kubernetes API is
+// transient, such as throttling, a timeout or an unavailable server. Errors
+// that didn't come from the API, like network errors, are retryable as well.
+func Retryable(err error) bool {
+	// The AWS SDK wraps the errors of an operation, so they are matched with
+	// errors.As rather than by their cause. Errors that didn't reach the API,
+	// like an expired request timeout, carry no API error code.
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		var responseErr *awshttp.ResponseError
+		if errors.As(err, &responseErr) && (responseErr.HTTPStatusCode() == http.StatusTooManyRequests || responseErr.HTTPStatusCode() >= http.StatusInternalServerError) {
+			return true
+		}
+		return isThrottlingCode(apiErr.ErrorCode())
+	}
+	cause := errors.Cause(err)
+	if _, ok := cause.(k8sErrors.APIStatus); ok {
+		return k8sErrors.IsTooManyRequests(cause) || k8sErrors.IsServerTimeout(cause) || k8sErrors.IsTimeout(cause) ||
+			k8sErrors.IsInternalError(cause) || k8sErrors.IsServiceUnavailable(cause) || (k8sErrors.IsConflict(cause) && !isFieldManagerConflict(cause))
+	}
+
+	return true
+}
+
+// isFieldManagerConflict reports whether a conflict was caused by fields of
+// another field manager. Unlike a stale resourceVersion, retrying doesn't
+// resolve it.
+func isFieldManagerConflict(err error) bool {
+	status, ok := err.(k8sErrors.APIStatus)
+	if !ok || status.Status().Details == nil {
+		return false
+	}
+	for _, cause := range status.Status().Details.Causes {
+		if cause.Type == metav1.CauseTypeFieldManagerConflict {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isThrottlingCode reports whether an AWS error code signals throttling.
+func isThrottlingCode(code string) bool {
+	switch code {
+	case "Throttling", "ThrottlingException", "ThrottledException", "RequestLimitExceeded", "PriorRequestNotComplete":
+		return true
+	}
+
+	return false
+}
+
+// retry calls fn with the configured retry policy, retrying transient API errors.
+func (r *Reconciler) retry(description string, fn func() error) error {
+	return retry.Do(r.config.RetryPolicy, description, Retryable, fn)
+}
@@ -0,0 +1,42 @@
+package reconcile
+
+import (
+	"os"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/export"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+)
+
+// WriteAudit writes an audit record of the applied target changes to the
+// audit bucket, if one is configured and any targets changed.
+func (r *Reconciler) WriteAudit(namespace, secretName, runID string, changes []render.Change) error {
+	if len(r.config.AuditS3Bucket) == 0 {
+		return nil
+	}
+
+	record := export.NewAuditRecord(runID, r.auditOperator(), namespace, secretName, changes)
+	if len(record.Added) == 0 && len(record.Removed) == 0 {
+		return nil
+	}
+	audit := &export.AuditLog{
+		Store:  r.clients.Objects,
+		Bucket: r.config.AuditS3Bucket,
+		Prefix: r.config.AuditS3Prefix,
+	}
+
+	return audit.Write(record)
+}
+
+// auditOperator returns the configured operator of the changes, or else the
+// user or host running the discovery.
+func (r *Reconciler) auditOperator() string {
+	if len(r.config.AuditOperator) > 0 {
+		return r.config.AuditOperator
+	}
+	if user := os.Getenv("USER"); len(user) > 0 {
+		return user
+	}
+	hostname, _ := os.Hostname()
+
+	return hostname
+}
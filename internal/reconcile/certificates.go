@@ -0,0 +1,40 @@
+package reconcile
+
+import (
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// KubeCertificates lists the cert-manager Certificates.
+type KubeCertificates interface {
+	ListCertificates() ([]discovery.Certificate, error)
+}
+
+// listCertificates gets the cert-manager Certificates, if certificate
+// probing is enabled.
+func (r *Reconciler) listCertificates(report *Report) ([]discovery.Certificate, error) {
+	if len(r.config.CertificateProbeModule) == 0 {
+		return nil, nil
+	}
+	if r.clients.Certificates == nil {
+		return nil, errors.New("the Kubernetes client does not support certificate discovery")
+	}
+
+	started := time.Now()
+	log.Info("Getting the cert-manager certificates")
+	var certificates []discovery.Certificate
+	err := r.retry("list the certificates", func() error {
+		var err error
+		certificates, err = r.clients.Certificates.ListCertificates()
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to get the certificates")
+	}
+	report.phaseDone("List certificates", started, "%d cert-manager certificates", len(certificates))
+
+	return certificates, nil
+}
@@ -0,0 +1,371 @@
+package reconcile
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// SecretSizeLimit is the maximum size of the data of a Kubernetes secret or ConfigMap.
+	SecretSizeLimit = 1 << 20
+	// DefaultSecretSizeWarnPercent is the share of SecretSizeLimit above which
+	// a secret is reported as approaching the limit.
+	DefaultSecretSizeWarnPercent = 80
+	// DefaultSecretMountPath is the directory the Prometheus Operator mounts
+	// the secrets of a Prometheus in.
+	DefaultSecretMountPath = "/etc/prometheus/secrets"
+	// ChunkCountAnnotation records the number of secrets the scrape config of
+	// a chunked secret is spread across.
+	ChunkCountAnnotation = "blackbox-target-discovery.mattermost.com/chunks"
+	// IncludeConfigSecretKey is the key of the chunked secret holding the
+	// Prometheus config that loads every chunk.
+	IncludeConfigSecretKey = "scrape_config_files.yaml"
+	// chunkHeadroom is kept free in every chunk for the keys and the include config.
+	chunkHeadroom = 16 << 10
+	// splitJobInfix separates the name of a split job from the number of its
+	// part in the job names of all parts but the first.
+	splitJobInfix = "-part-"
+)
+
+// secretSize returns the size of the keys and values of a secret.
+func secretSize(secret *corev1.Secret) int {
+	size := 0
+	for key, value := range secret.Data {
+		size += len(key) + len(value)
+	}
+
+	return size
+}
+
+// secretSizeBudget returns the size above which a secret is reported as
+// approaching the size limit, and up to which chunks are filled.
+func (c *Config) secretSizeBudget() int {
+	percent := c.SecretSizeWarnPercent
+	if percent <= 0 || percent > 100 {
+		percent = DefaultSecretSizeWarnPercent
+	}
+
+	return SecretSizeLimit * percent / 100
+}
+
+// chunkName returns the name of the secret holding the chunk at the given
+// position of a chunked secret, starting at 1 for the secret itself.
+func chunkName(secretName string, position int) string {
+	if position == 1 {
+		return secretName
+	}
+
+	return fmt.Sprintf("%s-%d", secretName, position)
+}
+
+// newOutputSecrets returns the secrets holding the scrape config of an output
+// secret. With SECRET_CHUNKING the jobs are spread across as many chunk
+// secrets as needed to keep each of them within the size budget.
+func (r *Reconciler) newOutputSecrets(secretName string, data []byte, main bool) ([]*corev1.Secret, error) {
+	secret, err := r.NewScrapeConfigSecret(secretName, data)
+	if err != nil {
+		return nil, err
+	}
+	// Only the main secret holds the generated Blackbox exporter modules.
+	if !main {
+		delete(secret.Data, BlackboxModulesSecretKey)
+	}
+	if r.config.SecretChunking {
+		return r.chunkSecret(secret, data)
+	}
+
+	return []*corev1.Secret{secret}, r.checkSecretSize(secret)
+}
+
+// checkSecretSize returns an error if a secret exceeds the size limit, and
+// warns if it exceeds the size budget.
+func (r *Reconciler) checkSecretSize(secret *corev1.Secret) error {
+	size := secretSize(secret)
+	if size > SecretSizeLimit {
+		return errors.Errorf("secret %s would hold %d bytes, more than the Kubernetes limit of %d bytes, set SECRET_CHUNKING=true or move jobs to OUTPUT_SECRETS", secret.Name, size, SecretSizeLimit)
+	}
+	if size <= r.config.secretSizeBudget() {
+		return nil
+	}
+
+	warning := errors.Errorf("secret %s holds %d bytes, %d%% of the Kubernetes limit of %d bytes", secret.Name, size, size*100/SecretSizeLimit, SecretSizeLimit)
+	log.WithError(warning).Warn("The Blackbox targets Prometheus secret is approaching the size limit")
+	if r.clients.Notifier != nil {
+		err := r.clients.Notifier.SendError(warning, "The Blackbox targets Prometheus secret is approaching the size limit, set SECRET_CHUNKING=true or move jobs to OUTPUT_SECRETS")
+		if err != nil {
+			log.WithError(err).Error("Failed to send the secret size warning")
+		}
+	}
+
+	return nil
+}
+
+// chunkSecret spreads the jobs of a secret across chunk secrets that each stay
+// within the size budget. A job too large for a single chunk is split into
+// parts holding a share of its targets. Every chunk holds its jobs as scrape
+// config files under the keys of the secret, and the first chunk also holds
+// the include config loading all of them and the annotations of the whole
+// scrape config.
+func (r *Reconciler) chunkSecret(secret *corev1.Secret, data []byte) ([]*corev1.Secret, error) {
+	config, err := render.Parse(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the scrape config")
+	}
+	budget := r.config.secretSizeBudget() - len(secret.Data[BlackboxModulesSecretKey]) - chunkHeadroom
+
+	var chunks []render.Config
+	size := 0
+	for _, job := range config {
+		jobData, err := render.Config{job}.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		parts := []render.Job{job}
+		if len(jobData) > budget {
+			parts, err = splitJob(job, budget)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to split job %s of secret %s", job.JobName, secret.Name)
+			}
+		}
+		for _, part := range parts {
+			partData, err := render.Config{part}.Marshal()
+			if err != nil {
+				return nil, err
+			}
+			if len(chunks) == 0 || size+len(partData) > budget {
+				chunks = append(chunks, render.Config{})
+				size = 0
+			}
+			chunks[len(chunks)-1] = append(chunks[len(chunks)-1], part)
+			size += len(partData)
+		}
+	}
+	if len(chunks) == 0 {
+		chunks = append(chunks, render.Config{})
+	}
+
+	var secrets []*corev1.Secret
+	var includes []string
+	for i, chunk := range chunks {
+		name := chunkName(secret.Name, i+1)
+		chunkData, err := chunk.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		chunkSecret, err := r.NewScrapeConfigSecret(name, chunkData)
+		if err != nil {
+			return nil, err
+		}
+		delete(chunkSecret.Data, BlackboxModulesSecretKey)
+		for _, key := range r.config.ScrapeConfigKeys() {
+			part, err := render.Parse(chunkSecret.Data[key])
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse the scrape config of key %s", key)
+			}
+			chunkSecret.Data[key], err = part.MarshalFile()
+			if err != nil {
+				return nil, err
+			}
+			includes = append(includes, path.Join(r.config.SecretMountPath, name, key))
+		}
+		secrets = append(secrets, chunkSecret)
+	}
+
+	first := secrets[0]
+	first.Annotations = secret.Annotations
+	first.Annotations[ChunkCountAnnotation] = strconv.Itoa(len(secrets))
+	if modules, ok := secret.Data[BlackboxModulesSecretKey]; ok {
+		first.Data[BlackboxModulesSecretKey] = modules
+	}
+	first.Data[IncludeConfigSecretKey], err = render.IncludeConfig(includes)
+	if err != nil {
+		return nil, err
+	}
+	if len(secrets) > 1 {
+		log.Infof("Chunked the scrape config of secret %s across %d secrets", secret.Name, len(secrets))
+	}
+
+	return secrets, nil
+}
+
+// deleteStaleChunks deletes the chunk secrets of a chunked secret that are
+// no longer used after it was written with the given number of chunks.
+func (r *Reconciler) deleteStaleChunks(namespace, secretName string, previous, current int) error {
+	for position := current + 1; position <= previous; position++ {
+		name := chunkName(secretName, position)
+		log.Infof("Deleting the unused chunk secret %s", name)
+		err := r.retry("delete the chunk secret "+name, func() error {
+			return r.clients.Secrets.DeleteSecret(namespace, name)
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete the chunk secret %s", name)
+		}
+	}
+
+	return nil
+}
+
+// chunkCount returns the number of chunks recorded on a secret, or 0 if it
+// is not chunked.
+func chunkCount(annotations map[string]string) int {
+	count, err := strconv.Atoi(annotations[ChunkCountAnnotation])
+	if err != nil {
+		return 0
+	}
+
+	return count
+}
+
+// joinChunks returns the scrape config spread across the chunks of a chunked secret.
+func (r *Reconciler) joinChunks(namespace string, secret *corev1.Secret) ([]byte, error) {
+	var joined render.Config
+	count := chunkCount(secret.Annotations)
+	for position := 1; position <= count; position++ {
+		chunk := secret
+		if position > 1 {
+			var err error
+			chunk, err = r.clients.Secrets.GetSecret(namespace, chunkName(secret.Name, position))
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to get the Blackbox targets Prometheus chunk secret")
+			}
+			if chunk == nil {
+				return nil, errors.Errorf("chunk secret %s of secret %s is missing", chunkName(secret.Name, position), secret.Name)
+			}
+		}
+		for _, key := range r.config.ScrapeConfigKeys() {
+			part, err := render.ParseFile(chunk.Data[key])
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse the scrape config of key %s of secret %s", key, chunk.Name)
+			}
+			joined = append(joined, part...)
+		}
+	}
+
+	return joinSplitJobs(joined).Marshal()
+}
+
+// splitJob splits the targets of a job across parts of at most budget bytes.
+// Prometheus requires unique job names, so every part but the first is named
+// <job>-part-N and sets the job label of its targets back to the name of the
+// job, which Prometheus keeps over the job name.
+func splitJob(job render.Job, budget int) ([]render.Job, error) {
+	empty := job
+	empty.StaticConfigs = nil
+	emptyData, err := render.Config{empty}.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	var parts []render.Job
+	size := 0
+	for _, staticConfig := range job.StaticConfigs {
+		groupSize := 32 + len(job.JobName)
+		for name, value := range staticConfig.Labels {
+			groupSize += len(name) + len(value) + 8
+		}
+		grouped := false
+		for _, target := range staticConfig.Targets {
+			targetSize := len(target) + 16
+			if len(parts) == 0 || size+groupSize+targetSize > budget {
+				part := empty
+				if len(parts) > 0 {
+					part.JobName = fmt.Sprintf("%s%s%d", job.JobName, splitJobInfix, len(parts)+1)
+				}
+				parts = append(parts, part)
+				size = len(emptyData)
+				grouped = false
+			}
+			current := &parts[len(parts)-1]
+			if !grouped {
+				labels := map[string]string{}
+				for name, value := range staticConfig.Labels {
+					labels[name] = value
+				}
+				if len(parts) > 1 {
+					labels["job"] = job.JobName
+				}
+				if len(labels) == 0 {
+					labels = nil
+				}
+				current.StaticConfigs = append(current.StaticConfigs, render.StaticConfig{Targets: []string{}, Labels: labels})
+				size += groupSize
+				grouped = true
+			}
+			group := &current.StaticConfigs[len(current.StaticConfigs)-1]
+			group.Targets = append(group.Targets, target)
+			size += targetSize
+		}
+	}
+
+	if len(parts) == 0 {
+		parts = append(parts, empty)
+	}
+	for _, part := range parts {
+		partData, err := render.Config{part}.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		if len(partData) > budget {
+			return nil, errors.Errorf("part %s holds %d bytes, more than fits in a chunk of %d bytes", part.JobName, len(partData), budget)
+		}
+	}
+
+	return parts, nil
+}
+
+// joinSplitJobs merges the parts of the jobs split by splitJob back into
+// the jobs they were split from.
+func joinSplitJobs(config render.Config) render.Config {
+	var joined render.Config
+	for _, job := range config {
+		i := splitJobIndex(joined, job)
+		if i < 0 {
+			joined = append(joined, job)
+			continue
+		}
+		for n, staticConfig := range job.StaticConfigs {
+			labels := map[string]string{}
+			for name, value := range staticConfig.Labels {
+				if name != "job" {
+					labels[name] = value
+				}
+			}
+			if len(labels) == 0 {
+				labels = nil
+			}
+			// The first group of a part continues the last group of the
+			// previous part when the targets of a group were split.
+			groups := joined[i].StaticConfigs
+			if n == 0 && len(groups) > 0 && reflect.DeepEqual(groups[len(groups)-1].Labels, labels) {
+				groups[len(groups)-1].Targets = append(groups[len(groups)-1].Targets, staticConfig.Targets...)
+				continue
+			}
+			joined[i].StaticConfigs = append(joined[i].StaticConfigs, render.StaticConfig{Targets: staticConfig.Targets, Labels: labels})
+		}
+	}
+
+	return joined
+}
+
+// splitJobIndex returns the index of the job a part written by splitJob was
+// split from, or -1 if the job is not such a part.
+func splitJobIndex(config render.Config, job render.Job) int {
+	position := strings.LastIndex(job.JobName, splitJobInfix)
+	if position < 0 {
+		return -1
+	}
+	if _, err := strconv.Atoi(job.JobName[position+len(splitJobInfix):]); err != nil {
+		return -1
+	}
+
+	return config.FindJob(job.JobName[:position])
+}
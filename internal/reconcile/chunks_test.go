@@ -0,0 +1,71 @@
+package reconcile
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+)
+
+func TestSplitJob(t *testing.T) {
+	job := render.Job{JobName: "blackbox", MetricsPath: "/probe"}
+	for group := 0; group < 3; group++ {
+		staticConfig := render.StaticConfig{}
+		if group > 0 {
+			staticConfig.Labels = map[string]string{"environment": fmt.Sprintf("env-%d", group)}
+		}
+		for i := 0; i < 500; i++ {
+			staticConfig.Targets = append(staticConfig.Targets, fmt.Sprintf("https://installation-%d-%d.cloud.mattermost.com", group, i))
+		}
+		job.StaticConfigs = append(job.StaticConfigs, staticConfig)
+	}
+	want, err := render.Config{job}.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	budget := 10000
+	parts, err := splitJob(job, budget)
+	if err != nil {
+		t.Fatalf("splitJob() error = %v", err)
+	}
+	if len(parts) < 2 {
+		t.Fatalf("splitJob() returned %d parts, want more than one", len(parts))
+	}
+	for i, part := range parts {
+		data, err := render.Config{part}.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) > budget {
+			t.Errorf("part %s holds %d bytes, want at most %d", part.JobName, len(data), budget)
+		}
+		if i == 0 {
+			continue
+		}
+		if wantName := fmt.Sprintf("blackbox-part-%d", i+1); part.JobName != wantName {
+			t.Errorf("part %d is named %s, want %s", i+1, part.JobName, wantName)
+		}
+		for _, staticConfig := range part.StaticConfigs {
+			if staticConfig.Labels["job"] != "blackbox" {
+				t.Errorf("part %s has job label %q, want blackbox", part.JobName, staticConfig.Labels["job"])
+			}
+		}
+	}
+
+	got, err := joinSplitJobs(render.Config(parts)).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("joinSplitJobs() did not restore the split job:\n%s", got)
+	}
+}
+
+func TestSplitJobTooLarge(t *testing.T) {
+	job := render.Job{JobName: "blackbox", StaticConfigs: []render.StaticConfig{{Targets: []string{"https://installation.cloud.mattermost.com"}}}}
+	_, err := splitJob(job, 10)
+	if err == nil {
+		t.Error("splitJob() error = nil, want an error for a target that fits in no chunk")
+	}
+}
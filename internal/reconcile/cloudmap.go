@@ -0,0 +1,44 @@
+package reconcile
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ServiceInstanceLister is implemented by record listers that can list the
+// instances registered in AWS Cloud Map services.
+type ServiceInstanceLister interface {
+	ListServiceInstances(namespaces []string) ([]discovery.ServiceInstance, error)
+}
+
+// listServiceInstances gets the instances of the services of the configured
+// Cloud Map namespaces, if any.
+func (r *Reconciler) listServiceInstances(report *Report) ([]discovery.ServiceInstance, error) {
+	if len(r.config.CloudMapNamespaces) == 0 {
+		return nil, nil
+	}
+	lister, ok := r.clients.Records.(ServiceInstanceLister)
+	if !ok {
+		return nil, errors.New("the AWS client does not support Cloud Map discovery")
+	}
+
+	started := time.Now()
+	namespaces := strings.Join(r.config.CloudMapNamespaces, ", ")
+	log.Infof("Getting Cloud Map service instances of namespaces %s", namespaces)
+	var instances []discovery.ServiceInstance
+	err := r.retry("list the Cloud Map service instances", func() error {
+		var err error
+		instances, err = lister.ListServiceInstances(r.config.CloudMapNamespaces)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to get the Cloud Map service instances")
+	}
+	report.phaseDone("List Cloud Map", started, "%d instances in %s", len(instances), namespaces)
+
+	return instances, nil
+}
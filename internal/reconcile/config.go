@@ -0,0 +1,309 @@
+package reconcile
+
+import (
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/retry"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/schedule"
+)
+
+const (
+	// OutputSecret writes the scrape config to the Prometheus secret.
+	OutputSecret = "secret"
+	// OutputProbe writes a Prometheus Operator Probe resource per target group.
+	OutputProbe = "probe"
+	// OutputScrapeConfig writes a Prometheus Operator ScrapeConfig resource per scrape job.
+	OutputScrapeConfig = "scrapeconfig"
+	// OutputConfigMap writes the scrape config to a ConfigMap with the keys of the secret.
+	OutputConfigMap = "configmap"
+
+	// DefaultZoneListWorkers is the number of hosted zones listed concurrently.
+	DefaultZoneListWorkers = 4
+	// DefaultRoute53RequestRate stays within the Route53 limit of five
+	// requests per second per account.
+	DefaultRoute53RequestRate = 5
+)
+
+// Config configures the Blackbox target discovery.
+type Config struct {
+	PublicHostedZoneIDs      []string
+	PrivateHostedZoneIDs     []string
+	HostedZoneTagKey         string
+	HostedZoneTagValue       string
+	PrometheusNamespace      string
+	PrometheusSecretName     string
+	PrometheusReloadURL      string
+	PrometheusWorkload       string
+	MattermostAlertsHook     string
+	NotifyOnSuccess          bool
+	SlackWebhookURL          string
+	NotifyWebhookURL         string
+	NotifyWebhookTemplate    string
+	ExcludedTargets          []string
+	IncludedTargetPatterns   []string
+	TargetURLTemplate        *template.Template
+	TargetURLRules           []discovery.TargetURLRule
+	PrivateRecordRules       []discovery.PrivateRecordRule
+	AdditionalTargets        []string
+	DevMode                  string
+	BindServers              []string
+	ScrapeConfigTemplate     string
+	ServiceNowURL            string
+	ServiceNowUsername       string
+	ServiceNowPassword       string
+	ServiceNowTable          string
+	StateConfigMapName       string
+	StatusConfigMapName      string
+	HistoryConfigMapName     string
+	TargetLabels             []string
+	Environment              string
+	OutputKind               string
+	FileSDPath               string
+	FileSDConfigMapName      string
+	FileSDConfigMapKey       string
+	TargetsConfigMapName     string
+	TicketFailureThreshold   int
+	IncidentFailureThreshold int
+	PagerDutyRoutingKey      string
+	OpsgenieAPIKey           string
+	OpsgenieAPIURL           string
+	OpsgeniePriority         string
+	TicketProvider           string
+	JiraURL                  string
+	JiraUsername             string
+	JiraAPIToken             string
+	JiraProjectKey           string
+	JiraIssueType            string
+	GitHubToken              string
+	GitHubRepository         string
+	ChangeWebhookURL         string
+	AuditS3Bucket            string
+	AuditS3Prefix            string
+	AuditOperator            string
+	InventoryS3Bucket        string
+	InventoryS3Prefix        string
+	InventoryRetentionDays   int
+	RegionNamePattern        *regexp.Regexp
+	LatencyBudgets           map[string]string
+	DefaultLatencyBudget     string
+	GRPCProbeModule          string
+	GRPCHealthService        string
+	TCPProbes                []discovery.TCPProbe
+	CloudMapNamespaces       []string
+	LoadBalancerTagKey       string
+	LoadBalancerTagValue     string
+	DatabaseTagKey           string
+	DatabaseTagValue         string
+	DiscoverIngresses        bool
+	DiscoverServices         bool
+	CertificateProbeModule   string
+	ProvisionerURL           string
+	PreflightTimeout         time.Duration
+	QuarantineRuns           int
+	MaxTargetDropPercent     int
+	ForceApply               bool
+	ForceConflicts           bool
+	MetricsTextfile          string
+	PushgatewayURL           string
+	StatusListenAddress      string
+	RetryPolicy              retry.Policy
+	AWSTimeout               time.Duration
+	ZoneListWorkers          int
+	Route53RequestRate       int
+	AWSRegion                string
+	AWSProfile               string
+	AWSEndpointURL           string
+	CloudflareAPIToken       string
+	CloudflareZoneIDs        []string
+	GCPProject               string
+	GCPDNSZones              []string
+	GCPCredentialsFile       string
+	AzureSubscriptionID      string
+	AzureResourceGroup       string
+	AzureDNSZones            []string
+	BindZones                []string
+	AzureClientID            string
+	AssumeRoleARN            string
+	ZoneRoleARNs             map[string]string
+	AWSAccounts              []AWSAccount
+	AssumeRoleExternalID     string
+	AssumeRoleSessionName    string
+	KubeContext              string
+	ProductionContexts       *regexp.Regexp
+	AssumeYes                bool
+	DryRun                   bool
+	RecordDir                string
+	ReplayDir                string
+	OrchestrationConfig      string
+	FailPhase                string
+	MaintenanceWindows       []discovery.MaintenanceWindow
+	MaintenanceAction        string
+	MaintenanceConfigMapName string
+	RunSchedule              schedule.Schedule
+	MinTargets               int
+	WebSocketProbeModule     string
+	WebSocketTargetPattern   string
+	ProbeProfiles            []render.ProbeProfile
+	JobRules                 []discovery.JobRule
+	ModulePatterns           []discovery.ModulePattern
+	IPVersion                string
+	RecordTypes              []string
+	ScrapeConfigKey          string
+	SecretKeyRules           []SecretKeyRule
+	OutputSecrets            []SecretTemplate
+	SecretChunking           bool
+	SecretMountPath          string
+	SecretSizeWarnPercent    int
+	OutputS3Bucket           string
+	OutputS3Key              string
+	OutputS3Format           string
+	OutputS3KMSKeyID         string
+	RecordNamePrefixes       []string
+	DiscoverSRVRecords       bool
+}
+
+// AWSAccount is a named AWS account whose hosted zones are discovered with
+// the IAM role assumed in it.
+type AWSAccount struct {
+	Name    string
+	RoleARN string
+}
+
+// TemplateRequirements returns the scrape jobs the configuration renders targets into.
+func (c *Config) TemplateRequirements() render.TemplateRequirements {
+	requirements := render.TemplateRequirements{
+		Jobs:        []string{discovery.DefaultJobName},
+		Modules:     map[string]string{discovery.DefaultJobName: discovery.DefaultModule},
+		BindServers: len(c.BindServers),
+	}
+	// Go templates place the BIND servers themselves.
+	if render.IsGoTemplate(c.ScrapeConfigTemplate) {
+		requirements.BindServers = 0
+	}
+	if len(c.GRPCProbeModule) > 0 {
+		requirements.Jobs = append(requirements.Jobs, discovery.GRPCJobName)
+		requirements.Modules[discovery.GRPCJobName] = c.GRPCProbeModule
+	}
+	if len(c.TCPProbes) > 0 || len(c.CloudMapNamespaces) > 0 || len(c.LoadBalancerTagKey) > 0 || len(c.DatabaseTagKey) > 0 || c.DiscoverServices || c.DiscoverSRVRecords {
+		requirements.Jobs = append(requirements.Jobs, discovery.TCPJobName)
+		requirements.Modules[discovery.TCPJobName] = discovery.TCPProbeModule
+	}
+	if len(c.WebSocketProbeModule) > 0 {
+		requirements.Jobs = append(requirements.Jobs, discovery.WebSocketJobName)
+		requirements.Modules[discovery.WebSocketJobName] = c.WebSocketProbeModule
+	}
+	if len(c.CertificateProbeModule) > 0 {
+		requirements.Jobs = append(requirements.Jobs, discovery.CertificateJobName)
+		requirements.Modules[discovery.CertificateJobName] = c.CertificateProbeModule
+	}
+	for _, rule := range c.JobRules {
+		if _, ok := requirements.Modules[rule.JobName]; !ok {
+			requirements.Jobs = append(requirements.Jobs, rule.JobName)
+			requirements.Modules[rule.JobName] = rule.Module
+		}
+		if len(rule.Interval) > 0 {
+			if requirements.Intervals == nil {
+				requirements.Intervals = map[string]string{}
+			}
+			requirements.Intervals[rule.JobName] = rule.Interval
+		}
+	}
+
+	return requirements
+}
+
+// RecordFilter returns the filter selecting the records of the hosted zones.
+func (c *Config) RecordFilter() discovery.RecordFilter {
+	return discovery.RecordFilter{Types: c.RecordTypes, NamePrefixes: c.RecordNamePrefixes}
+}
+
+// DiscoveryOptions returns the options used to select and label the Blackbox targets.
+func (c *Config) DiscoveryOptions() discovery.Options {
+	return discovery.Options{
+		ExcludedTargets:        c.ExcludedTargets,
+		IncludedTargetPatterns: c.IncludedTargetPatterns,
+		AdditionalTargets:      c.AdditionalTargets,
+		RegionNamePattern:      c.RegionNamePattern,
+		LatencyBudgets:         c.LatencyBudgets,
+		DefaultLatencyBudget:   c.DefaultLatencyBudget,
+		GRPCProbeModule:        c.GRPCProbeModule,
+		GRPCHealthService:      c.GRPCHealthService,
+		TCPProbes:              c.TCPProbes,
+		WebSocketProbeModule:   c.WebSocketProbeModule,
+		WebSocketTargetPattern: c.WebSocketTargetPattern,
+		ModuleRules:            render.ModuleRules(c.ProbeProfiles),
+		TargetLabels:           c.TargetLabels,
+		Environment:            c.Environment,
+		IPVersion:              c.IPVersion,
+		DiscoverSRVRecords:     c.DiscoverSRVRecords,
+		TargetURLTemplate:      c.TargetURLTemplate,
+		TargetURLRules:         c.TargetURLRules,
+		PrivateRecordRules:     c.PrivateRecordRules,
+	}
+}
+
+// PublicZoneIDs returns the configured public hosted zones followed by the
+// zones of other DNS providers, which are discovered like public zones.
+func (c *Config) PublicZoneIDs() []string {
+	zoneIDs := c.PublicHostedZoneIDs
+	for _, providerZoneIDs := range [][]string{c.CloudflareZoneIDs, c.GCPDNSZones, c.AzureDNSZones, c.BindZones} {
+		zoneIDs = mergeZoneIDs(zoneIDs, providerZoneIDs)
+	}
+
+	return zoneIDs
+}
+
+// ParseHostedZoneIDs splits a comma-separated list of hosted zone IDs,
+// skipping empty entries.
+func ParseHostedZoneIDs(value string) []string {
+	var zoneIDs []string
+	for _, zoneID := range strings.Split(value, ",") {
+		zoneID = strings.TrimSpace(zoneID)
+		if len(zoneID) > 0 {
+			zoneIDs = append(zoneIDs, zoneID)
+		}
+	}
+
+	return zoneIDs
+}
+
+// HostedZoneTag returns the tag used to discover hosted zones as key=value, or
+// only the key if any value matches.
+func (c *Config) HostedZoneTag() string {
+	if len(c.HostedZoneTagValue) == 0 {
+		return c.HostedZoneTagKey
+	}
+
+	return c.HostedZoneTagKey + "=" + c.HostedZoneTagValue
+}
+
+// LoadBalancerTag returns the load balancer tag in the key=value format, or
+// only the key if any value matches.
+func (c *Config) LoadBalancerTag() string {
+	if len(c.LoadBalancerTagValue) == 0 {
+		return c.LoadBalancerTagKey
+	}
+
+	return c.LoadBalancerTagKey + "=" + c.LoadBalancerTagValue
+}
+
+// DatabaseTag returns the database tag in the key=value format, or only the
+// key if any value matches.
+func (c *Config) DatabaseTag() string {
+	if len(c.DatabaseTagValue) == 0 {
+		return c.DatabaseTagKey
+	}
+
+	return c.DatabaseTagKey + "=" + c.DatabaseTagValue
+}
+
+// WritesScrapeConfig reports whether the scrape config is written to a secret
+// or ConfigMap, which can be compared with the previous scrape config.
+func (c *Config) WritesScrapeConfig() bool {
+	return c.OutputKind == OutputSecret || c.OutputKind == OutputConfigMap
+}
@@ -0,0 +1,38 @@
+package reconcile
+
+import (
+	"testing"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+)
+
+// TestBundledTemplateRequirements checks that the bundled scrape config
+// template defines the job of every setting that probes targets in a job of
+// its own.
+func TestBundledTemplateRequirements(t *testing.T) {
+	template, err := render.Load("../../scrapeconfig.yml")
+	if err != nil {
+		t.Fatalf("failed to load the bundled template: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "defaults"},
+		{name: "grpc", config: Config{GRPCProbeModule: "grpc"}},
+		{name: "tcp", config: Config{TCPProbes: []discovery.TCPProbe{{}}}},
+		{name: "websocket", config: Config{WebSocketProbeModule: "websocket"}},
+		{name: "certificates", config: Config{CertificateProbeModule: "https_tls"}},
+		{name: "bind servers", config: Config{BindServers: []string{"10.0.0.1:53", "10.0.0.2:53", "10.0.0.3:53"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, problem := range render.ValidateTemplate(template, test.config.TemplateRequirements()) {
+				t.Error(problem)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package reconcile
+
+import (
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// DatabaseLister is implemented by record listers that can list the endpoints
+// of tagged RDS instances and Aurora clusters.
+type DatabaseLister interface {
+	ListDatabaseEndpoints(tagKey, tagValue string) ([]discovery.DatabaseEndpoint, error)
+}
+
+// listDatabaseEndpoints gets the endpoints of the databases carrying the
+// configured tag, if any.
+func (r *Reconciler) listDatabaseEndpoints(report *Report) ([]discovery.DatabaseEndpoint, error) {
+	if len(r.config.DatabaseTagKey) == 0 {
+		return nil, nil
+	}
+	lister, ok := r.clients.Records.(DatabaseLister)
+	if !ok {
+		return nil, errors.New("the AWS client does not support database discovery")
+	}
+
+	started := time.Now()
+	log.Infof("Getting the endpoints of databases tagged with %s", r.config.DatabaseTag())
+	var endpoints []discovery.DatabaseEndpoint
+	err := r.retry("list the database endpoints", func() error {
+		var err error
+		endpoints, err = lister.ListDatabaseEndpoints(r.config.DatabaseTagKey, r.config.DatabaseTagValue)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to get the database endpoints")
+	}
+	report.phaseDone("List databases", started, "%d endpoints of databases tagged with %s", len(endpoints), r.config.DatabaseTag())
+
+	return endpoints, nil
+}
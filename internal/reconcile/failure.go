@@ -0,0 +1,24 @@
+package reconcile
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Phases that can be failed deliberately with the FailPhase setting.
+const (
+	PhaseAWS        = "aws"
+	PhaseKubernetes = "k8s"
+	PhaseRender     = "render"
+)
+
+// injectFailure returns an error if the configuration asks for the phase to
+// fail, so the notification and escalation path can be tested end to end.
+func (r *Reconciler) injectFailure(phase string) error {
+	if r.config.FailPhase != phase {
+		return nil
+	}
+	log.Warnf("Failing the %s phase as requested by FAIL_PHASE", phase)
+
+	return errors.Errorf("injected failure in the %s phase (FAIL_PHASE=%s)", phase, phase)
+}
@@ -0,0 +1,92 @@
+package reconcile
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/httpsd"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultFileSDConfigMapKey is the key of the file_sd ConfigMap holding the targets.
+const DefaultFileSDConfigMapKey = "blackbox-targets.json"
+
+// FileSDData returns the targets in the Prometheus file_sd JSON format.
+func FileSDData(targets []discovery.Target) ([]byte, error) {
+	data, err := json.MarshalIndent(httpsd.Groups(targets), "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the file_sd targets")
+	}
+
+	return append(data, '\n'), nil
+}
+
+// writeFileSD writes the targets as file_sd JSON to the configured file and
+// ConfigMap, so Prometheus installations using file_sd_configs can consume them.
+func (r *Reconciler) writeFileSD(targets []discovery.Target, report *Report) error {
+	if len(r.config.FileSDPath) == 0 && len(r.config.FileSDConfigMapName) == 0 {
+		return nil
+	}
+
+	started := time.Now()
+	data, err := FileSDData(targets)
+	if err != nil {
+		return err
+	}
+
+	if len(r.config.FileSDPath) > 0 {
+		log.Infof("Writing Blackbox targets to file_sd file %s", r.config.FileSDPath)
+		err = writeFileAtomic(r.config.FileSDPath, data)
+		if err != nil {
+			return errors.Wrapf(err, "failed to write the file_sd file %s", r.config.FileSDPath)
+		}
+	}
+
+	if len(r.config.FileSDConfigMapName) > 0 {
+		log.Infof("Writing Blackbox targets to file_sd ConfigMap %s/%s", r.config.PrometheusNamespace, r.config.FileSDConfigMapName)
+		_, err = r.clients.ConfigMaps.CreateOrUpdateConfigMap(r.config.PrometheusNamespace, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: r.config.FileSDConfigMapName},
+			Data:       map[string]string{r.config.FileSDConfigMapKey: string(data)},
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to write the file_sd ConfigMap")
+		}
+	}
+	report.phaseDone("Write file_sd", started, "%d targets", len(targets))
+
+	return nil
+}
+
+// writeFileAtomic replaces a file by renaming a temporary file next to it, so
+// Prometheus never reads a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	file, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(data)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	err = file.Chmod(0644)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
@@ -0,0 +1,172 @@
+package reconcile
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	historyTargetsKey = "targets"
+	historyEntriesKey = "history"
+
+	// maxHistorySize keeps the history ConfigMap below the 1MiB object size
+	// limit. The oldest entries are dropped once it is exceeded.
+	maxHistorySize = 900 * 1024
+)
+
+// TargetRef identifies a target of a scrape job.
+type TargetRef struct {
+	Job    string `json:"job"`
+	Target string `json:"target"`
+}
+
+// HistoryEntry records the targets added and removed by a run.
+type HistoryEntry struct {
+	Time    time.Time   `json:"time"`
+	RunID   string      `json:"run_id"`
+	Added   []TargetRef `json:"added,omitempty"`
+	Removed []TargetRef `json:"removed,omitempty"`
+}
+
+// recordHistory compares the targets of the run with the snapshot of the
+// previous run kept in the history ConfigMap, appends the added and removed
+// targets to the history and stores the new snapshot. The changes are also
+// counted in the report.
+func (r *Reconciler) recordHistory(blackBoxTargets []discovery.Target, report *Report) error {
+	if len(r.config.HistoryConfigMapName) == 0 {
+		return nil
+	}
+
+	started := time.Now()
+	previous, history, err := r.readHistory()
+	if err != nil {
+		return err
+	}
+
+	current := targetRefs(blackBoxTargets)
+	entry := HistoryEntry{Time: time.Now().UTC(), RunID: report.RunID}
+	entry.Added = missingRefs(current, previous)
+	entry.Removed = missingRefs(previous, current)
+	report.TargetsAdded = len(entry.Added)
+	report.TargetsRemoved = len(entry.Removed)
+	if len(entry.Added) > 0 || len(entry.Removed) > 0 {
+		history = append(history, entry)
+	}
+
+	snapshot, err := json.Marshal(current)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the target snapshot")
+	}
+	var entries []byte
+	for {
+		entries, err = json.Marshal(history)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the target history")
+		}
+		if len(snapshot)+len(entries) <= maxHistorySize || len(history) == 0 {
+			break
+		}
+		history = history[1:]
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: r.config.HistoryConfigMapName,
+		},
+		Data: map[string]string{
+			historyTargetsKey: string(snapshot),
+			historyEntriesKey: string(entries),
+		},
+	}
+	_, err = r.clients.ConfigMaps.CreateOrUpdateConfigMap(r.config.PrometheusNamespace, configMap)
+	if err != nil {
+		return errors.Wrap(err, "failed to save the target history")
+	}
+	report.phaseDone("Record history", started, "%d added, %d removed", len(entry.Added), len(entry.Removed))
+
+	return nil
+}
+
+// History returns the recorded changes of the targets, oldest first.
+func (r *Reconciler) History() ([]HistoryEntry, error) {
+	if len(r.config.HistoryConfigMapName) == 0 {
+		return nil, errors.New("HISTORY_CONFIGMAP_NAME is not set, no target history is recorded")
+	}
+	_, history, err := r.readHistory()
+
+	return history, err
+}
+
+// readHistory returns the target snapshot of the previous run and the
+// recorded history.
+func (r *Reconciler) readHistory() ([]TargetRef, []HistoryEntry, error) {
+	configMap, err := r.clients.ConfigMaps.GetConfigMap(r.config.PrometheusNamespace, r.config.HistoryConfigMapName)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get the target history")
+	}
+	if configMap == nil {
+		return nil, nil, nil
+	}
+
+	var snapshot []TargetRef
+	if len(configMap.Data[historyTargetsKey]) > 0 {
+		err = json.Unmarshal([]byte(configMap.Data[historyTargetsKey]), &snapshot)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to parse the target snapshot")
+		}
+	}
+	var history []HistoryEntry
+	if len(configMap.Data[historyEntriesKey]) > 0 {
+		err = json.Unmarshal([]byte(configMap.Data[historyEntriesKey]), &history)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to parse the target history")
+		}
+	}
+
+	return snapshot, history, nil
+}
+
+// targetRefs returns the sorted, unique references of the targets.
+func targetRefs(targets []discovery.Target) []TargetRef {
+	seen := map[TargetRef]bool{}
+	var refs []TargetRef
+	for _, target := range targets {
+		ref := TargetRef{Job: target.Job, Target: target.Target}
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Job != refs[j].Job {
+			return refs[i].Job < refs[j].Job
+		}
+		return refs[i].Target < refs[j].Target
+	})
+
+	return refs
+}
+
+// missingRefs returns the references of a that are not in b.
+func missingRefs(a, b []TargetRef) []TargetRef {
+	inB := map[TargetRef]bool{}
+	for _, ref := range b {
+		inB[ref] = true
+	}
+	var missing []TargetRef
+	for _, ref := range a {
+		if !inB[ref] {
+			missing = append(missing, ref)
+		}
+	}
+
+	return missing
+}
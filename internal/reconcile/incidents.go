@@ -0,0 +1,80 @@
+package reconcile
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/notify"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// incidentNotifiers returns the configured incident notifiers.
+func incidentNotifiers(config *Config) []notify.IncidentNotifier {
+	var notifiers []notify.IncidentNotifier
+	if len(config.PagerDutyRoutingKey) > 0 {
+		notifiers = append(notifiers, &notify.PagerDuty{RoutingKey: config.PagerDutyRoutingKey})
+	}
+	if len(config.OpsgenieAPIKey) > 0 {
+		notifiers = append(notifiers, &notify.Opsgenie{
+			APIKey:   config.OpsgenieAPIKey,
+			APIURL:   config.OpsgenieAPIURL,
+			Priority: config.OpsgeniePriority,
+		})
+	}
+
+	return notifiers
+}
+
+// incidentKey identifies the incident of the Prometheus secret the discovery maintains.
+func (r *Reconciler) incidentKey() string {
+	return fmt.Sprintf("blackbox-target-discovery/%s/%s", r.config.PrometheusNamespace, r.config.PrometheusSecretName)
+}
+
+// trackIncident raises an incident once the consecutive failures reach the
+// incident threshold and resolves it after the next successful run.
+func (r *Reconciler) trackIncident(state *runState, report *Report) error {
+	notifiers := incidentNotifiers(r.config)
+	if len(notifiers) == 0 {
+		return nil
+	}
+
+	if report.Success {
+		if !state.OpenIncident {
+			return nil
+		}
+		log.Info("Run succeeded, resolving the discovery failure incident")
+		for _, notifier := range notifiers {
+			err := notifier.ResolveIncident(r.incidentKey())
+			if err != nil {
+				return err
+			}
+		}
+		state.OpenIncident = false
+		return nil
+	}
+
+	if state.OpenIncident || state.ConsecutiveFailures < r.config.IncidentFailureThreshold {
+		return nil
+	}
+	log.Info("Raising an incident for the persistent discovery failure")
+	incident := notify.Incident{
+		Key:     r.incidentKey(),
+		Summary: fmt.Sprintf("Blackbox target discovery for %s/%s failed %d consecutive times", r.config.PrometheusNamespace, r.config.PrometheusSecretName, state.ConsecutiveFailures),
+		Details: map[string]string{
+			"error":                report.Error,
+			"run_id":               report.RunID,
+			"consecutive_failures": strconv.Itoa(state.ConsecutiveFailures),
+			"build":                report.Build.String(),
+		},
+	}
+	for _, notifier := range notifiers {
+		err := notifier.TriggerIncident(incident)
+		if err != nil {
+			return errors.Wrap(err, "failed to raise the discovery failure incident")
+		}
+	}
+	state.OpenIncident = true
+
+	return nil
+}
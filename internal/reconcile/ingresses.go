@@ -0,0 +1,40 @@
+package reconcile
+
+import (
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// KubeIngresses lists the hosts of the Ingresses opted in to be probed.
+type KubeIngresses interface {
+	ListProbedIngressHosts() ([]discovery.IngressHost, error)
+}
+
+// listIngressHosts gets the hosts of the Ingresses carrying the probe
+// annotation, if Ingress discovery is enabled.
+func (r *Reconciler) listIngressHosts(report *Report) ([]discovery.IngressHost, error) {
+	if !r.config.DiscoverIngresses {
+		return nil, nil
+	}
+	if r.clients.Ingresses == nil {
+		return nil, errors.New("the Kubernetes client does not support Ingress discovery")
+	}
+
+	started := time.Now()
+	log.Infof("Getting the hosts of Ingresses annotated with %s", discovery.ProbeAnnotation)
+	var hosts []discovery.IngressHost
+	err := r.retry("list the Ingresses", func() error {
+		var err error
+		hosts, err = r.clients.Ingresses.ListProbedIngressHosts()
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to get the Ingress hosts")
+	}
+	report.phaseDone("List Ingresses", started, "%d hosts of Ingresses annotated with %s", len(hosts), discovery.ProbeAnnotation)
+
+	return hosts, nil
+}
@@ -0,0 +1,40 @@
+package reconcile
+
+import (
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// InstallationLister lists the installations of the provisioning server.
+type InstallationLister interface {
+	ListInstallations() ([]discovery.Installation, error)
+}
+
+// listInstallations gets the installations of the provisioning server, if
+// one is configured.
+func (r *Reconciler) listInstallations(report *Report) ([]discovery.Installation, error) {
+	if len(r.config.ProvisionerURL) == 0 {
+		return nil, nil
+	}
+	if r.clients.Installations == nil {
+		return nil, errors.New("no provisioner client is configured")
+	}
+
+	started := time.Now()
+	log.Infof("Getting the installations of the provisioner at %s", r.config.ProvisionerURL)
+	var installations []discovery.Installation
+	err := r.retry("list the provisioner installations", func() error {
+		var err error
+		installations, err = r.clients.Installations.ListInstallations()
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to get the provisioner installations")
+	}
+	report.phaseDone("List installations", started, "%d provisioner installations", len(installations))
+
+	return installations, nil
+}
@@ -0,0 +1,42 @@
+package reconcile
+
+import (
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// LoadBalancerLister is implemented by record listers that can list the
+// listeners of tagged Elastic Load Balancing v2 load balancers.
+type LoadBalancerLister interface {
+	ListLoadBalancerListeners(tagKey, tagValue string) ([]discovery.LoadBalancerListener, error)
+}
+
+// listLoadBalancerListeners gets the listeners of the load balancers carrying
+// the configured tag, if any.
+func (r *Reconciler) listLoadBalancerListeners(report *Report) ([]discovery.LoadBalancerListener, error) {
+	if len(r.config.LoadBalancerTagKey) == 0 {
+		return nil, nil
+	}
+	lister, ok := r.clients.Records.(LoadBalancerLister)
+	if !ok {
+		return nil, errors.New("the AWS client does not support load balancer discovery")
+	}
+
+	started := time.Now()
+	log.Infof("Getting the listeners of load balancers tagged with %s", r.config.LoadBalancerTag())
+	var listeners []discovery.LoadBalancerListener
+	err := r.retry("list the load balancer listeners", func() error {
+		var err error
+		listeners, err = lister.ListLoadBalancerListeners(r.config.LoadBalancerTagKey, r.config.LoadBalancerTagValue)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to get the load balancer listeners")
+	}
+	report.phaseDone("List load balancers", started, "%d listeners of load balancers tagged with %s", len(listeners), r.config.LoadBalancerTag())
+
+	return listeners, nil
+}
@@ -0,0 +1,46 @@
+package reconcile
+
+import (
+	"sort"
+
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+)
+
+// maintenanceWindows collects the maintenance windows from the configuration,
+// the maintenance ConfigMap and the maintenance TXT records of all zones.
+func (r *Reconciler) maintenanceWindows(publicRecords, privateRecords []*route53types.ResourceRecordSet) ([]discovery.MaintenanceWindow, error) {
+	windows := append([]discovery.MaintenanceWindow{}, r.config.MaintenanceWindows...)
+
+	for _, records := range [][]*route53types.ResourceRecordSet{publicRecords, privateRecords} {
+		recordWindows, err := discovery.MaintenanceWindowsFromRecords(records)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, recordWindows...)
+	}
+
+	if len(r.config.MaintenanceConfigMapName) > 0 && r.clients.ConfigMaps != nil {
+		configMap, err := r.clients.ConfigMaps.GetConfigMap(r.config.PrometheusNamespace, r.config.MaintenanceConfigMapName)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get the maintenance ConfigMap")
+		}
+		if configMap != nil {
+			var keys []string
+			for key := range configMap.Data {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				configMapWindows, err := discovery.ParseMaintenanceWindows(configMap.Data[key])
+				if err != nil {
+					return nil, errors.Wrapf(err, "invalid maintenance window %s in ConfigMap %s", key, r.config.MaintenanceConfigMapName)
+				}
+				windows = append(windows, configMapWindows...)
+			}
+		}
+	}
+
+	return windows, nil
+}
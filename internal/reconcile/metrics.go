@@ -0,0 +1,61 @@
+package reconcile
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/export"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// pushgatewayJob is the job the run metrics are pushed as.
+const pushgatewayJob = "blackbox-target-discovery"
+
+// RunMetrics returns the metrics of a completed run in the Prometheus text
+// exposition format, together with the build_info metric.
+func RunMetrics(report *Report) string {
+	success := 0
+	if report.Success {
+		success = 1
+	}
+
+	var metrics strings.Builder
+	for _, metric := range []struct {
+		name, help string
+		value      interface{}
+	}{
+		{"blackbox_discovery_last_run_timestamp", "Unix time the last Blackbox target discovery run finished.", report.FinishedAt.Unix()},
+		{"blackbox_discovery_success", "Whether the last Blackbox target discovery run succeeded.", success},
+		{"blackbox_discovery_run_duration_seconds", "Duration of the last Blackbox target discovery run.", report.FinishedAt.Sub(report.StartedAt).Seconds()},
+		{"blackbox_discovery_targets_total", "Number of targets discovered by the last run.", report.TargetCount},
+		{"blackbox_discovery_targets_added", "Number of targets the last run added.", report.TargetsAdded},
+		{"blackbox_discovery_targets_removed", "Number of targets the last run removed.", report.TargetsRemoved},
+	} {
+		fmt.Fprintf(&metrics, "# HELP %s %s\n# TYPE %s gauge\n%s %v\n", metric.name, metric.help, metric.name, metric.name, metric.value)
+	}
+	metrics.WriteString(report.Build.BuildInfoMetric())
+
+	return metrics.String()
+}
+
+// pushMetrics pushes the metrics of a completed run to the Pushgateway,
+// grouped by the Prometheus secret the run maintains.
+func (r *Reconciler) pushMetrics(report *Report) error {
+	if len(r.config.PushgatewayURL) == 0 {
+		return nil
+	}
+
+	started := time.Now()
+	err := export.PushMetrics(r.config.PushgatewayURL, pushgatewayJob, map[string]string{
+		"namespace": r.config.PrometheusNamespace,
+		"secret":    r.config.PrometheusSecretName,
+	}, RunMetrics(report))
+	if err != nil {
+		return errors.Wrap(err, "failed to push the run metrics to the Pushgateway")
+	}
+	log.Debugf("Pushed the run metrics to the Pushgateway in %s", time.Since(started))
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package reconcile
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Migration converts a managed Prometheus secret to the current format of the
+// Blackbox target discovery and optionally moves it to a new location.
+type Migration struct {
+	FromNamespace string
+	FromSecret    string
+	ToNamespace   string
+	ToSecret      string
+	// DeleteSource deletes the source secret after a successful cutover to a new location.
+	DeleteSource bool
+	// DryRun converts and validates the secret without writing anything.
+	DryRun bool
+}
+
+// MigrationResult describes a migrated secret.
+type MigrationResult struct {
+	Targets int
+	// Unchanged is true if the target secret already had the converted content.
+	Unchanged bool
+}
+
+// Migrate reads the source secret, converts it to the current format and
+// validates that the converted scrape config is equivalent to the original.
+// The converted secret is then written and read back. If writing or verifying
+// it fails, the target secret is restored to its previous state. The source
+// secret is only deleted once the cutover has been verified.
+func (r *Reconciler) Migrate(m Migration) (*MigrationResult, error) {
+	sameLocation := m.FromNamespace == m.ToNamespace && m.FromSecret == m.ToSecret
+	if sameLocation && m.DeleteSource {
+		return nil, errors.New("the source secret can only be deleted when migrating to a new location")
+	}
+
+	source, err := r.clients.Secrets.GetSecret(m.FromNamespace, m.FromSecret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get secret %s/%s", m.FromNamespace, m.FromSecret)
+	}
+	if source == nil {
+		return nil, errors.Errorf("secret %s/%s does not exist", m.FromNamespace, m.FromSecret)
+	}
+	if chunkCount(source.Annotations) > 0 || r.config.SecretChunking {
+		return nil, errors.New("chunked secrets cannot be migrated, the next run writes them in the current format")
+	}
+	// Secrets written before the keys were configured only have the default key.
+	sourceData, err := r.joinScrapeConfig(source.Data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the scrape config of secret %s/%s", m.FromNamespace, m.FromSecret)
+	}
+	if sourceData == nil {
+		sourceData = source.Data[ScrapeConfigSecretKey]
+	}
+	if sourceData == nil {
+		return nil, errors.Errorf("secret %s/%s has none of the %s keys", m.FromNamespace, m.FromSecret, strings.Join(r.config.ScrapeConfigKeys(), ", "))
+	}
+
+	log.Infof("Converting secret %s/%s", m.FromNamespace, m.FromSecret)
+	config, err := render.Parse(sourceData)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the scrape config of secret %s/%s", m.FromNamespace, m.FromSecret)
+	}
+	data, err := config.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	differences, err := render.Differences(sourceData, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(differences) > 0 {
+		return nil, errors.Errorf("the converted scrape config is not equivalent to the original, it differs at %s", strings.Join(differences, ", "))
+	}
+
+	converted, err := r.NewScrapeConfigSecret(m.ToSecret, data)
+	if err != nil {
+		return nil, err
+	}
+	// Keep the Blackbox modules of the source, the next run regenerates them from the configuration.
+	if modules, ok := source.Data[BlackboxModulesSecretKey]; ok {
+		converted.Data[BlackboxModulesSecretKey] = modules
+	}
+
+	result := &MigrationResult{}
+	for _, job := range config {
+		result.Targets += len(job.Targets())
+	}
+
+	previous, err := r.clients.Secrets.GetSecret(m.ToNamespace, m.ToSecret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get secret %s/%s", m.ToNamespace, m.ToSecret)
+	}
+	if previous != nil && previous.Annotations[ContentHashAnnotation] == converted.Annotations[ContentHashAnnotation] {
+		if generatedAt, ok := previous.Annotations[GeneratedAtAnnotation]; ok {
+			converted.Annotations[GeneratedAtAnnotation] = generatedAt
+		}
+	}
+	if previous != nil && sameSecretData(previous, converted) && sameAnnotations(previous, converted) {
+		result.Unchanged = true
+	}
+	if m.DryRun || result.Unchanged {
+		return result, r.deleteSource(m, sameLocation)
+	}
+
+	log.Infof("Writing converted secret %s/%s", m.ToNamespace, m.ToSecret)
+	err = r.cutover(m.ToNamespace, converted, previous)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, r.deleteSource(m, sameLocation)
+}
+
+// cutover writes the converted secret and verifies it, restoring the previous
+// secret, or deleting the new one if there was none, when that fails.
+func (r *Reconciler) cutover(namespace string, converted, previous *corev1.Secret) error {
+	_, err := r.clients.Secrets.CreateOrUpdateSecret(namespace, converted)
+	if err == nil {
+		var written *corev1.Secret
+		written, err = r.clients.Secrets.GetSecret(namespace, converted.Name)
+		if err == nil && (written == nil || !sameSecretData(written, converted)) {
+			err = errors.New("the written secret does not match the converted secret")
+		}
+	}
+	if err == nil {
+		return nil
+	}
+
+	log.WithError(err).Errorf("Migration of secret %s/%s failed, rolling back", namespace, converted.Name)
+	var rollbackErr error
+	if previous == nil {
+		rollbackErr = r.clients.Secrets.DeleteSecret(namespace, converted.Name)
+	} else {
+		previous = previous.DeepCopy()
+		previous.ResourceVersion = ""
+		_, rollbackErr = r.clients.Secrets.CreateOrUpdateSecret(namespace, previous)
+	}
+	if rollbackErr != nil {
+		return errors.Wrapf(err, "failed to migrate secret %s/%s and to roll it back (%s)", namespace, converted.Name, rollbackErr)
+	}
+
+	return errors.Wrapf(err, "failed to migrate secret %s/%s, the previous state was restored", namespace, converted.Name)
+}
+
+// deleteSource deletes the source secret of a migration to a new location, if requested.
+func (r *Reconciler) deleteSource(m Migration, sameLocation bool) error {
+	if !m.DeleteSource || sameLocation || m.DryRun {
+		return nil
+	}
+
+	log.Infof("Deleting source secret %s/%s", m.FromNamespace, m.FromSecret)
+	return errors.Wrapf(r.clients.Secrets.DeleteSecret(m.FromNamespace, m.FromSecret), "failed to delete secret %s/%s", m.FromNamespace, m.FromSecret)
+}
+
+func sameSecretData(a, b *corev1.Secret) bool {
+	if len(a.Data) != len(b.Data) {
+		return false
+	}
+	for key, value := range a.Data {
+		if !bytes.Equal(value, b.Data[key]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sameAnnotations(a, b *corev1.Secret) bool {
+	for key, value := range b.Annotations {
+		if a.Annotations[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
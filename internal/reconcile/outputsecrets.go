@@ -0,0 +1,228 @@
+package reconcile
+
+import (
+	"strings"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+	"github.com/pkg/errors"
+)
+
+// bindJobPrefix is the name prefix of the BIND server scrape jobs.
+const bindJobPrefix = "bind-server-"
+
+// SecretTemplate names an additional Prometheus secret and the template
+// defining the scrape jobs it holds, so different Prometheus instances can
+// mount only the jobs they need.
+type SecretTemplate struct {
+	Name     string
+	Template string
+}
+
+// ParseOutputSecrets parses semicolon-separated secret_name=template rules.
+// The secret names must differ from each other and from the main secret.
+func ParseOutputSecrets(value, mainSecretName string) ([]SecretTemplate, error) {
+	var secrets []SecretTemplate
+	seen := map[string]bool{mainSecretName: true}
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return nil, errors.Errorf("invalid output secret %q, expected secret_name=template", entry)
+		}
+		secret := SecretTemplate{Name: strings.TrimSpace(parts[0]), Template: strings.TrimSpace(parts[1])}
+		if seen[secret.Name] {
+			return nil, errors.Errorf("secret %s is written more than once", secret.Name)
+		}
+		seen[secret.Name] = true
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, nil
+}
+
+// outputSecretNames returns the names of the secrets holding the scrape
+// config, the main secret first.
+func (c *Config) outputSecretNames(mainSecretName string) []string {
+	names := []string{mainSecretName}
+	for _, secret := range c.OutputSecrets {
+		names = append(names, secret.Name)
+	}
+
+	return names
+}
+
+// LoadTemplates loads the scrape config template and the templates of the
+// output secrets, and returns their jobs in the order they are written.
+func (c *Config) LoadTemplates() (render.Config, error) {
+	config, err := render.LoadTemplate(c.ScrapeConfigTemplate, c.BindServers)
+	if err != nil {
+		return nil, errors.Wrapf(err, "SCRAPE_CONFIG_TEMPLATE %s is invalid", c.ScrapeConfigTemplate)
+	}
+	templates, err := c.outputTemplates()
+	if err != nil {
+		return nil, err
+	}
+	for _, template := range templates {
+		config = append(config, template...)
+	}
+
+	return config, nil
+}
+
+// outputTemplates loads the templates of the output secrets.
+func (c *Config) outputTemplates() ([]render.Config, error) {
+	var templates []render.Config
+	for _, secret := range c.OutputSecrets {
+		template, err := render.LoadTemplate(secret.Template, c.BindServers)
+		if err != nil {
+			return nil, errors.Wrapf(err, "template %s of output secret %s is invalid", secret.Template, secret.Name)
+		}
+		templates = append(templates, template)
+	}
+
+	return templates, nil
+}
+
+// outputOf returns the position in outputSecretNames of the secret holding a
+// job. Jobs no output template defines are held by the main secret. The BIND
+// server jobs are held by the first output secret whose template defines any
+// BIND server job, since jobs are added for servers the template has none for.
+func outputOf(templates []render.Config, jobName string) int {
+	for i, template := range templates {
+		if template.FindJob(jobName) >= 0 {
+			return i + 1
+		}
+	}
+	if strings.HasPrefix(jobName, bindJobPrefix) {
+		return bindOutput(templates)
+	}
+
+	return 0
+}
+
+// bindOutput returns the position of the secret holding the BIND server jobs.
+func bindOutput(templates []render.Config) int {
+	for i, template := range templates {
+		for _, job := range template {
+			if strings.HasPrefix(job.JobName, bindJobPrefix) {
+				return i + 1
+			}
+		}
+	}
+
+	return 0
+}
+
+// renderOutputs renders the targets into the template of the secret holding
+// their job, and returns the jobs of every secret in the order they are
+// written, the main secret first.
+func (r *Reconciler) renderOutputs(blackBoxTargets []discovery.Target) (render.Config, error) {
+	templates, err := r.config.outputTemplates()
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{r.config.ScrapeConfigTemplate}
+	for _, secret := range r.config.OutputSecrets {
+		paths = append(paths, secret.Template)
+	}
+
+	targets := make([][]discovery.Target, len(paths))
+	for _, target := range blackBoxTargets {
+		i := outputOf(templates, target.Job)
+		targets[i] = append(targets[i], target)
+	}
+
+	var config render.Config
+	bindSecret := bindOutput(templates)
+	for i, path := range paths {
+		var bindServers []string
+		if i == bindSecret {
+			bindServers = r.config.BindServers
+		}
+		part, err := render.RenderFile(path, targets[i], bindServers)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to render the scrape config of secret %s", r.config.outputSecretNames(r.config.PrometheusSecretName)[i])
+		}
+		render.ApplyScrapeIntervals(part, r.config.TemplateRequirements().Intervals)
+		config = append(config, r.orderJobsByKey(part)...)
+	}
+
+	return config, nil
+}
+
+// splitOutputs returns the scrape config of every secret, the main secret
+// first. Every secret is written, a secret without jobs holds an empty list.
+func (r *Reconciler) splitOutputs(data []byte) ([][]byte, error) {
+	if len(r.config.OutputSecrets) == 0 {
+		return [][]byte{data}, nil
+	}
+	templates, err := r.config.outputTemplates()
+	if err != nil {
+		return nil, err
+	}
+	config, err := render.Parse(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the scrape config")
+	}
+
+	parts := make([]render.Config, len(templates)+1)
+	for _, job := range config {
+		i := outputOf(templates, job.JobName)
+		parts[i] = append(parts[i], job)
+	}
+	var split [][]byte
+	for _, part := range parts {
+		if part == nil {
+			part = render.Config{}
+		}
+		partData, err := part.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		split = append(split, partData)
+	}
+
+	return split, nil
+}
+
+// currentOutputs appends the scrape config of the existing output secrets to
+// the scrape config of the main secret, in the order they are written.
+func (r *Reconciler) currentOutputs(namespace string, mainData []byte) ([]byte, error) {
+	if len(r.config.OutputSecrets) == 0 {
+		return mainData, nil
+	}
+
+	var joined render.Config
+	found := mainData != nil
+	if found {
+		main, err := render.Parse(mainData)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse the scrape config of the main secret")
+		}
+		joined = append(joined, main...)
+	}
+	for _, secretName := range r.config.outputSecretNames("")[1:] {
+		data, err := r.currentScrapeConfig(namespace, secretName)
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			continue
+		}
+		found = true
+		part, err := render.Parse(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the scrape config of secret %s", secretName)
+		}
+		joined = append(joined, part...)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return joined.Marshal()
+}
@@ -0,0 +1,141 @@
+package reconcile
+
+import (
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultQuarantineRuns is the number of runs an unreachable new target
+	// is held back before it is added anyway.
+	DefaultQuarantineRuns = 3
+
+	// preflightWorkers is the number of new targets probed at the same time.
+	preflightWorkers = 10
+)
+
+// preflight probes the targets that are not part of the current scrape
+// config once and holds back the unreachable ones, so half-provisioned
+// endpoints don't page on-call. A target is quarantined for at most the
+// configured number of runs and added anyway afterwards. Nothing is probed
+// on the first run, when there is no scrape config to compare with.
+func (r *Reconciler) preflight(blackBoxTargets []discovery.Target, report *Report) ([]discovery.Target, error) {
+	if r.config.PreflightTimeout == 0 {
+		return blackBoxTargets, nil
+	}
+
+	currentData, err := r.CurrentScrapeConfig(r.config.PrometheusNamespace, r.config.PrometheusSecretName)
+	if err != nil {
+		return nil, err
+	}
+	if len(currentData) == 0 {
+		return blackBoxTargets, nil
+	}
+	current, err := render.Parse(currentData)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the current scrape config")
+	}
+	existing := map[string]bool{}
+	for _, job := range current {
+		for _, target := range job.Targets() {
+			existing[target] = true
+		}
+	}
+
+	state, err := getRunState(r.clients.ConfigMaps, r.config.PrometheusNamespace, r.config.StateConfigMapName)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the run state")
+	}
+
+	started := time.Now()
+	var newTargets []discovery.Target
+	for _, target := range blackBoxTargets {
+		if !existing[target.Target] {
+			newTargets = append(newTargets, target)
+		}
+	}
+	unreachable := r.probeTargets(newTargets)
+
+	report.quarantine = map[string]int{}
+	var kept []discovery.Target
+	for _, target := range blackBoxTargets {
+		probeErr, failed := unreachable[target.Target]
+		if !failed {
+			kept = append(kept, target)
+			continue
+		}
+		runs := state.Quarantine[target.Target] + 1
+		if runs > r.config.QuarantineRuns {
+			log.WithError(probeErr).Warnf("New target %s is still unreachable after %d quarantined runs, adding it", target.Target, r.config.QuarantineRuns)
+			kept = append(kept, target)
+			continue
+		}
+		log.WithError(probeErr).Warnf("New target %s is unreachable, quarantining it (run %d of %d)", target.Target, runs, r.config.QuarantineRuns)
+		report.quarantine[target.Target] = runs
+		report.Quarantined = append(report.Quarantined, target.Target)
+	}
+	sort.Strings(report.Quarantined)
+	report.phaseDone("Preflight new targets", started, "%d new targets, %d quarantined", len(newTargets), len(report.Quarantined))
+
+	return kept, nil
+}
+
+// probeTargets probes each target once and returns the error of every
+// unreachable target.
+func (r *Reconciler) probeTargets(targets []discovery.Target) map[string]error {
+	unreachable := map[string]error{}
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, preflightWorkers)
+	for _, target := range targets {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(target discovery.Target) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			err := probeTarget(target, r.config.PreflightTimeout)
+			if err != nil {
+				mutex.Lock()
+				unreachable[target.Target] = err
+				mutex.Unlock()
+			}
+		}(target)
+	}
+	wg.Wait()
+
+	return unreachable
+}
+
+// probeTarget checks that a target answers. URLs and the targets of the HTTP
+// probe job must return an HTTP response, whatever its status, the other
+// targets must accept a TCP connection.
+func probeTarget(target discovery.Target, timeout time.Duration) error {
+	address := target.Target
+	if !strings.Contains(address, "://") && target.Job != discovery.DefaultJobName {
+		conn, err := net.DialTimeout("tcp", address, timeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(address)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
@@ -0,0 +1,140 @@
+package reconcile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// outputLabel marks the custom resources written by the discovery with the
+// name of the output, so resources that are no longer wanted can be deleted.
+const outputLabel = "blackbox-target-discovery.mattermost.com/output"
+
+// probeResource is the Prometheus Operator Probe custom resource.
+var probeResource = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "probes"}
+
+// ProbeResources converts the probe jobs of a rendered scrape config into
+// Prometheus Operator Probe resources, one per target group. The prober URL,
+// scheme, path, interval and timeout are taken from the job. Jobs that are not
+// probed through the Blackbox exporter, such as the BIND servers, are skipped.
+func ProbeResources(config render.Config, output string) ([]*unstructured.Unstructured, error) {
+	var resources []*unstructured.Unstructured
+	for _, job := range config {
+		if !job.IsProbe() {
+			continue
+		}
+		prober, err := proberAddress(job)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, staticConfig := range job.StaticConfigs {
+			if len(staticConfig.Targets) == 0 {
+				continue
+			}
+			module := staticConfig.Labels["module"]
+			if len(module) == 0 && len(job.Params.Module) > 0 {
+				module = job.Params.Module[0]
+			}
+
+			spec := map[string]interface{}{
+				"jobName": job.JobName,
+				"module":  module,
+				"prober": withoutEmpty(map[string]interface{}{
+					"url":    prober,
+					"scheme": job.Scheme,
+					"path":   job.MetricsPath,
+				}),
+				"targets": map[string]interface{}{
+					"staticConfig": map[string]interface{}{
+						"static": stringList(staticConfig.Targets),
+						"labels": stringMap(staticConfig.Labels),
+					},
+				},
+			}
+			if len(job.ScrapeInterval) > 0 {
+				spec["interval"] = job.ScrapeInterval
+			}
+			if len(job.ScrapeTimeout) > 0 {
+				spec["scrapeTimeout"] = job.ScrapeTimeout
+			}
+
+			resources = append(resources, &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "monitoring.coreos.com/v1",
+				"kind":       "Probe",
+				"metadata": map[string]interface{}{
+					"name":   groupResourceName(output, job.JobName, staticConfig.Labels),
+					"labels": map[string]interface{}{outputLabel: output},
+				},
+				"spec": spec,
+			}})
+		}
+	}
+
+	return resources, nil
+}
+
+// proberAddress returns the Blackbox exporter address a probe job sends its
+// requests to, which the template sets by relabeling __address__.
+func proberAddress(job render.Job) (string, error) {
+	for _, relabelConfig := range job.RelabelConfigs {
+		if relabelConfig.TargetLabel == "__address__" && len(relabelConfig.Replacement) > 0 {
+			return relabelConfig.Replacement, nil
+		}
+	}
+
+	return "", errors.Errorf("job %s has no relabel config setting the Blackbox exporter __address__", job.JobName)
+}
+
+// groupResourceName returns a stable resource name for a target group,
+// derived from the output, the job and the labels of the group.
+func groupResourceName(output, jobName string, labels map[string]string) string {
+	var names []string
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	hash := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(hash, "%s=%s\n", name, labels[name])
+	}
+
+	return strings.ToLower(fmt.Sprintf("%s-%s-%s", output, jobName, hex.EncodeToString(hash.Sum(nil))[:8]))
+}
+
+// stringList and stringMap convert values to the JSON types unstructured objects require.
+func stringList(values []string) []interface{} {
+	list := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		list = append(list, value)
+	}
+
+	return list
+}
+
+func stringMap(values map[string]string) map[string]interface{} {
+	result := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		result[name] = value
+	}
+
+	return result
+}
+
+func withoutEmpty(values map[string]interface{}) map[string]interface{} {
+	for name, value := range values {
+		if value == "" {
+			delete(values, name)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,751 @@
+package reconcile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/export"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/notify"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/version"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// ScrapeConfigSecretKey is the default secret key holding the scrape config.
+	ScrapeConfigSecretKey = "scrape_config_secret.yaml"
+	// BlackboxModulesSecretKey is the secret key holding the generated Blackbox exporter modules.
+	BlackboxModulesSecretKey = "blackbox_modules.yaml"
+	// VersionAnnotation records the version of the build that wrote the secret.
+	VersionAnnotation = "blackbox-target-discovery.mattermost.com/version"
+	// CommitAnnotation records the commit of the build that wrote the secret.
+	CommitAnnotation = "blackbox-target-discovery.mattermost.com/commit"
+	// TargetCountAnnotation records the number of probe targets of the scrape config.
+	TargetCountAnnotation = "blackbox-target-discovery.mattermost.com/target-count"
+	// GeneratedAtAnnotation records when the scrape config of the secret last
+	// changed, in RFC 3339 format.
+	GeneratedAtAnnotation = "blackbox-target-discovery.mattermost.com/generated-at"
+	// SourceZonesAnnotation records the comma-separated configured zone IDs
+	// the targets were discovered in.
+	SourceZonesAnnotation = "blackbox-target-discovery.mattermost.com/source-zones"
+	// SourceZoneTagAnnotation records the tag of the discovered hosted zones.
+	SourceZoneTagAnnotation = "blackbox-target-discovery.mattermost.com/source-zone-tag"
+	// ContentHashAnnotation records the SHA-256 of the scrape config of the secret.
+	ContentHashAnnotation = "blackbox-target-discovery.mattermost.com/content-hash"
+	// ManagedByLabel marks the objects written by the discovery.
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	// ManagedBy is the value of the ManagedByLabel.
+	ManagedBy = "blackbox-target-discovery"
+)
+
+// RecordLister lists the Route53 records of a hosted zone.
+type RecordLister interface {
+	ListAllRecordSets(hostedZoneID string) ([]*route53types.ResourceRecordSet, error)
+}
+
+// KubeSecrets reads and writes Kubernetes secrets.
+type KubeSecrets interface {
+	GetSecret(namespace, name string) (*corev1.Secret, error)
+	CreateOrUpdateSecret(namespace string, secret *corev1.Secret) (metav1.Object, error)
+	DeleteSecret(namespace, name string) error
+}
+
+// KubeConfigMaps reads and writes Kubernetes ConfigMaps.
+type KubeConfigMaps interface {
+	GetConfigMap(namespace, name string) (*corev1.ConfigMap, error)
+	CreateOrUpdateConfigMap(namespace string, configMap *corev1.ConfigMap) (metav1.Object, error)
+}
+
+// KubeResources writes the custom resources of the Prometheus Operator outputs.
+type KubeResources interface {
+	SyncResources(namespace string, resource schema.GroupVersionResource, ownerLabel, owner string, objects []*unstructured.Unstructured) error
+}
+
+// Clients holds the external dependencies of the Blackbox target discovery.
+type Clients struct {
+	Records RecordLister
+	// ZoneRecords are the record listers of the zones not hosted in Route53,
+	// by zone ID.
+	ZoneRecords   map[string]RecordLister
+	Objects       export.ObjectStore
+	Secrets       KubeSecrets
+	ConfigMaps    KubeConfigMaps
+	Resources     KubeResources
+	Workloads     KubeWorkloads
+	Ingresses     KubeIngresses
+	Services      KubeServices
+	Certificates  KubeCertificates
+	Installations InstallationLister
+	Notifier      notify.Notifier
+}
+
+// Reconciler keeps the Prometheus scrape config secret up to date with the Blackbox targets.
+type Reconciler struct {
+	config  *Config
+	clients *Clients
+}
+
+// New creates a Reconciler.
+func New(config *Config, clients *Clients) *Reconciler {
+	return &Reconciler{config: config, clients: clients}
+}
+
+// Config returns the configuration of the reconciler.
+func (r *Reconciler) Config() *Config {
+	return r.config
+}
+
+// Clients returns the clients used by the reconciler.
+func (r *Reconciler) Clients() *Clients {
+	return r.clients
+}
+
+// Run is used to keep Prometheus up to date with Blackbox targets.
+func (r *Reconciler) Run(report *Report) error {
+	config, blackBoxTargets, err := r.GenerateScrapeConfig(report)
+	if err != nil {
+		return err
+	}
+	err = r.CheckMinTargets(blackBoxTargets)
+	if err != nil {
+		return err
+	}
+	err = r.CheckTargetDrop(config)
+	if err != nil {
+		return err
+	}
+	if len(blackBoxTargets) < 1 {
+		log.Info("No targets to register, canceling run")
+		return nil
+	}
+
+	data, err := config.Marshal()
+	if err != nil {
+		return err
+	}
+
+	var changes []render.Change
+	var event *notify.ChangeEvent
+	if (len(r.config.ChangeWebhookURL) > 0 || r.config.NotifyOnSuccess || len(r.config.PushgatewayURL) > 0 || len(r.config.AuditS3Bucket) > 0) && r.config.WritesScrapeConfig() {
+		changes, err = r.targetChanges(config, blackBoxTargets)
+		if err != nil {
+			return errors.Wrap(err, "failed to compute the Blackbox target changes")
+		}
+		event = notify.NewChangeEvent(r.config.PrometheusNamespace, r.config.PrometheusSecretName, changes, blackBoxTargets)
+	}
+
+	err = r.injectFailure(PhaseKubernetes)
+	if err != nil {
+		return err
+	}
+
+	switch r.config.OutputKind {
+	case OutputProbe:
+		var probes []*unstructured.Unstructured
+		probes, err = ProbeResources(config, r.config.PrometheusSecretName)
+		if err == nil {
+			err = r.writeResources(probeResource, "Probe", probes, report)
+		}
+	case OutputScrapeConfig:
+		err = r.writeResources(scrapeConfigResource, "ScrapeConfig", ScrapeConfigResources(config, r.config.PrometheusSecretName), report)
+	default:
+		err = r.writeScrapeConfig(data, report)
+	}
+	if err != nil {
+		return err
+	}
+
+	if event != nil {
+		report.TargetsAdded = len(event.Added)
+		report.TargetsRemoved = len(event.Removed)
+	}
+
+	err = r.recordHistory(blackBoxTargets, report)
+	if err != nil {
+		return err
+	}
+
+	if len(r.config.AuditS3Bucket) > 0 {
+		started := time.Now()
+		err = r.WriteAudit(r.config.PrometheusNamespace, r.config.PrometheusSecretName, report.RunID, changes)
+		if err != nil {
+			return errors.Wrap(err, "failed to write the audit record")
+		}
+		report.phaseDone("Write audit record", started, "s3://%s/%s", r.config.AuditS3Bucket, r.config.AuditS3Prefix)
+	}
+
+	err = r.writeFileSD(blackBoxTargets, report)
+	if err != nil {
+		return err
+	}
+
+	err = r.writeS3Output(data, blackBoxTargets, report)
+	if err != nil {
+		return err
+	}
+
+	if event != nil && r.config.NotifyOnSuccess {
+		started := time.Now()
+		log.Infof("Sending change summary with %d added and %d removed targets", len(event.Added), len(event.Removed))
+		err = r.clients.Notifier.SendChanges(event)
+		if err != nil {
+			return errors.Wrap(err, "failed to send the change summary")
+		}
+		report.phaseDone("Send change summary", started, "%d added, %d removed", len(event.Added), len(event.Removed))
+	}
+
+	if event != nil && len(r.config.ChangeWebhookURL) > 0 {
+		started := time.Now()
+		log.Infof("Sending change event with %d added and %d removed targets", len(event.Added), len(event.Removed))
+		err = notify.SendChangeEvent(r.config.ChangeWebhookURL, event)
+		if err != nil {
+			return errors.Wrap(err, "failed to send the change event")
+		}
+		report.phaseDone("Send change event", started, "%d added, %d removed", len(event.Added), len(event.Removed))
+	}
+
+	if len(r.config.ServiceNowURL) > 0 {
+		started := time.Now()
+		log.Info("Exporting Blackbox target inventory to ServiceNow")
+		serviceNow := export.NewServiceNow(r.config.ServiceNowURL, r.config.ServiceNowUsername, r.config.ServiceNowPassword, r.config.ServiceNowTable)
+		err = serviceNow.Export(blackBoxTargets)
+		if err != nil {
+			return errors.Wrap(err, "failed to export the Blackbox target inventory to ServiceNow")
+		}
+		report.phaseDone("Export to ServiceNow", started, "%d targets", len(blackBoxTargets))
+	}
+
+	if len(r.config.InventoryS3Bucket) > 0 {
+		started := time.Now()
+		log.Info("Publishing Blackbox target inventory to S3")
+		publisher := &export.InventoryPublisher{
+			Store:         r.clients.Objects,
+			Bucket:        r.config.InventoryS3Bucket,
+			Prefix:        r.config.InventoryS3Prefix,
+			RetentionDays: r.config.InventoryRetentionDays,
+		}
+		err = publisher.Publish(blackBoxTargets)
+		if err != nil {
+			return errors.Wrap(err, "failed to publish the Blackbox target inventory to S3")
+		}
+		report.phaseDone("Publish inventory", started, "s3://%s/%s", r.config.InventoryS3Bucket, r.config.InventoryS3Prefix)
+	}
+
+	return nil
+}
+
+// GenerateScrapeConfig discovers the Blackbox targets and renders them into the
+// scrape config. The rendered config is loaded with the Prometheus config
+// parser, and rejected if it would break the Prometheus config.
+func (r *Reconciler) GenerateScrapeConfig(report *Report) (render.Config, []discovery.Target, error) {
+	blackBoxTargets, err := r.DiscoverTargets(report)
+	if err != nil {
+		return nil, nil, err
+	}
+	blackBoxTargets, err = r.preflight(blackBoxTargets, report)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(blackBoxTargets) < 1 {
+		return nil, blackBoxTargets, nil
+	}
+
+	err = r.injectFailure(PhaseRender)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	started := time.Now()
+	log.Info("Adding new targets in config")
+	config, err := r.renderOutputs(blackBoxTargets)
+	if err != nil {
+		return nil, nil, err
+	}
+	report.phaseDone("Render config", started, "%d jobs", len(config))
+
+	started = time.Now()
+	data, err := config.Marshal()
+	if err != nil {
+		return nil, nil, err
+	}
+	err = render.CheckScrapeConfig(data)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "generated scrape config is invalid, refusing to update the Blackbox targets")
+	}
+	report.phaseDone("Validate config", started, "%d jobs", len(config))
+
+	return config, blackBoxTargets, nil
+}
+
+// DiscoverTargets lists the records of the hosted zones and returns the
+// Blackbox targets, after applying the filters and maintenance windows.
+func (r *Reconciler) DiscoverTargets(report *Report) ([]discovery.Target, error) {
+	err := r.injectFailure(PhaseAWS)
+	if err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	publicZoneIDs, privateZoneIDs, err := r.HostedZoneIDs()
+	if err != nil {
+		return nil, err
+	}
+	if len(r.config.HostedZoneTagKey) > 0 {
+		report.phaseDone("Discover zones", started, "%d public and %d private zones tagged with %s", len(publicZoneIDs), len(privateZoneIDs), r.config.HostedZoneTag())
+	}
+
+	publicZones, err := r.listZones("public", publicZoneIDs, report)
+	if err != nil {
+		return nil, err
+	}
+	privateZones, err := r.listZones("private", privateZoneIDs, report)
+	if err != nil {
+		return nil, err
+	}
+	publicRecords := discovery.Records(publicZones)
+	privateRecords := discovery.Records(privateZones)
+	report.PublicRecords = len(publicRecords)
+	report.PrivateRecords = len(privateRecords)
+
+	instances, err := r.listServiceInstances(report)
+	if err != nil {
+		return nil, err
+	}
+	listeners, err := r.listLoadBalancerListeners(report)
+	if err != nil {
+		return nil, err
+	}
+	databaseEndpoints, err := r.listDatabaseEndpoints(report)
+	if err != nil {
+		return nil, err
+	}
+	ingressHosts, err := r.listIngressHosts(report)
+	if err != nil {
+		return nil, err
+	}
+	serviceEndpoints, err := r.listServiceEndpoints(report)
+	if err != nil {
+		return nil, err
+	}
+	certificates, err := r.listCertificates(report)
+	if err != nil {
+		return nil, err
+	}
+	installations, err := r.listInstallations(report)
+	if err != nil {
+		return nil, err
+	}
+
+	started = time.Now()
+	log.Info("Getting Blackbox targets")
+	options, err := r.discoveryOptions()
+	if err != nil {
+		return nil, err
+	}
+	blackBoxTargets := discovery.GetTargets(publicZones, privateZones, options)
+	// The provisioner targets come first, so they keep their installation
+	// labels when the same installation is also found in the hosted zones.
+	blackBoxTargets = discovery.MergeTargets(discovery.InstallationTargets(installations, options), blackBoxTargets)
+	blackBoxTargets, inactiveTargets := discovery.DropInactiveInstallations(blackBoxTargets, installations)
+	for _, target := range inactiveTargets {
+		log.Infof("Skipping target %s of a hibernated or deleting installation", target.Target)
+	}
+	blackBoxTargets = discovery.MergeTargets(blackBoxTargets, discovery.CloudMapTargets(instances, options))
+	blackBoxTargets = discovery.MergeTargets(blackBoxTargets, discovery.LoadBalancerTargets(listeners, options))
+	blackBoxTargets = discovery.MergeTargets(blackBoxTargets, discovery.DatabaseTargets(databaseEndpoints, options))
+	blackBoxTargets = discovery.MergeTargets(blackBoxTargets, discovery.IngressTargets(ingressHosts, options))
+	blackBoxTargets = discovery.MergeTargets(blackBoxTargets, discovery.ServiceTargets(serviceEndpoints, options))
+	blackBoxTargets = discovery.MergeTargets(blackBoxTargets, discovery.CertificateTargets(certificates, r.config.CertificateProbeModule, options))
+
+	windows, err := r.maintenanceWindows(publicRecords, privateRecords)
+	if err != nil {
+		return nil, err
+	}
+	blackBoxTargets = discovery.ApplyMaintenance(blackBoxTargets, windows, time.Now(), r.config.MaintenanceAction)
+	blackBoxTargets = discovery.LabelTargets(blackBoxTargets, options)
+	blackBoxTargets = discovery.AssignJobs(blackBoxTargets, r.config.JobRules)
+	blackBoxTargets = discovery.SelectModules(blackBoxTargets, r.config.ModulePatterns)
+	blackBoxTargets, skippedTargets := discovery.ApplyDirectives(blackBoxTargets, discovery.DirectivesFromRecords(append(publicRecords, privateRecords...)))
+	for _, target := range skippedTargets {
+		log.Infof("Skipping target %s with a probe=false directive record", target.Target)
+	}
+	unique := discovery.UniqueTargets(blackBoxTargets)
+	if len(unique) < len(blackBoxTargets) {
+		log.Debugf("Removed %d duplicate targets", len(blackBoxTargets)-len(unique))
+	}
+	blackBoxTargets = unique
+	report.TargetCount = len(blackBoxTargets)
+	report.phaseDone("Filter records", started, "%d targets from %d records", len(blackBoxTargets), len(publicRecords)+len(privateRecords))
+
+	return blackBoxTargets, nil
+}
+
+// writeScrapeConfig writes the scrape config to the Prometheus secret or ConfigMap.
+// Prometheus is reloaded if the scrape config changed.
+func (r *Reconciler) writeScrapeConfig(data []byte, report *Report) error {
+	started := time.Now()
+	var currentData []byte
+	reload := len(r.config.PrometheusReloadURL) > 0 || len(r.config.PrometheusWorkload) > 0
+	if reload {
+		var err error
+		currentData, err = r.CurrentScrapeConfig(r.config.PrometheusNamespace, r.config.PrometheusSecretName)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := r.WriteScrapeConfig(r.config.PrometheusNamespace, r.config.PrometheusSecretName, data)
+	if err != nil {
+		return err
+	}
+	log.Info("Successfully updated Blackbox targets")
+	report.phaseDone("Write "+r.config.OutputKind, started, "%s/%s", r.config.PrometheusNamespace, r.config.PrometheusSecretName)
+
+	if !reload || bytes.Equal(currentData, data) {
+		return nil
+	}
+	started = time.Now()
+	err = r.ReloadPrometheus(data)
+	if err != nil {
+		return err
+	}
+	report.phaseDone("Reload Prometheus", started, "scrape config changed")
+
+	return nil
+}
+
+// WriteScrapeConfig creates or updates the Prometheus secret holding the
+// scrape config, or the ConfigMap with the same keys with the configmap output.
+// The jobs of the output secrets are written to their own secrets, and with
+// SECRET_CHUNKING every secret is spread across chunk secrets as needed.
+func (r *Reconciler) WriteScrapeConfig(namespace, name string, data []byte) error {
+	parts, err := r.splitOutputs(data)
+	if err != nil {
+		return err
+	}
+
+	for i, secretName := range r.config.outputSecretNames(name) {
+		previousChunks := 0
+		if r.config.SecretChunking {
+			annotations, err := r.outputAnnotations(namespace, secretName)
+			if err != nil {
+				return err
+			}
+			previousChunks = chunkCount(annotations)
+		}
+
+		secrets, err := r.newOutputSecrets(secretName, parts[i], i == 0)
+		if err != nil {
+			return err
+		}
+		for _, secret := range secrets {
+			err = r.keepGeneratedAt(namespace, secret)
+			if err != nil {
+				return err
+			}
+			err = r.writeSecret(namespace, secret)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = r.deleteStaleChunks(namespace, secretName, previousChunks, len(secrets))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// keepGeneratedAt keeps the generation time of the existing secret if its
+// scrape config is unchanged, so a run that changes nothing doesn't update
+// the secret.
+func (r *Reconciler) keepGeneratedAt(namespace string, secret *corev1.Secret) error {
+	annotations, err := r.outputAnnotations(namespace, secret.Name)
+	if err != nil {
+		return err
+	}
+	generatedAt, ok := annotations[GeneratedAtAnnotation]
+	if ok && annotations[ContentHashAnnotation] == secret.Annotations[ContentHashAnnotation] {
+		secret.Annotations[GeneratedAtAnnotation] = generatedAt
+	}
+
+	return nil
+}
+
+// writeSecret creates or updates a Prometheus secret, or the ConfigMap with
+// the same keys with the configmap output.
+func (r *Reconciler) writeSecret(namespace string, secret *corev1.Secret) error {
+	if r.config.OutputKind == OutputConfigMap {
+		log.Infof("Creating/updating Blackbox targets Prometheus ConfigMap %s", secret.Name)
+		err := r.retry("update the Prometheus ConfigMap "+secret.Name, func() error {
+			_, err := r.clients.ConfigMaps.CreateOrUpdateConfigMap(namespace, scrapeConfigConfigMap(secret))
+			return err
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to create the Blackbox targets Prometheus ConfigMap %s", secret.Name)
+		}
+		return nil
+	}
+
+	log.Infof("Creating/updating Blackbox targets Prometheus secret %s", secret.Name)
+	err := r.retry("update the Prometheus secret "+secret.Name, func() error {
+		_, err := r.clients.Secrets.CreateOrUpdateSecret(namespace, secret)
+		return err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the Blackbox targets Prometheus secret %s", secret.Name)
+	}
+
+	return nil
+}
+
+// scrapeConfigConfigMap returns a ConfigMap with the name, annotations and keys of the secret.
+func scrapeConfigConfigMap(secret *corev1.Secret) *corev1.ConfigMap {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        secret.Name,
+			Labels:      secret.Labels,
+			Annotations: secret.Annotations,
+		},
+		Data: map[string]string{},
+	}
+	for key, value := range secret.Data {
+		configMap.Data[key] = string(value)
+	}
+
+	return configMap
+}
+
+// writeResources replaces the custom resources of the output with the given objects.
+func (r *Reconciler) writeResources(resource schema.GroupVersionResource, kind string, objects []*unstructured.Unstructured, report *Report) error {
+	if r.clients.Resources == nil {
+		return errors.New("the Kubernetes client does not support custom resources")
+	}
+
+	started := time.Now()
+	log.Infof("Creating/updating %d Blackbox target %s resources", len(objects), kind)
+	err := r.clients.Resources.SyncResources(r.config.PrometheusNamespace, resource, outputLabel, r.config.PrometheusSecretName, objects)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write the Blackbox target %s resources", kind)
+	}
+	log.Info("Successfully updated Blackbox targets")
+	report.phaseDone("Write "+resource.Resource, started, "%d %s resources in %s", len(objects), kind, r.config.PrometheusNamespace)
+
+	return nil
+}
+
+// listZones gets the Route53 records of each of the given hosted zones.
+func (r *Reconciler) listZones(kind string, zoneIDs []string, report *Report) ([]discovery.Zone, error) {
+	zones := make([]discovery.Zone, len(zoneIDs))
+	failures := make([]error, len(zoneIDs))
+	workerCount := r.config.ZoneListWorkers
+	if workerCount < 1 {
+		workerCount = 1
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, workerCount)
+	for i, zoneID := range zoneIDs {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(i int, zoneID string) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			started := time.Now()
+			log.Infof("Getting Route53 records for %s hostedzone %s", kind, zoneID)
+			var records []*route53types.ResourceRecordSet
+			err := r.retry("list the records of hostedzone "+zoneID, func() error {
+				var err error
+				records, err = r.recordLister(zoneID).ListAllRecordSets(zoneID)
+				return err
+			})
+			if err != nil {
+				failures[i] = err
+				return
+			}
+			records = r.config.RecordFilter().Apply(records)
+			mutex.Lock()
+			report.phaseDone(fmt.Sprintf("List %s zone", kind), started, "%d records in %s", len(records), zoneID)
+			mutex.Unlock()
+			zones[i] = discovery.Zone{ID: zoneID, Account: r.zoneAccount(zoneID), Records: records}
+		}(i, zoneID)
+	}
+	wg.Wait()
+
+	// Every failed zone is reported, the error keeps the cause of the first one.
+	var failedZoneIDs []string
+	var firstFailure error
+	for i, failure := range failures {
+		if failure == nil {
+			continue
+		}
+		if firstFailure == nil {
+			firstFailure = failure
+		}
+		failedZoneIDs = append(failedZoneIDs, zoneIDs[i])
+		log.WithError(failure).Errorf("Unable to get the existing %s Route53 records of hostedzone %s", kind, zoneIDs[i])
+	}
+	if len(failedZoneIDs) == 1 {
+		return nil, errors.Wrapf(firstFailure, "Unable to get the existing %s Route53 records of hostedzone %s", kind, failedZoneIDs[0])
+	}
+	if len(failedZoneIDs) > 1 {
+		return nil, errors.Wrapf(firstFailure, "Unable to get the existing %s Route53 records of %d hostedzones (%s), first failure in %s", kind, len(failedZoneIDs), strings.Join(failedZoneIDs, ", "), failedZoneIDs[0])
+	}
+
+	return zones, nil
+}
+
+// CheckMinTargets returns an error if fewer targets than the configured minimum
+// were discovered. This protects the existing secret from being overwritten
+// after an empty hosted zone response or a credential scoping mistake.
+func (r *Reconciler) CheckMinTargets(blackBoxTargets []discovery.Target) error {
+	if len(blackBoxTargets) < r.config.MinTargets {
+		return errors.Errorf("discovered %d targets, fewer than the minimum of %d, refusing to update the Blackbox targets Prometheus secret", len(blackBoxTargets), r.config.MinTargets)
+	}
+
+	return nil
+}
+
+// CurrentScrapeConfig returns the scrape config data of the existing secret,
+// or ConfigMap with the configmap output, if any, followed by the jobs of the
+// output secrets.
+func (r *Reconciler) CurrentScrapeConfig(namespace, secretName string) ([]byte, error) {
+	data, err := r.currentScrapeConfig(namespace, secretName)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.currentOutputs(namespace, data)
+}
+
+// currentScrapeConfig returns the scrape config data of a single existing
+// secret or ConfigMap, if any.
+func (r *Reconciler) currentScrapeConfig(namespace, secretName string) ([]byte, error) {
+	if r.config.OutputKind == OutputConfigMap {
+		configMap, err := r.clients.ConfigMaps.GetConfigMap(namespace, secretName)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get the Blackbox targets Prometheus ConfigMap")
+		}
+		if configMap == nil {
+			return nil, nil
+		}
+		data := map[string][]byte{}
+		for key, value := range configMap.Data {
+			data[key] = []byte(value)
+		}
+		return r.joinScrapeConfig(data)
+	}
+
+	secret, err := r.clients.Secrets.GetSecret(namespace, secretName)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the Blackbox targets Prometheus secret")
+	}
+	if secret == nil {
+		return nil, nil
+	}
+	if chunkCount(secret.Annotations) > 0 {
+		return r.joinChunks(namespace, secret)
+	}
+
+	return r.joinScrapeConfig(secret.Data)
+}
+
+// targetChanges compares the new scrape config with the existing secret and
+// returns the resulting changes.
+func (r *Reconciler) targetChanges(config render.Config, blackBoxTargets []discovery.Target) ([]render.Change, error) {
+	currentData, err := r.CurrentScrapeConfig(r.config.PrometheusNamespace, r.config.PrometheusSecretName)
+	if err != nil {
+		return nil, err
+	}
+
+	return render.Diff(currentData, config, r.TargetReasons(blackBoxTargets))
+}
+
+// TargetReasons explains why each target is part of the scrape config.
+func (r *Reconciler) TargetReasons(blackBoxTargets []discovery.Target) map[string]string {
+	reasons := map[string]string{}
+	for _, target := range blackBoxTargets {
+		if target.Source == discovery.SourceAdditional {
+			reasons[target.Target] = "listed in ADDITIONAL_TARGETS"
+			continue
+		}
+		reasons[target.Target] = fmt.Sprintf("discovered in %s", target.Source)
+	}
+	for _, bindServer := range r.config.BindServers {
+		reasons[bindServer] = "listed in BIND_SERVERS"
+	}
+
+	return reasons
+}
+
+// NewScrapeConfigSecret returns the Prometheus secret holding the given scrape config
+// and the generated Blackbox exporter module definitions, if any.
+func (r *Reconciler) NewScrapeConfigSecret(secretName string, data []byte) (*corev1.Secret, error) {
+	config, err := render.Parse(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the scrape config")
+	}
+	build := version.Get()
+	sum := sha256.Sum256(data)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   secretName,
+			Labels: map[string]string{ManagedByLabel: ManagedBy},
+			Annotations: map[string]string{
+				VersionAnnotation:     build.Version,
+				CommitAnnotation:      build.Commit,
+				TargetCountAnnotation: strconv.Itoa(config.ProbeTargetCount()),
+				GeneratedAtAnnotation: time.Now().UTC().Format(time.RFC3339),
+				SourceZonesAnnotation: strings.Join(append(r.config.PublicZoneIDs(), r.config.PrivateHostedZoneIDs...), ","),
+				ContentHashAnnotation: hex.EncodeToString(sum[:]),
+			},
+		},
+	}
+	if len(r.config.HostedZoneTagKey) > 0 {
+		secret.Annotations[SourceZoneTagAnnotation] = r.config.HostedZoneTag()
+	}
+	secret.Data, err = r.splitScrapeConfig(data)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := render.BlackboxModules{}
+	if len(r.config.GRPCProbeModule) > 0 {
+		modules = modules.Merge(render.GRPCModule(r.config.GRPCProbeModule, r.config.GRPCHealthService))
+	}
+	if len(r.config.WebSocketProbeModule) > 0 {
+		modules = modules.Merge(render.WebSocketModule(r.config.WebSocketProbeModule))
+	}
+	if len(r.config.CertificateProbeModule) > 0 {
+		modules = modules.Merge(render.CertificateModule(r.config.CertificateProbeModule))
+	}
+	if len(r.config.ProbeProfiles) > 0 {
+		modules = modules.Merge(render.ProbeProfileModules(r.config.ProbeProfiles))
+	}
+	if len(modules.Modules) > 0 {
+		data, err := modules.WithIPVersion(r.config.IPVersion).Marshal()
+		if err != nil {
+			return nil, err
+		}
+		secret.Data[BlackboxModulesSecretKey] = data
+	}
+
+	return secret, nil
+}
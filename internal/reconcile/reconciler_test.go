@@ -0,0 +1,82 @@
+package reconcile_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/harness"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/reconcile"
+)
+
+// TestRunConfig runs the reconcile path of the harness with settings that
+// change where the targets are written or which of them are kept.
+func TestRunConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		configure   func(config *reconcile.Config)
+		wantErr     bool
+		wantTargets []string
+	}{
+		{
+			name: "configmap output",
+			configure: func(config *reconcile.Config) {
+				config.OutputKind = reconcile.OutputConfigMap
+			},
+			wantTargets: []string{
+				"customer-a.cloud.example.com/api/v4/system/ping",
+				"customer-b.cloud.example.com/api/v4/system/ping",
+				"provisioner-grpc.internal.example.com.:9090",
+			},
+		},
+		{
+			name: "excluded target",
+			configure: func(config *reconcile.Config) {
+				config.ExcludedTargets = []string{"customer-b.cloud.example.com."}
+			},
+			wantTargets: []string{
+				"customer-a.cloud.example.com/api/v4/system/ping",
+				"provisioner-grpc.internal.example.com.:9090",
+			},
+		},
+		{
+			name: "too few targets",
+			configure: func(config *reconcile.Config) {
+				config.MinTargets = 10
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := harness.DefaultConfig("../../scrapeconfig.yml")
+			test.configure(config)
+			env, err := harness.NewEnvironmentFromFixtures(config, "../harness/testdata/zones.json")
+			if err != nil {
+				t.Fatalf("failed to create environment: %v", err)
+			}
+
+			_, err = env.Reconcile()
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("expected the run to fail")
+				}
+				if _, err = env.ScrapeConfig(); err == nil {
+					t.Error("expected the failed run not to write a scrape config")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to reconcile: %v", err)
+			}
+
+			targets, err := env.JobTargets("blackbox")
+			if err != nil {
+				t.Fatalf("failed to get the blackbox targets: %v", err)
+			}
+			if !reflect.DeepEqual(targets, test.wantTargets) {
+				t.Errorf("blackbox job has targets %v, want %v", targets, test.wantTargets)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package reconcile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ScrapeConfigChecksumAnnotation is set on the pod template of the Prometheus
+// workload to roll it out when the scrape config changes.
+const ScrapeConfigChecksumAnnotation = "blackbox-target-discovery.mattermost.com/scrape-config-checksum"
+
+const reloadTimeout = 30 * time.Second
+
+// KubeWorkloads annotates the pod template of Kubernetes workloads.
+type KubeWorkloads interface {
+	AnnotatePodTemplate(namespace, kind, name string, annotations map[string]string) error
+}
+
+// ParseWorkload parses a workload reference in the kind/name format, where
+// kind is statefulset or deployment.
+func ParseWorkload(value string) (string, string, error) {
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 || len(parts[1]) == 0 {
+		return "", "", errors.Errorf("invalid workload %q, expected kind/name", value)
+	}
+	kind := strings.ToLower(parts[0])
+	if kind != "statefulset" && kind != "deployment" {
+		return "", "", errors.Errorf("invalid workload kind %q, expected statefulset or deployment", parts[0])
+	}
+
+	return kind, parts[1], nil
+}
+
+// ReloadPrometheus makes Prometheus pick up a changed scrape config right away
+// instead of waiting for its config reloader, by posting to the reload URL
+// and by setting the checksum of the scrape config on the pod template of the
+// Prometheus workload, whichever are configured.
+func (r *Reconciler) ReloadPrometheus(data []byte) error {
+	if len(r.config.PrometheusReloadURL) > 0 {
+		log.Infof("Reloading Prometheus at %s", r.config.PrometheusReloadURL)
+		err := postReload(r.config.PrometheusReloadURL)
+		if err != nil {
+			return errors.Wrap(err, "failed to reload Prometheus")
+		}
+	}
+
+	if len(r.config.PrometheusWorkload) > 0 {
+		if r.clients.Workloads == nil {
+			return errors.New("the Kubernetes client does not support annotating workloads")
+		}
+		kind, name, err := ParseWorkload(r.config.PrometheusWorkload)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		log.Infof("Annotating the pod template of %s %s/%s with the scrape config checksum", kind, r.config.PrometheusNamespace, name)
+		err = r.clients.Workloads.AnnotatePodTemplate(r.config.PrometheusNamespace, kind, name, map[string]string{
+			ScrapeConfigChecksumAnnotation: hex.EncodeToString(sum[:]),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to annotate %s %s/%s", kind, r.config.PrometheusNamespace, name)
+		}
+	}
+
+	return nil
+}
+
+// postReload triggers a reload through the Prometheus lifecycle API.
+func postReload(reloadURL string) error {
+	client := &http.Client{Timeout: reloadTimeout}
+	resp, err := client.Post(reloadURL, "text/plain", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("reload request returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
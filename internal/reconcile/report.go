@@ -0,0 +1,88 @@
+package reconcile
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/version"
+)
+
+// Report summarizes the outcome of a single Blackbox target discovery run.
+type Report struct {
+	RunID          string    `json:"run_id"`
+	StartedAt      time.Time `json:"started_at"`
+	FinishedAt     time.Time `json:"finished_at"`
+	Success        bool      `json:"success"`
+	Error          string    `json:"error,omitempty"`
+	PublicRecords  int       `json:"public_records"`
+	PrivateRecords int       `json:"private_records"`
+	TargetCount    int       `json:"target_count"`
+	TargetsAdded   int       `json:"targets_added"`
+	TargetsRemoved int       `json:"targets_removed"`
+	// Quarantined are the unreachable new targets held back by the preflight.
+	Quarantined []string     `json:"quarantined,omitempty"`
+	Build       version.Info `json:"build"`
+	Phases      []Phase      `json:"phases"`
+
+	// OnPhase, if set, is called whenever a phase of the run completes.
+	OnPhase func(phase Phase) `json:"-"`
+
+	// quarantine counts the runs each quarantined target has been held back,
+	// saved in the run state if the preflight ran.
+	quarantine map[string]int
+}
+
+// Phase is a completed step of a run.
+type Phase struct {
+	Name     string        `json:"name"`
+	Detail   string        `json:"detail,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// NewReport starts the report of a new run.
+func NewReport() *Report {
+	return &Report{RunID: newRunID(), StartedAt: time.Now().UTC(), Build: version.Get()}
+}
+
+// newRunID returns a random identifier of a run.
+func newRunID() string {
+	id := make([]byte, 8)
+	_, err := rand.Read(id)
+	if err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+
+	return hex.EncodeToString(id)
+}
+
+// Complete marks the run as finished with the given error, if any.
+func (r *Report) Complete(err error) {
+	r.FinishedAt = time.Now().UTC()
+	r.Success = err == nil
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+// JSON returns the indented JSON representation of the report.
+func (r *Report) JSON() []byte {
+	data, _ := json.MarshalIndent(r, "", "  ")
+	return data
+}
+
+// phaseDone records a phase that started at the given time and has just completed.
+func (r *Report) phaseDone(name string, started time.Time, format string, args ...interface{}) {
+	phase := Phase{
+		Name:     name,
+		Detail:   fmt.Sprintf(format, args...),
+		Duration: time.Since(started),
+	}
+	r.Phases = append(r.Phases, phase)
+	if r.OnPhase != nil {
+		r.OnPhase(phase)
+	}
+}
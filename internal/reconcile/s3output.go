@@ -0,0 +1,57 @@
+package reconcile
+
+import (
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/export"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// S3FormatScrapeConfig uploads the rendered scrape config YAML.
+	S3FormatScrapeConfig = "scrapeconfig"
+	// S3FormatFileSD uploads the targets in the file_sd JSON format.
+	S3FormatFileSD = "filesd"
+	// DefaultS3ScrapeConfigKey is the default key of the uploaded scrape config.
+	DefaultS3ScrapeConfigKey = "blackbox-scrape-config.yaml"
+	// DefaultS3FileSDKey is the default key of the uploaded file_sd targets.
+	DefaultS3FileSDKey = "blackbox-targets.json"
+)
+
+// writeS3Output uploads the scrape config data, or the targets in the
+// file_sd format, to the configured S3 object.
+func (r *Reconciler) writeS3Output(data []byte, targets []discovery.Target, report *Report) error {
+	if len(r.config.OutputS3Bucket) == 0 {
+		return nil
+	}
+
+	started := time.Now()
+	contentType := "application/x-yaml"
+	if r.config.OutputS3Format == S3FormatFileSD {
+		var err error
+		data, err = FileSDData(targets)
+		if err != nil {
+			return err
+		}
+		contentType = "application/json"
+	}
+
+	log.Infof("Uploading the Blackbox targets in the %s format to S3", r.config.OutputS3Format)
+	sink := &export.ConfigSink{
+		Store:    r.clients.Objects,
+		Bucket:   r.config.OutputS3Bucket,
+		Key:      r.config.OutputS3Key,
+		KMSKeyID: r.config.OutputS3KMSKeyID,
+	}
+	err := r.retry("upload the Blackbox targets to S3", func() error {
+		return sink.Upload(data, contentType)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to upload the Blackbox targets to S3")
+	}
+	report.phaseDone("Upload to S3", started, "s3://%s/%s", r.config.OutputS3Bucket, r.config.OutputS3Key)
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package reconcile
+
+import (
+	"strings"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// scrapeConfigResource is the Prometheus Operator ScrapeConfig custom resource.
+var scrapeConfigResource = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1alpha1", Resource: "scrapeconfigs"}
+
+// ScrapeConfigResources converts every job of a rendered scrape config into a
+// Prometheus Operator ScrapeConfig resource, so the operator assembles the
+// final Prometheus config together with its other scrape jobs.
+func ScrapeConfigResources(config render.Config, output string) []*unstructured.Unstructured {
+	var resources []*unstructured.Unstructured
+	for _, job := range config {
+		spec := map[string]interface{}{
+			"jobName":         job.JobName,
+			"honorTimestamps": job.HonorTimestamps,
+		}
+		if len(job.MetricsPath) > 0 {
+			spec["metricsPath"] = job.MetricsPath
+		}
+		if len(job.Scheme) > 0 {
+			spec["scheme"] = strings.ToUpper(job.Scheme)
+		}
+		if len(job.ScrapeInterval) > 0 {
+			spec["scrapeInterval"] = job.ScrapeInterval
+		}
+		if len(job.ScrapeTimeout) > 0 {
+			spec["scrapeTimeout"] = job.ScrapeTimeout
+		}
+		if len(job.Params.Module) > 0 {
+			spec["params"] = map[string]interface{}{"module": stringList(job.Params.Module)}
+		}
+
+		var relabelings []interface{}
+		for _, relabelConfig := range job.RelabelConfigs {
+			relabeling := map[string]interface{}{}
+			if len(relabelConfig.SourceLabels) > 0 {
+				relabeling["sourceLabels"] = stringList(relabelConfig.SourceLabels)
+			}
+			if len(relabelConfig.TargetLabel) > 0 {
+				relabeling["targetLabel"] = relabelConfig.TargetLabel
+			}
+			if len(relabelConfig.Replacement) > 0 {
+				relabeling["replacement"] = relabelConfig.Replacement
+			}
+			relabelings = append(relabelings, relabeling)
+		}
+		if len(relabelings) > 0 {
+			spec["relabelings"] = relabelings
+		}
+
+		var staticConfigs []interface{}
+		for _, staticConfig := range job.StaticConfigs {
+			if len(staticConfig.Targets) == 0 {
+				continue
+			}
+			group := map[string]interface{}{"targets": stringList(staticConfig.Targets)}
+			if len(staticConfig.Labels) > 0 {
+				group["labels"] = stringMap(staticConfig.Labels)
+			}
+			staticConfigs = append(staticConfigs, group)
+		}
+		if len(staticConfigs) == 0 {
+			continue
+		}
+		spec["staticConfigs"] = staticConfigs
+
+		resources = append(resources, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "monitoring.coreos.com/v1alpha1",
+			"kind":       "ScrapeConfig",
+			"metadata": map[string]interface{}{
+				"name":   strings.ToLower(output + "-" + job.JobName),
+				"labels": map[string]interface{}{outputLabel: output},
+			},
+			"spec": spec,
+		}})
+	}
+
+	return resources
+}
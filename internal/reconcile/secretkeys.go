@@ -0,0 +1,182 @@
+package reconcile
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+	"github.com/pkg/errors"
+)
+
+// secretKeyPattern matches the keys Kubernetes accepts in secrets and ConfigMaps.
+var secretKeyPattern = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+// SecretKeyRule places the scrape jobs whose name matches one of the job
+// patterns under their own key of the Prometheus secret, so Prometheus can
+// include them independently.
+type SecretKeyRule struct {
+	Key  string
+	Jobs []string
+}
+
+// ParseSecretKeyRules parses semicolon-separated key=job,job rules. Job names
+// can be globs such as bind-server-*.
+func ParseSecretKeyRules(value string) ([]SecretKeyRule, error) {
+	var rules []SecretKeyRule
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return nil, errors.Errorf("invalid rule %q, expected key=job,job", entry)
+		}
+		rule := SecretKeyRule{Key: parts[0]}
+		for _, job := range strings.Split(parts[1], ",") {
+			job = strings.TrimSpace(job)
+			_, err := path.Match(job, "")
+			if err != nil || len(job) == 0 {
+				return nil, errors.Errorf("invalid job pattern %q in rule for key %s", job, rule.Key)
+			}
+			rule.Jobs = append(rule.Jobs, job)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// ValidateSecretKeys checks that the keys of the scrape config are valid and
+// distinct secret keys.
+func ValidateSecretKeys(mainKey string, rules []SecretKeyRule) error {
+	seen := map[string]bool{BlackboxModulesSecretKey: true}
+	for _, key := range append([]string{mainKey}, secretRuleKeys(rules)...) {
+		if !secretKeyPattern.MatchString(key) {
+			return errors.Errorf("invalid secret key %q", key)
+		}
+		if seen[key] {
+			return errors.Errorf("secret key %s is used more than once", key)
+		}
+		seen[key] = true
+	}
+
+	return nil
+}
+
+func secretRuleKeys(rules []SecretKeyRule) []string {
+	var keys []string
+	for _, rule := range rules {
+		keys = append(keys, rule.Key)
+	}
+
+	return keys
+}
+
+// ScrapeConfigKeys returns the keys of the Prometheus secret holding the
+// scrape config, the main key first.
+func (c *Config) ScrapeConfigKeys() []string {
+	return append([]string{c.scrapeConfigKey()}, secretRuleKeys(c.SecretKeyRules)...)
+}
+
+// scrapeConfigKey returns the key holding the jobs no rule places elsewhere.
+func (c *Config) scrapeConfigKey() string {
+	if len(c.ScrapeConfigKey) == 0 {
+		return ScrapeConfigSecretKey
+	}
+
+	return c.ScrapeConfigKey
+}
+
+// jobKey returns the index in ScrapeConfigKeys of the key holding a job.
+func (c *Config) jobKey(jobName string) int {
+	for i, rule := range c.SecretKeyRules {
+		for _, pattern := range rule.Jobs {
+			if matched, _ := path.Match(pattern, jobName); matched {
+				return i + 1
+			}
+		}
+	}
+
+	return 0
+}
+
+// orderJobsByKey orders the jobs by the key they are written to, keeping the
+// order of the template within a key, so the scrape config read back from
+// the keys is identical to the written one.
+func (r *Reconciler) orderJobsByKey(config render.Config) render.Config {
+	if len(r.config.SecretKeyRules) == 0 {
+		return config
+	}
+	ordered := append(render.Config{}, config...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return r.config.jobKey(ordered[i].JobName) < r.config.jobKey(ordered[j].JobName)
+	})
+
+	return ordered
+}
+
+// splitScrapeConfig returns the data of every scrape config key of the
+// Prometheus secret. Keys without jobs hold an empty list, so they can
+// always be included.
+func (r *Reconciler) splitScrapeConfig(data []byte) (map[string][]byte, error) {
+	keys := r.config.ScrapeConfigKeys()
+	if len(keys) == 1 {
+		return map[string][]byte{keys[0]: data}, nil
+	}
+
+	config, err := render.Parse(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the scrape config")
+	}
+	parts := make([]render.Config, len(keys))
+	for _, job := range config {
+		i := r.config.jobKey(job.JobName)
+		parts[i] = append(parts[i], job)
+	}
+
+	split := map[string][]byte{}
+	for i, key := range keys {
+		if parts[i] == nil {
+			parts[i] = render.Config{}
+		}
+		partData, err := parts[i].Marshal()
+		if err != nil {
+			return nil, err
+		}
+		split[key] = partData
+	}
+
+	return split, nil
+}
+
+// joinScrapeConfig returns the scrape config held by the keys of the
+// Prometheus secret, or nil if the secret has none of them.
+func (r *Reconciler) joinScrapeConfig(data map[string][]byte) ([]byte, error) {
+	keys := r.config.ScrapeConfigKeys()
+	if len(keys) == 1 {
+		return data[keys[0]], nil
+	}
+
+	var joined render.Config
+	found := false
+	for _, key := range keys {
+		partData, ok := data[key]
+		if !ok {
+			continue
+		}
+		found = true
+		part, err := render.Parse(partData)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the scrape config of key %s", key)
+		}
+		joined = append(joined, part...)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return joined.Marshal()
+}
@@ -0,0 +1,151 @@
+package reconcile
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+	"github.com/pkg/errors"
+)
+
+const selfTestHTTPTimeout = 10 * time.Second
+
+// HostedZoneChecker verifies access to a Route53 hosted zone without listing all of its records.
+type HostedZoneChecker interface {
+	CheckHostedZone(hostedZoneID string) error
+}
+
+// Check is the outcome of a single self-test check.
+type Check struct {
+	Name     string        `json:"name"`
+	OK       bool          `json:"ok"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// SelfTest verifies the AWS credentials, Kubernetes access, the scrape config
+// template and the reachability of the configured webhooks. It runs every
+// check and never modifies anything.
+func (r *Reconciler) SelfTest() []Check {
+	var checks []Check
+	run := func(name string, check func() error) {
+		started := time.Now()
+		err := check()
+		result := Check{Name: name, OK: err == nil, Duration: time.Since(started)}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		checks = append(checks, result)
+	}
+
+	publicZoneIDs, privateZoneIDs := r.config.PublicZoneIDs(), r.config.PrivateHostedZoneIDs
+	if len(r.config.HostedZoneTagKey) > 0 {
+		run("Route53 hosted zone discovery", func() error {
+			var err error
+			publicZoneIDs, privateZoneIDs, err = r.HostedZoneIDs()
+			return err
+		})
+	}
+	var zones []struct{ name, id string }
+	for _, zoneID := range publicZoneIDs {
+		name := "Route53 public zone " + zoneID
+		if _, ok := r.clients.ZoneRecords[zoneID]; ok {
+			name = "Public zone " + zoneID
+		}
+		zones = append(zones, struct{ name, id string }{name, zoneID})
+	}
+	for _, zoneID := range privateZoneIDs {
+		zones = append(zones, struct{ name, id string }{"Route53 private zone " + zoneID, zoneID})
+	}
+	for _, zone := range zones {
+		zoneID := zone.id
+		run(zone.name, func() error {
+			lister := r.recordLister(zoneID)
+			checker, ok := lister.(HostedZoneChecker)
+			if !ok {
+				_, err := lister.ListAllRecordSets(zoneID)
+				return err
+			}
+			return checker.CheckHostedZone(zoneID)
+		})
+	}
+
+	run("Kubernetes secret access", func() error {
+		_, err := r.clients.Secrets.GetSecret(r.config.PrometheusNamespace, r.config.PrometheusSecretName)
+		return err
+	})
+
+	run("Kubernetes ConfigMap access", func() error {
+		_, err := r.clients.ConfigMaps.GetConfigMap(r.config.PrometheusNamespace, r.config.StateConfigMapName)
+		return err
+	})
+
+	run("Scrape config template", func() error {
+		template, err := r.config.LoadTemplates()
+		if err != nil {
+			return err
+		}
+		problems := render.ValidateTemplate(template, r.config.TemplateRequirements())
+		if len(problems) > 0 {
+			return problems[0]
+		}
+		return nil
+	})
+
+	for _, endpoint := range []struct{ name, url string }{
+		{"Mattermost alerts webhook", r.config.MattermostAlertsHook},
+		{"Slack webhook", r.config.SlackWebhookURL},
+		{"Notification webhook", r.config.NotifyWebhookURL},
+		{"Change webhook", r.config.ChangeWebhookURL},
+		{"ServiceNow", r.config.ServiceNowURL},
+		{"Jira", r.config.JiraURL},
+	} {
+		if len(endpoint.url) == 0 {
+			continue
+		}
+		endpointURL := endpoint.url
+		run(endpoint.name+" reachability", func() error {
+			return checkReachable(endpointURL)
+		})
+	}
+
+	return checks
+}
+
+// checkReachable verifies that an HTTP endpoint answers. Any HTTP response,
+// including error statuses, counts as reachable so no payload is ever sent.
+func checkReachable(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return errors.Wrap(err, "invalid URL")
+	}
+
+	client := &http.Client{Timeout: selfTestHTTPTimeout}
+	resp, err := client.Head(u.String())
+	if err != nil {
+		return errors.Wrapf(err, "%s is not reachable", u.Host)
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// SelfTestHandler serves the self-test results as JSON. It responds with 503
+// Service Unavailable if any check fails, so it can be used as a health check.
+func (r *Reconciler) SelfTestHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		checks := r.SelfTest()
+		status := http.StatusOK
+		for _, check := range checks {
+			if !check.OK {
+				status = http.StatusServiceUnavailable
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(checks)
+	})
+}
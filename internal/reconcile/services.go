@@ -0,0 +1,41 @@
+package reconcile
+
+import (
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// KubeServices lists the endpoints of the LoadBalancer Services opted in to
+// be probed.
+type KubeServices interface {
+	ListProbedServiceEndpoints() ([]discovery.ServiceEndpoint, error)
+}
+
+// listServiceEndpoints gets the external endpoints of the LoadBalancer
+// Services carrying the probe annotation, if Service discovery is enabled.
+func (r *Reconciler) listServiceEndpoints(report *Report) ([]discovery.ServiceEndpoint, error) {
+	if !r.config.DiscoverServices {
+		return nil, nil
+	}
+	if r.clients.Services == nil {
+		return nil, errors.New("the Kubernetes client does not support Service discovery")
+	}
+
+	started := time.Now()
+	log.Infof("Getting the endpoints of LoadBalancer Services annotated with %s", discovery.ProbeAnnotation)
+	var endpoints []discovery.ServiceEndpoint
+	err := r.retry("list the Services", func() error {
+		var err error
+		endpoints, err = r.clients.Services.ListProbedServiceEndpoints()
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to get the Service endpoints")
+	}
+	report.phaseDone("List Services", started, "%d endpoints of LoadBalancer Services annotated with %s", len(endpoints), discovery.ProbeAnnotation)
+
+	return endpoints, nil
+}
@@ -0,0 +1,167 @@
+package reconcile
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/notify"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	stateConsecutiveFailuresKey = "consecutive_failures"
+	stateLastReportKey          = "last_report"
+	stateOpenTicketKey          = "open_ticket"
+	stateOpenIncidentKey        = "open_incident"
+	stateQuarantineKey          = "quarantine"
+)
+
+// runState is the information kept between Blackbox target discovery runs.
+type runState struct {
+	ConsecutiveFailures int
+	OpenTicket          string
+	OpenIncident        bool
+	// Quarantine counts the runs each unreachable new target has been held back.
+	Quarantine map[string]int
+}
+
+// TrackRunOutcome keeps count of consecutive failed runs, publishes the run
+// status and opens a ticket once the configured failure threshold is reached.
+// Incidents are raised for persistent failures and resolved by the next
+// successful run.
+func (r *Reconciler) TrackRunOutcome(report *Report) error {
+	state, err := getRunState(r.clients.ConfigMaps, r.config.PrometheusNamespace, r.config.StateConfigMapName)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the run state")
+	}
+
+	if report.Success {
+		if len(state.OpenTicket) > 0 {
+			log.Infof("Run succeeded, ticket %s can be closed", state.OpenTicket)
+		}
+		state.ConsecutiveFailures = 0
+		state.OpenTicket = ""
+	} else {
+		state.ConsecutiveFailures++
+		log.Infof("Blackbox target discovery has failed %d consecutive times", state.ConsecutiveFailures)
+	}
+
+	if len(r.config.StatusConfigMapName) > 0 {
+		err = publishStatus(r.clients.ConfigMaps, r.config.PrometheusNamespace, r.config.StatusConfigMapName, report, state.ConsecutiveFailures)
+		if err != nil {
+			return errors.Wrap(err, "failed to publish the run status")
+		}
+	}
+
+	if r.config.TicketFailureThreshold > 0 && state.ConsecutiveFailures >= r.config.TicketFailureThreshold && len(state.OpenTicket) == 0 {
+		log.Infof("Opening %s ticket for persistent discovery failure", r.config.TicketProvider)
+		title := fmt.Sprintf("Blackbox target discovery failed %d consecutive times", state.ConsecutiveFailures)
+		description := fmt.Sprintf("The Blackbox target discovery has failed %d consecutive times. Last error:\n\n%s", state.ConsecutiveFailures, report.Error)
+		ticket, err := newTicketOpener(r.config).OpenTicket(title, description, report.JSON())
+		if len(ticket) > 0 {
+			state.OpenTicket = ticket
+			log.Infof("Opened ticket %s", ticket)
+		}
+		if err != nil {
+			// Keep saving the state, so the failures are still counted and
+			// the ticket is opened by the next failed run.
+			log.WithError(err).Error("Failed to open the failure ticket")
+		}
+	}
+
+	if report.quarantine != nil {
+		state.Quarantine = report.quarantine
+	}
+
+	err = r.pushMetrics(report)
+	if err != nil {
+		log.WithError(err).Error("Failed to push the run metrics")
+	}
+
+	err = r.trackIncident(state, report)
+	if err != nil {
+		// Keep the state, so the incident is raised or resolved by the next run.
+		log.WithError(err).Error("Failed to update the discovery failure incident")
+	}
+
+	err = saveRunState(r.clients.ConfigMaps, r.config.PrometheusNamespace, r.config.StateConfigMapName, state, report)
+	if err != nil {
+		return errors.Wrap(err, "failed to save the run state")
+	}
+
+	return nil
+}
+
+// newTicketOpener returns the ticket opener of the configured ticket provider.
+func newTicketOpener(config *Config) notify.TicketOpener {
+	if config.TicketProvider == "github" {
+		return &notify.GitHub{
+			Token:      config.GitHubToken,
+			Repository: config.GitHubRepository,
+		}
+	}
+
+	return &notify.Jira{
+		URL:        config.JiraURL,
+		Username:   config.JiraUsername,
+		APIToken:   config.JiraAPIToken,
+		ProjectKey: config.JiraProjectKey,
+		IssueType:  config.JiraIssueType,
+	}
+}
+
+// getRunState reads the run state ConfigMap, returning an empty state if it doesn't exist yet.
+func getRunState(configMaps KubeConfigMaps, namespace, name string) (*runState, error) {
+	configMap, err := configMaps.GetConfigMap(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &runState{}
+	if configMap == nil {
+		return state, nil
+	}
+
+	state.ConsecutiveFailures, _ = strconv.Atoi(configMap.Data[stateConsecutiveFailuresKey])
+	state.OpenTicket = configMap.Data[stateOpenTicketKey]
+	state.OpenIncident = configMap.Data[stateOpenIncidentKey] == "true"
+	if len(configMap.Data[stateQuarantineKey]) > 0 {
+		err = json.Unmarshal([]byte(configMap.Data[stateQuarantineKey]), &state.Quarantine)
+		if err != nil {
+			log.WithError(err).Warn("Ignoring the invalid quarantine of the run state")
+		}
+	}
+
+	return state, nil
+}
+
+// saveRunState creates or updates the run state ConfigMap.
+func saveRunState(configMaps KubeConfigMaps, namespace, name string, state *runState, report *Report) error {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Data: map[string]string{
+			stateConsecutiveFailuresKey: strconv.Itoa(state.ConsecutiveFailures),
+			stateLastReportKey:          string(report.JSON()),
+			stateOpenTicketKey:          state.OpenTicket,
+			stateOpenIncidentKey:        strconv.FormatBool(state.OpenIncident),
+		},
+	}
+	if len(state.Quarantine) > 0 {
+		quarantine, err := json.Marshal(state.Quarantine)
+		if err != nil {
+			return err
+		}
+		configMap.Data[stateQuarantineKey] = string(quarantine)
+	}
+
+	_, err := configMaps.CreateOrUpdateConfigMap(namespace, configMap)
+
+	return err
+}
@@ -0,0 +1,53 @@
+package reconcile
+
+import (
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// StatusSucceeded is the status of a successful run.
+	StatusSucceeded = "Succeeded"
+	// StatusFailed is the status of a failed run.
+	StatusFailed = "Failed"
+
+	// statusLabel marks the status ConfigMaps, so the status of every run can
+	// be listed with kubectl get configmaps -l blackbox-target-discovery.mattermost.com/status.
+	statusLabel = "blackbox-target-discovery.mattermost.com/status"
+)
+
+// publishStatus writes the outcome of a run to the status ConfigMap, so
+// dashboards and kubectl can read it without inspecting Job exit codes.
+func publishStatus(configMaps KubeConfigMaps, namespace, name string, report *Report, consecutiveFailures int) error {
+	status := StatusSucceeded
+	if !report.Success {
+		status = StatusFailed
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{statusLabel: status},
+		},
+		Data: map[string]string{
+			"status":               status,
+			"run_id":               report.RunID,
+			"started_at":           report.StartedAt.Format(time.RFC3339),
+			"finished_at":          report.FinishedAt.Format(time.RFC3339),
+			"duration":             report.FinishedAt.Sub(report.StartedAt).Round(time.Millisecond).String(),
+			"public_records":       strconv.Itoa(report.PublicRecords),
+			"private_records":      strconv.Itoa(report.PrivateRecords),
+			"target_count":         strconv.Itoa(report.TargetCount),
+			"consecutive_failures": strconv.Itoa(consecutiveFailures),
+			"error":                report.Error,
+			"version":              report.Build.Version,
+		},
+	}
+
+	_, err := configMaps.CreateOrUpdateConfigMap(namespace, configMap)
+
+	return err
+}
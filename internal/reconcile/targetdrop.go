@@ -0,0 +1,86 @@
+package reconcile
+
+import (
+	"strconv"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// CheckTargetDrop returns an error if the scrape config has a larger share of
+// targets fewer than the previous run than the configured maximum drop. This
+// protects the monitoring coverage from a mis-scoped exclusion or an empty
+// zone response. The check is skipped with FORCE_APPLY, and if the previous
+// target count is unknown.
+func (r *Reconciler) CheckTargetDrop(config render.Config) error {
+	if r.config.MaxTargetDropPercent == 0 {
+		return nil
+	}
+
+	previous, err := r.previousTargetCount()
+	if err != nil {
+		return err
+	}
+	current := config.ProbeTargetCount()
+	if previous == 0 || current >= previous {
+		return nil
+	}
+
+	drop := (previous - current) * 100 / previous
+	if drop <= r.config.MaxTargetDropPercent {
+		return nil
+	}
+	if r.config.ForceApply {
+		log.Warnf("The number of targets dropped by %d%% from %d to %d, applying anyway because FORCE_APPLY is set", drop, previous, current)
+		return nil
+	}
+
+	return errors.Errorf("the number of targets dropped by %d%% from %d to %d, more than the maximum of %d%%, refusing to update the Blackbox targets, set FORCE_APPLY=true to apply anyway", drop, previous, current, r.config.MaxTargetDropPercent)
+}
+
+// previousTargetCount returns the number of targets recorded in the target
+// count annotation of the existing secret, or ConfigMap with the configmap
+// output, summed over the output secrets. It returns 0 if there is no
+// previous count.
+func (r *Reconciler) previousTargetCount() (int, error) {
+	total := 0
+	for _, secretName := range r.config.outputSecretNames(r.config.PrometheusSecretName) {
+		annotations, err := r.outputAnnotations(r.config.PrometheusNamespace, secretName)
+		if err != nil {
+			return 0, err
+		}
+		count, err := strconv.Atoi(annotations[TargetCountAnnotation])
+		if err != nil {
+			return 0, nil
+		}
+		total += count
+	}
+
+	return total, nil
+}
+
+// outputAnnotations returns the annotations of the existing secret, or
+// ConfigMap with the configmap output, with the given name.
+func (r *Reconciler) outputAnnotations(namespace, secretName string) (map[string]string, error) {
+	if r.config.OutputKind == OutputConfigMap {
+		configMap, err := r.clients.ConfigMaps.GetConfigMap(namespace, secretName)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get the Blackbox targets Prometheus ConfigMap")
+		}
+		if configMap == nil {
+			return nil, nil
+		}
+		return configMap.Annotations, nil
+	}
+
+	secret, err := r.clients.Secrets.GetSecret(namespace, secretName)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the Blackbox targets Prometheus secret")
+	}
+	if secret == nil {
+		return nil, nil
+	}
+
+	return secret.Annotations, nil
+}
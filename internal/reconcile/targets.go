@@ -0,0 +1,53 @@
+package reconcile
+
+import (
+	"strings"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+)
+
+const (
+	// TargetsConfigMapAdditionalKey lists additional targets in the targets ConfigMap.
+	TargetsConfigMapAdditionalKey = "additional_targets"
+	// TargetsConfigMapExcludedKey lists excluded record names in the targets ConfigMap.
+	TargetsConfigMapExcludedKey = "excluded_targets"
+)
+
+// discoveryOptions returns the discovery options of the configuration,
+// extended with the additional and excluded targets of the targets ConfigMap.
+func (r *Reconciler) discoveryOptions() (discovery.Options, error) {
+	options := r.config.DiscoveryOptions()
+	if len(r.config.TargetsConfigMapName) == 0 || r.clients.ConfigMaps == nil {
+		return options, nil
+	}
+
+	configMap, err := r.clients.ConfigMaps.GetConfigMap(r.config.PrometheusNamespace, r.config.TargetsConfigMapName)
+	if err != nil {
+		return options, errors.Wrap(err, "failed to get the targets ConfigMap")
+	}
+	if configMap == nil {
+		return options, nil
+	}
+
+	options.AdditionalTargets = append(append([]string{}, options.AdditionalTargets...), splitTargets(configMap.Data[TargetsConfigMapAdditionalKey])...)
+	options.ExcludedTargets = append(append([]string{}, options.ExcludedTargets...), splitTargets(configMap.Data[TargetsConfigMapExcludedKey])...)
+
+	return options, nil
+}
+
+// splitTargets splits a ConfigMap value with one target per line or
+// comma-separated targets, skipping empty entries.
+func splitTargets(value string) []string {
+	var targets []string
+	for _, target := range strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == '\n'
+	}) {
+		target = strings.TrimSpace(target)
+		if len(target) > 0 {
+			targets = append(targets, target)
+		}
+	}
+
+	return targets
+}
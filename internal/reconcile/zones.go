@@ -0,0 +1,85 @@
+package reconcile
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// HostedZoneFinder is implemented by record listers that can discover hosted zones by tag.
+type HostedZoneFinder interface {
+	FindHostedZones(tagKey, tagValue string) ([]string, []string, error)
+}
+
+// ZoneAccountResolver is implemented by record listers that aggregate the
+// hosted zones of several AWS accounts.
+type ZoneAccountResolver interface {
+	// ZoneAccount returns the name of the account of a discovered hosted
+	// zone, or an empty string for zones of the default account.
+	ZoneAccount(hostedZoneID string) string
+}
+
+// HostedZoneIDs returns the public and private hosted zones to discover
+// targets in, including the public zones of other DNS providers. If a hosted zone tag is configured, the zones carrying the tag
+// are added to the configured zones.
+func (r *Reconciler) HostedZoneIDs() ([]string, []string, error) {
+	if len(r.config.HostedZoneTagKey) == 0 {
+		return r.config.PublicZoneIDs(), r.config.PrivateHostedZoneIDs, nil
+	}
+
+	finder, ok := r.clients.Records.(HostedZoneFinder)
+	if !ok {
+		return nil, nil, errors.New("the Route53 client does not support hosted zone discovery")
+	}
+	log.Infof("Discovering hosted zones tagged with %s", r.config.HostedZoneTag())
+	publicZoneIDs, privateZoneIDs, err := finder.FindHostedZones(r.config.HostedZoneTagKey, r.config.HostedZoneTagValue)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to discover the hosted zones")
+	}
+	log.Infof("Discovered %d public and %d private hosted zones", len(publicZoneIDs), len(privateZoneIDs))
+
+	publicZoneIDs = mergeZoneIDs(r.config.PublicZoneIDs(), publicZoneIDs)
+	privateZoneIDs = mergeZoneIDs(r.config.PrivateHostedZoneIDs, privateZoneIDs)
+	if len(publicZoneIDs) == 0 && len(privateZoneIDs) == 0 {
+		return nil, nil, errors.Errorf("no hosted zones tagged with %s were found", r.config.HostedZoneTag())
+	}
+
+	return publicZoneIDs, privateZoneIDs, nil
+}
+
+// mergeZoneIDs returns the configured zones followed by the discovered zones
+// that are not configured already.
+func mergeZoneIDs(configured, discovered []string) []string {
+	zoneIDs := append([]string{}, configured...)
+	seen := map[string]bool{}
+	for _, zoneID := range configured {
+		seen[zoneID] = true
+	}
+	for _, zoneID := range discovered {
+		if !seen[zoneID] {
+			seen[zoneID] = true
+			zoneIDs = append(zoneIDs, zoneID)
+		}
+	}
+
+	return zoneIDs
+}
+
+// zoneAccount returns the name of the AWS account of the hosted zone, if the
+// record lister aggregates several accounts.
+func (r *Reconciler) zoneAccount(zoneID string) string {
+	resolver, ok := r.clients.Records.(ZoneAccountResolver)
+	if !ok {
+		return ""
+	}
+
+	return resolver.ZoneAccount(zoneID)
+}
+
+// recordLister returns the record lister of the zone.
+func (r *Reconciler) recordLister(zoneID string) RecordLister {
+	if lister, ok := r.clients.ZoneRecords[zoneID]; ok {
+		return lister
+	}
+
+	return r.clients.Records
+}
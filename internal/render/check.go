@@ -0,0 +1,33 @@
+package render
+
+import (
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/config"
+	"gopkg.in/yaml.v2"
+
+	// Register the file service discovery, so templates can mix file_sd_configs
+	// jobs reading the file_sd output with the static jobs.
+	_ "github.com/prometheus/prometheus/discovery/file"
+)
+
+// CheckScrapeConfig loads a marshalled scrape config with the Prometheus
+// config parser, so a broken template or record cannot end up in the
+// Prometheus secret and prevent Prometheus from reloading its config.
+func CheckScrapeConfig(data []byte) error {
+	var jobs []yaml.MapSlice
+	err := yaml.Unmarshal(data, &jobs)
+	if err != nil {
+		return errors.Wrap(err, "scrape config is not a valid list of scrape jobs")
+	}
+	prometheusConfig, err := yaml.Marshal(yaml.MapSlice{{Key: "scrape_configs", Value: jobs}})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the Prometheus config")
+	}
+
+	_, err = config.Load(string(prometheusConfig))
+	if err != nil {
+		return errors.Wrap(err, "Prometheus rejects the scrape config")
+	}
+
+	return nil
+}
@@ -0,0 +1,275 @@
+package render
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Config is a Prometheus scrape config made of a list of scrape jobs.
+type Config []Job
+
+// Job is a single Prometheus scrape job. Only the fields the discovery reads
+// or writes are modeled. Every other field, such as basic_auth, tls_config or
+// metric_relabel_configs, is kept in Extra and written back unchanged.
+type Job struct {
+	HonorTimestamps bool   `yaml:"honor_timestamps"`
+	JobName         string `yaml:"job_name"`
+	MetricsPath     string `yaml:"metrics_path"`
+	Params          struct {
+		Module []string               `yaml:"module"`
+		Extra  map[string]interface{} `yaml:",inline"`
+	} `yaml:"params"`
+	RelabelConfigs []struct {
+		SourceLabels []string               `yaml:"source_labels,omitempty"`
+		TargetLabel  string                 `yaml:"target_label,omitempty"`
+		Replacement  string                 `yaml:"replacement,omitempty"`
+		Extra        map[string]interface{} `yaml:",inline"`
+	} `yaml:"relabel_configs"`
+	Scheme         string                 `yaml:"scheme"`
+	ScrapeInterval string                 `yaml:"scrape_interval"`
+	ScrapeTimeout  string                 `yaml:"scrape_timeout"`
+	StaticConfigs  []StaticConfig         `yaml:"static_configs"`
+	Extra          map[string]interface{} `yaml:",inline"`
+}
+
+// StaticConfig is a group of targets sharing the same labels.
+type StaticConfig struct {
+	Targets []string          `yaml:"targets"`
+	Labels  map[string]string `yaml:"labels,omitempty"`
+}
+
+// Load reads and parses a scrape config template.
+func Load(path string) (Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error reading scrape config file")
+	}
+
+	config, err := Parse(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error parsing scrape config file")
+	}
+
+	return config, nil
+}
+
+// Parse parses a scrape config.
+func Parse(data []byte) (Config, error) {
+	var config Config
+	err := yaml.Unmarshal(data, &config)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// Marshal returns the YAML representation of the scrape config.
+func (c Config) Marshal() ([]byte, error) {
+	data, err := yaml.Marshal(&c)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error running marshal for config file")
+	}
+
+	return data, nil
+}
+
+// FindJob returns the index of the scrape job with the given name, or -1 if there is none.
+func (c Config) FindJob(jobName string) int {
+	for i, job := range c {
+		if job.JobName == jobName {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// ProbeTargetCount returns the number of targets of the probe jobs.
+func (c Config) ProbeTargetCount() int {
+	count := 0
+	for _, job := range c {
+		if job.IsProbe() {
+			count += len(job.Targets())
+		}
+	}
+
+	return count
+}
+
+// IsProbe reports whether the scrape job probes its targets through the Blackbox exporter.
+func (j Job) IsProbe() bool {
+	return j.MetricsPath == "/probe"
+}
+
+// Targets returns all targets of a scrape job.
+func (j Job) Targets() []string {
+	var targets []string
+	for _, staticConfig := range j.StaticConfigs {
+		targets = append(targets, staticConfig.Targets...)
+	}
+
+	return targets
+}
+
+// AssignTargets replaces the targets of each scrape job with the targets assigned to it.
+func AssignTargets(config Config, targets []discovery.Target) error {
+	jobs := map[string][]discovery.Target{}
+	for _, target := range targets {
+		jobs[target.Job] = append(jobs[target.Job], target)
+	}
+
+	for jobName, jobTargets := range jobs {
+		i := config.FindJob(jobName)
+		if i < 0 {
+			return errors.Errorf("scrape config template has no job named %s", jobName)
+		}
+
+		var baseLabels map[string]string
+		if len(config[i].StaticConfigs) > 0 {
+			baseLabels = config[i].StaticConfigs[0].Labels
+		}
+		config[i].StaticConfigs = GroupStaticConfigs(baseLabels, jobTargets)
+		if config[i].IsProbe() && mixesModules(config[i]) {
+			addModuleRelabel(&config[i])
+		}
+	}
+
+	return nil
+}
+
+// mixesModules reports whether a static config of a job has a module label
+// other than the module param of the job.
+func mixesModules(job Job) bool {
+	for _, staticConfig := range job.StaticConfigs {
+		module, ok := staticConfig.Labels["module"]
+		if ok && (len(job.Params.Module) == 0 || module != job.Params.Module[0]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addModuleRelabel makes a probe job probe every target with the module of
+// its module label instead of the module param of the job, unless the job
+// already does.
+func addModuleRelabel(job *Job) {
+	for _, relabel := range job.RelabelConfigs {
+		if relabel.TargetLabel == "__param_module" {
+			return
+		}
+	}
+	for _, relabel := range defaultJobs[0].RelabelConfigs {
+		if relabel.TargetLabel == "__param_module" {
+			job.RelabelConfigs = append(job.RelabelConfigs, relabel)
+			return
+		}
+	}
+}
+
+// ApplyScrapeIntervals sets the scrape interval of the jobs with an interval.
+func ApplyScrapeIntervals(config Config, intervals map[string]string) {
+	for jobName, interval := range intervals {
+		if i := config.FindJob(jobName); i >= 0 {
+			SetScrapeInterval(&config[i], interval)
+		}
+	}
+}
+
+// SetScrapeInterval sets the scrape interval of a job. The scrape timeout is
+// shortened to the interval if it is longer, since Prometheus rejects such jobs.
+func SetScrapeInterval(job *Job, interval string) {
+	job.ScrapeInterval = interval
+	timeout, err := time.ParseDuration(job.ScrapeTimeout)
+	if err != nil {
+		return
+	}
+	if limit, err := time.ParseDuration(interval); err == nil && timeout > limit {
+		job.ScrapeTimeout = interval
+	}
+}
+
+// BindJobName returns the name of the scrape job of the BIND server at the
+// given position in BIND_SERVERS, starting at 1.
+func BindJobName(position int) string {
+	return fmt.Sprintf("bind-server-%d", position)
+}
+
+// AssignBindServers sets the target of the job of each BIND server. The jobs
+// are matched by their bind-server-N name, and jobs modeled on the existing
+// BIND server jobs are appended for servers that have none. It fails if the
+// job of a server is a Blackbox probe job.
+func AssignBindServers(config Config, bindServers []string) (Config, error) {
+	for i, bindServer := range bindServers {
+		jobName := BindJobName(i + 1)
+		j := config.FindJob(jobName)
+		if j < 0 {
+			config = append(config, bindJob(config, jobName))
+			j = len(config) - 1
+		}
+		if config[j].IsProbe() {
+			return nil, errors.Errorf("scrape config template job %s is a Blackbox probe job and cannot scrape BIND server %s", jobName, bindServer)
+		}
+		if len(config[j].StaticConfigs) == 0 {
+			config[j].StaticConfigs = bindStaticConfigs(jobName)
+		}
+		config[j].StaticConfigs[0].Targets = []string{bindServer}
+	}
+
+	return config, nil
+}
+
+// GroupStaticConfigs groups targets sharing the same labels into static configs.
+// The base labels are added to every group and the groups are sorted by their labels.
+func GroupStaticConfigs(baseLabels map[string]string, targets []discovery.Target) []StaticConfig {
+	groups := map[string]*StaticConfig{}
+	var keys []string
+	for _, target := range targets {
+		labels := map[string]string{}
+		for name, value := range baseLabels {
+			labels[name] = value
+		}
+		if len(target.Module) > 0 {
+			labels["module"] = target.Module
+		}
+		for name, value := range target.Labels {
+			labels[name] = value
+		}
+
+		key := labelSetKey(labels)
+		group, ok := groups[key]
+		if !ok {
+			group = &StaticConfig{Targets: []string{}, Labels: labels}
+			groups[key] = group
+			keys = append(keys, key)
+		}
+		group.Targets = append(group.Targets, target.Target)
+	}
+	sort.Strings(keys)
+
+	staticConfigs := []StaticConfig{}
+	for _, key := range keys {
+		staticConfigs = append(staticConfigs, *groups[key])
+	}
+
+	return staticConfigs
+}
+
+// labelSetKey returns a stable string representation of a label set.
+func labelSetKey(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for name, value := range labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, value))
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
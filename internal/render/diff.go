@@ -0,0 +1,108 @@
+package render
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ActionAdd adds a target or job.
+	ActionAdd = "add"
+	// ActionRemove removes a target or job.
+	ActionRemove = "remove"
+	// ActionModify changes the settings of a job.
+	ActionModify = "modify"
+)
+
+// Change is a single difference between two scrape configs.
+type Change struct {
+	Action string `json:"action"`
+	Job    string `json:"job"`
+	Target string `json:"target,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// Diff compares the current scrape config data with the new config.
+// The reasons map provides the explanation for each added target.
+func Diff(currentData []byte, config Config, reasons map[string]string) ([]Change, error) {
+	var current Config
+	if len(currentData) > 0 {
+		var err error
+		current, err = Parse(currentData)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse the current scrape config")
+		}
+	}
+
+	currentJobs := map[string]int{}
+	for i, job := range current {
+		currentJobs[job.JobName] = i
+	}
+
+	changes := []Change{}
+	for _, job := range config {
+		i, exists := currentJobs[job.JobName]
+		delete(currentJobs, job.JobName)
+
+		var currentTargets []string
+		if exists {
+			currentTargets = current[i].Targets()
+			if !sameJobSettings(current[i], job) {
+				changes = append(changes, Change{Action: ActionModify, Job: job.JobName, Reason: "job settings differ from the scrape config template"})
+			}
+		}
+
+		added, removed := DiffTargets(currentTargets, job.Targets())
+		for _, target := range added {
+			reason, ok := reasons[target]
+			if !ok {
+				reason = "present in the scrape config template"
+			}
+			changes = append(changes, Change{Action: ActionAdd, Job: job.JobName, Target: target, Reason: reason})
+		}
+		for _, target := range removed {
+			changes = append(changes, Change{Action: ActionRemove, Job: job.JobName, Target: target, Reason: "no longer discovered or excluded"})
+		}
+	}
+
+	for name := range currentJobs {
+		changes = append(changes, Change{Action: ActionRemove, Job: name, Reason: "job no longer in the scrape config template"})
+	}
+
+	return changes, nil
+}
+
+// DiffTargets returns the sorted targets only present in newTargets and only present in currentTargets.
+func DiffTargets(currentTargets, newTargets []string) ([]string, []string) {
+	current := map[string]bool{}
+	for _, target := range currentTargets {
+		current[target] = true
+	}
+
+	var added []string
+	for _, target := range newTargets {
+		if !current[target] {
+			added = append(added, target)
+		}
+		delete(current, target)
+	}
+
+	var removed []string
+	for target := range current {
+		removed = append(removed, target)
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	return added, removed
+}
+
+// sameJobSettings compares two scrape jobs ignoring their targets.
+func sameJobSettings(a, b Job) bool {
+	a.StaticConfigs = nil
+	b.StaticConfigs = nil
+
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,90 @@
+package render
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Differences returns the paths at which two YAML documents differ. Keys that
+// only one of the documents sets to an empty string, list or map are not
+// differences, so a scrape config that was parsed and marshaled again is
+// equivalent to the original unless a setting was lost or changed on the way.
+func Differences(a, b []byte) ([]string, error) {
+	var docA, docB interface{}
+	err := yaml.Unmarshal(a, &docA)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the first document")
+	}
+	err = yaml.Unmarshal(b, &docB)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the second document")
+	}
+
+	return differences("", docA, docB), nil
+}
+
+func differences(path string, a, b interface{}) []string {
+	if isEmpty(a) && isEmpty(b) {
+		return nil
+	}
+
+	switch valueA := a.(type) {
+	case map[interface{}]interface{}:
+		valueB, ok := b.(map[interface{}]interface{})
+		if !ok {
+			return []string{path}
+		}
+		keys := map[string]interface{}{}
+		for key := range valueA {
+			keys[fmt.Sprint(key)] = key
+		}
+		for key := range valueB {
+			keys[fmt.Sprint(key)] = key
+		}
+		var names []string
+		for name := range keys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var diffs []string
+		for _, name := range names {
+			diffs = append(diffs, differences(path+"."+name, valueA[keys[name]], valueB[keys[name]])...)
+		}
+		return diffs
+	case []interface{}:
+		valueB, ok := b.([]interface{})
+		if !ok || len(valueA) != len(valueB) {
+			return []string{path}
+		}
+		var diffs []string
+		for i := range valueA {
+			diffs = append(diffs, differences(fmt.Sprintf("%s[%d]", path, i), valueA[i], valueB[i])...)
+		}
+		return diffs
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		return []string{path}
+	}
+
+	return nil
+}
+
+// isEmpty reports whether a YAML value is missing or empty.
+func isEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	}
+
+	return false
+}
@@ -0,0 +1,52 @@
+package render
+
+import (
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// scrapeConfigFile is a file Prometheus loads with scrape_config_files.
+type scrapeConfigFile struct {
+	ScrapeConfigs Config `yaml:"scrape_configs"`
+}
+
+// includeConfig is the part of the Prometheus config loading scrape config files.
+type includeConfig struct {
+	ScrapeConfigFiles []string `yaml:"scrape_config_files"`
+}
+
+// MarshalFile returns the scrape config as a file Prometheus can load with
+// scrape_config_files.
+func (c Config) MarshalFile() ([]byte, error) {
+	if c == nil {
+		c = Config{}
+	}
+	data, err := yaml.Marshal(&scrapeConfigFile{ScrapeConfigs: c})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error running marshal for scrape config file")
+	}
+
+	return data, nil
+}
+
+// ParseFile parses a scrape config file written by MarshalFile.
+func ParseFile(data []byte) (Config, error) {
+	var file scrapeConfigFile
+	err := yaml.Unmarshal(data, &file)
+	if err != nil {
+		return nil, err
+	}
+
+	return file.ScrapeConfigs, nil
+}
+
+// IncludeConfig returns the part of the Prometheus config that loads the
+// scrape config files at the given paths.
+func IncludeConfig(paths []string) ([]byte, error) {
+	data, err := yaml.Marshal(&includeConfig{ScrapeConfigFiles: paths})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error running marshal for include config")
+	}
+
+	return data, nil
+}
@@ -0,0 +1,84 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+)
+
+var update = flag.Bool("update", false, "rewrite the expected output of every golden case")
+
+const (
+	goldenDir          = "testdata/golden"
+	goldenTemplate     = "../../scrapeconfig.yml"
+	goldenInputFile    = "input.json"
+	goldenExpectedFile = "expected.yml"
+)
+
+// goldenInput is the environment rendered by a golden snapshot case.
+type goldenInput struct {
+	Targets     []discovery.Target `json:"targets"`
+	BindServers []string           `json:"bind_servers"`
+}
+
+// TestGolden renders every snapshot case below testdata/golden with the
+// scrape config template and compares the output with the case's
+// expected.yml. Each case is a directory containing an input.json with the
+// targets and BIND servers of an environment. Run with -update to rewrite
+// the expected files after an intended rendering change.
+func TestGolden(t *testing.T) {
+	template, err := ioutil.ReadFile(goldenTemplate)
+	if err != nil {
+		t.Fatalf("failed to read scrape config template: %v", err)
+	}
+	entries, err := ioutil.ReadDir(goldenDir)
+	if err != nil {
+		t.Fatalf("failed to read golden directory: %v", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		caseDir := filepath.Join(goldenDir, entry.Name())
+		t.Run(entry.Name(), func(t *testing.T) {
+			inputData, err := ioutil.ReadFile(filepath.Join(caseDir, goldenInputFile))
+			if err != nil {
+				t.Fatalf("failed to read input: %v", err)
+			}
+			var input goldenInput
+			err = json.Unmarshal(inputData, &input)
+			if err != nil {
+				t.Fatalf("failed to parse input: %v", err)
+			}
+
+			rendered, err := Render(input.Targets, template, input.BindServers)
+			if err != nil {
+				t.Fatalf("failed to render: %v", err)
+			}
+
+			expectedPath := filepath.Join(caseDir, goldenExpectedFile)
+			if *update {
+				err = ioutil.WriteFile(expectedPath, rendered, 0644)
+				if err != nil {
+					t.Fatalf("failed to update expected output: %v", err)
+				}
+				return
+			}
+
+			expected, err := ioutil.ReadFile(expectedPath)
+			if err != nil && !os.IsNotExist(err) {
+				t.Fatalf("failed to read expected output: %v", err)
+			}
+			if !bytes.Equal(expected, rendered) {
+				t.Errorf("rendered config differs from %s, run with -update if the change is intended:\n%s", expectedPath, UnifiedDiff(expectedPath, "rendered", string(expected), string(rendered), 3))
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package render
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"text/template"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// GoTemplateSuffix is the file name suffix of scrape config templates that
+// are rendered with text/template instead of having their targets replaced.
+const GoTemplateSuffix = ".tmpl"
+
+// TemplateData is the data a Go scrape config template is rendered with.
+type TemplateData struct {
+	// Targets are all discovered targets without duplicates, sorted by target and job.
+	Targets []discovery.Target
+	// Jobs are the static configs of the targets of each job, grouped by their labels.
+	Jobs map[string][]StaticConfig
+	// BindServers are the BIND servers in the configured order.
+	BindServers []string
+}
+
+// templateFuncs are the functions available to Go scrape config templates.
+var templateFuncs = template.FuncMap{
+	"toYaml": toYAML,
+	"indent": indent,
+	"add1": func(i int) int {
+		return i + 1
+	},
+}
+
+// IsGoTemplate reports whether the scrape config template at path is a Go template.
+func IsGoTemplate(path string) bool {
+	return strings.HasSuffix(path, GoTemplateSuffix)
+}
+
+// RenderFile renders the targets and BIND servers into the scrape config
+// template at path. Go templates are executed with the targets as data, other
+// templates have the targets of their jobs replaced as in RenderConfig.
+func RenderFile(path string, targets []discovery.Target, bindServers []string) (Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error reading scrape config file")
+	}
+	if IsGoTemplate(path) {
+		return RenderGoTemplate(targets, data, bindServers)
+	}
+
+	return RenderConfig(targets, data, bindServers)
+}
+
+// LoadTemplate reads the scrape config template at path. Go templates are
+// rendered without targets, so the jobs they define can be validated.
+func LoadTemplate(path string, bindServers []string) (Config, error) {
+	if IsGoTemplate(path) {
+		return RenderFile(path, nil, bindServers)
+	}
+
+	return Load(path)
+}
+
+// RenderGoTemplate executes a Go scrape config template with the targets and
+// BIND servers as TemplateData and parses the result. Every job a target is
+// assigned to must be defined by the rendered config.
+func RenderGoTemplate(targets []discovery.Target, text []byte, bindServers []string) (Config, error) {
+	tmpl, err := template.New("scrapeconfig").Funcs(templateFuncs).Option("missingkey=error").Parse(string(text))
+	if err != nil {
+		return nil, errors.Wrap(err, "Error parsing scrape config template")
+	}
+
+	sorted := discovery.SortTargets(discovery.UniqueTargets(targets))
+	jobTargets := map[string][]discovery.Target{}
+	for _, target := range sorted {
+		jobTargets[target.Job] = append(jobTargets[target.Job], target)
+	}
+	data := TemplateData{
+		Targets:     sorted,
+		Jobs:        map[string][]StaticConfig{},
+		BindServers: bindServers,
+	}
+	for jobName, targets := range jobTargets {
+		data.Jobs[jobName] = GroupStaticConfigs(nil, targets)
+	}
+
+	var out bytes.Buffer
+	err = tmpl.Execute(&out, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error executing scrape config template")
+	}
+
+	config, err := Parse(out.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "Error parsing rendered scrape config template")
+	}
+	for jobName := range jobTargets {
+		if config.FindJob(jobName) < 0 {
+			return nil, errors.Errorf("scrape config template has no job named %s", jobName)
+		}
+	}
+
+	return config, nil
+}
+
+// toYAML marshals a value to YAML without the trailing newline.
+func toYAML(value interface{}) (string, error) {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// indent indents every line of text by the given number of spaces.
+func indent(spaces int, text string) string {
+	padding := strings.Repeat(" ", spaces)
+	return padding + strings.Replace(text, "\n", "\n"+padding, -1)
+}
@@ -0,0 +1,161 @@
+package render
+
+import (
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// BlackboxModules is the modules section of a Blackbox exporter configuration.
+type BlackboxModules struct {
+	Modules map[string]BlackboxModule `yaml:"modules"`
+}
+
+// BlackboxModule is a single Blackbox exporter module.
+type BlackboxModule struct {
+	Prober  string             `yaml:"prober"`
+	Timeout string             `yaml:"timeout,omitempty"`
+	HTTP    *BlackboxHTTPProbe `yaml:"http,omitempty"`
+	GRPC    *BlackboxGRPCProbe `yaml:"grpc,omitempty"`
+}
+
+// BlackboxHTTPProbe is the configuration of the Blackbox exporter HTTP prober.
+type BlackboxHTTPProbe struct {
+	ValidStatusCodes           []int             `yaml:"valid_status_codes,omitempty"`
+	ValidHTTPVersions          []string          `yaml:"valid_http_versions,omitempty"`
+	Method                     string            `yaml:"method,omitempty"`
+	Headers                    map[string]string `yaml:"headers,omitempty"`
+	FailIfBodyNotMatchesRegexp []string          `yaml:"fail_if_body_not_matches_regexp,omitempty"`
+	NoFollowRedirects          bool              `yaml:"no_follow_redirects,omitempty"`
+	FailIfNotSSL               bool              `yaml:"fail_if_not_ssl,omitempty"`
+	PreferredIPProtocol        string            `yaml:"preferred_ip_protocol,omitempty"`
+	IPProtocolFallback         *bool             `yaml:"ip_protocol_fallback,omitempty"`
+}
+
+// BlackboxGRPCProbe is the configuration of the Blackbox exporter gRPC prober.
+type BlackboxGRPCProbe struct {
+	Service             string `yaml:"service,omitempty"`
+	TLS                 bool   `yaml:"tls"`
+	PreferredIPProtocol string `yaml:"preferred_ip_protocol,omitempty"`
+	IPProtocolFallback  *bool  `yaml:"ip_protocol_fallback,omitempty"`
+}
+
+// GRPCModule returns the Blackbox exporter module checking gRPC health,
+// to be merged into the exporter configuration.
+func GRPCModule(name, service string) BlackboxModules {
+	return BlackboxModules{
+		Modules: map[string]BlackboxModule{
+			name: {
+				Prober:  "grpc",
+				Timeout: "5s",
+				GRPC: &BlackboxGRPCProbe{
+					Service:             service,
+					TLS:                 false,
+					PreferredIPProtocol: "ip4",
+				},
+			},
+		},
+	}
+}
+
+// WebSocketModule returns the Blackbox exporter module checking that an
+// endpoint accepts the WebSocket upgrade handshake, to be merged into the
+// exporter configuration.
+func WebSocketModule(name string) BlackboxModules {
+	return BlackboxModules{
+		Modules: map[string]BlackboxModule{
+			name: {
+				Prober:  "http",
+				Timeout: "5s",
+				HTTP: &BlackboxHTTPProbe{
+					ValidStatusCodes: []int{101},
+					Method:           "GET",
+					Headers: map[string]string{
+						"Connection":            "Upgrade",
+						"Upgrade":               "websocket",
+						"Sec-WebSocket-Version": "13",
+						// The sample nonce of RFC 6455, any base64 encoded 16 byte value is valid.
+						"Sec-WebSocket-Key": "dGhlIHNhbXBsZSBub25jZQ==",
+					},
+					NoFollowRedirects:   true,
+					PreferredIPProtocol: "ip4",
+				},
+			},
+		},
+	}
+}
+
+// CertificateModule returns the Blackbox exporter module probing an https
+// endpoint with certificate verification, failing if the connection is not
+// encrypted, to be merged into the exporter configuration.
+func CertificateModule(name string) BlackboxModules {
+	return BlackboxModules{
+		Modules: map[string]BlackboxModule{
+			name: {
+				Prober:  "http",
+				Timeout: "5s",
+				HTTP: &BlackboxHTTPProbe{
+					FailIfNotSSL:        true,
+					PreferredIPProtocol: "ip4",
+				},
+			},
+		},
+	}
+}
+
+// WithIPVersion returns the modules probing over the IP version of
+// discovery.IPVersion4, discovery.IPVersion6 or discovery.IPVersionBoth. With
+// a single version the modules do not fall back to the other one, with both
+// they prefer IPv4 and fall back to IPv6.
+func (m BlackboxModules) WithIPVersion(version string) BlackboxModules {
+	protocol := "ip4"
+	if version == discovery.IPVersion6 {
+		protocol = "ip6"
+	}
+	var fallback *bool
+	if version == discovery.IPVersion4 || version == discovery.IPVersion6 {
+		fallback = new(bool)
+	}
+
+	modules := BlackboxModules{Modules: map[string]BlackboxModule{}}
+	for name, module := range m.Modules {
+		if module.HTTP != nil {
+			probe := *module.HTTP
+			probe.PreferredIPProtocol = protocol
+			probe.IPProtocolFallback = fallback
+			module.HTTP = &probe
+		}
+		if module.GRPC != nil {
+			probe := *module.GRPC
+			probe.PreferredIPProtocol = protocol
+			probe.IPProtocolFallback = fallback
+			module.GRPC = &probe
+		}
+		modules.Modules[name] = module
+	}
+
+	return modules
+}
+
+// Merge returns the modules of both module sets.
+func (m BlackboxModules) Merge(other BlackboxModules) BlackboxModules {
+	merged := BlackboxModules{Modules: map[string]BlackboxModule{}}
+	for name, module := range m.Modules {
+		merged.Modules[name] = module
+	}
+	for name, module := range other.Modules {
+		merged.Modules[name] = module
+	}
+
+	return merged
+}
+
+// Marshal returns the YAML representation of the modules.
+func (m BlackboxModules) Marshal() ([]byte, error) {
+	data, err := yaml.Marshal(&m)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the Blackbox exporter modules")
+	}
+
+	return data, nil
+}
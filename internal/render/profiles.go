@@ -0,0 +1,118 @@
+package render
+
+import (
+	"io/ioutil"
+	"path"
+	"regexp"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// ProbeProfile is a named set of HTTP probe options. A Blackbox module with
+// the name of the profile is generated for it, and the HTTP ping targets
+// whose host matches one of its target patterns are probed with that module.
+type ProbeProfile struct {
+	Name             string            `yaml:"name"`
+	Targets          []string          `yaml:"targets"`
+	Method           string            `yaml:"method"`
+	Headers          map[string]string `yaml:"headers"`
+	ValidStatusCodes []int             `yaml:"valid_status_codes"`
+	BodyRegexp       string            `yaml:"body_regexp"`
+	HTTP2            bool              `yaml:"http2"`
+	Timeout          string            `yaml:"timeout"`
+}
+
+// probeProfileFile is the file the probe profiles are loaded from.
+type probeProfileFile struct {
+	Profiles []ProbeProfile `yaml:"profiles"`
+}
+
+// LoadProbeProfiles reads and validates a probe profile file.
+func LoadProbeProfiles(file string) ([]ProbeProfile, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read probe profiles %s", file)
+	}
+
+	var profiles probeProfileFile
+	err = yaml.UnmarshalStrict(data, &profiles)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse probe profiles %s", file)
+	}
+
+	names := map[string]bool{}
+	for i, profile := range profiles.Profiles {
+		if len(profile.Name) == 0 {
+			return nil, errors.Errorf("probe profile %d has no name", i+1)
+		}
+		if names[profile.Name] {
+			return nil, errors.Errorf("probe profile %s is defined more than once", profile.Name)
+		}
+		names[profile.Name] = true
+		if profile.Name == discovery.DefaultModule {
+			return nil, errors.Errorf("probe profile %s would replace the default module", profile.Name)
+		}
+		if len(profile.Targets) == 0 {
+			return nil, errors.Errorf("probe profile %s has no target patterns", profile.Name)
+		}
+		for _, pattern := range profile.Targets {
+			_, err = path.Match(pattern, "")
+			if err != nil {
+				return nil, errors.Wrapf(err, "probe profile %s has an invalid target pattern %s", profile.Name, pattern)
+			}
+		}
+		if len(profile.BodyRegexp) > 0 {
+			_, err = regexp.Compile(profile.BodyRegexp)
+			if err != nil {
+				return nil, errors.Wrapf(err, "probe profile %s has an invalid body_regexp", profile.Name)
+			}
+		}
+	}
+
+	return profiles.Profiles, nil
+}
+
+// Module returns the Blackbox exporter module of the profile.
+func (p ProbeProfile) Module() BlackboxModule {
+	probe := &BlackboxHTTPProbe{
+		ValidStatusCodes:    p.ValidStatusCodes,
+		Method:              p.Method,
+		Headers:             p.Headers,
+		PreferredIPProtocol: "ip4",
+	}
+	if len(p.BodyRegexp) > 0 {
+		probe.FailIfBodyNotMatchesRegexp = []string{p.BodyRegexp}
+	}
+	if p.HTTP2 {
+		probe.ValidHTTPVersions = []string{"HTTP/2.0"}
+	}
+
+	timeout := p.Timeout
+	if len(timeout) == 0 {
+		timeout = "5s"
+	}
+
+	return BlackboxModule{Prober: "http", Timeout: timeout, HTTP: probe}
+}
+
+// ProbeProfileModules returns the Blackbox exporter modules of the profiles.
+func ProbeProfileModules(profiles []ProbeProfile) BlackboxModules {
+	modules := BlackboxModules{Modules: map[string]BlackboxModule{}}
+	for _, profile := range profiles {
+		modules.Modules[profile.Name] = profile.Module()
+	}
+
+	return modules
+}
+
+// ModuleRules returns the rules assigning the targets of the profiles to their modules.
+func ModuleRules(profiles []ProbeProfile) []discovery.ModuleRule {
+	var rules []discovery.ModuleRule
+	for _, profile := range profiles {
+		rules = append(rules, discovery.ModuleRule{Module: profile.Name, Patterns: profile.Targets})
+	}
+
+	return rules
+}
@@ -0,0 +1,34 @@
+package render
+
+import (
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/pkg/errors"
+)
+
+// Render renders the targets and BIND servers into the scrape config template
+// and returns the resulting YAML. The output only depends on the arguments, so
+// the same targets always produce byte-identical configs regardless of the
+// order they were discovered in.
+func Render(targets []discovery.Target, template []byte, bindServers []string) ([]byte, error) {
+	config, err := RenderConfig(targets, template, bindServers)
+	if err != nil {
+		return nil, err
+	}
+
+	return config.Marshal()
+}
+
+// RenderConfig is like Render but returns the scrape config before it is marshaled.
+func RenderConfig(targets []discovery.Target, template []byte, bindServers []string) (Config, error) {
+	config, err := Parse(template)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error parsing scrape config file")
+	}
+
+	err = AssignTargets(config, discovery.SortTargets(discovery.UniqueTargets(targets)))
+	if err != nil {
+		return nil, err
+	}
+
+	return AssignBindServers(config, bindServers)
+}
@@ -0,0 +1,148 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultJobs are the jobs regenerated when a template has no job to copy from.
+var defaultJobs = mustParse(`
+- honor_timestamps: true
+  job_name: blackbox
+  metrics_path: /probe
+  relabel_configs:
+  - source_labels:
+    - __address__
+    target_label: __param_target
+  - source_labels:
+    - module
+    target_label: __param_module
+  - source_labels:
+    - __param_target
+    target_label: instance
+  - replacement: mattermost-cm-blackbox-prometheus-blackbox-exporter:9115
+    target_label: __address__
+  scheme: http
+  scrape_interval: 1m
+  scrape_timeout: 30s
+- honor_timestamps: true
+  job_name: bind-server
+  metrics_path: /metrics
+  scheme: http
+  scrape_interval: 1m
+  scrape_timeout: 30s
+`)
+
+// RepairTemplate regenerates the sections of a scrape config template that
+// ValidateTemplate reports as broken. Duplicate jobs are removed, missing
+// module params are set, and missing probe and BIND server jobs are added,
+// based on the existing jobs where possible. It returns the repaired template
+// together with a description of every repair. Problems that cannot be
+// repaired are still reported when validating the repaired template.
+func RepairTemplate(config Config, requirements TemplateRequirements) (Config, []string) {
+	var repaired Config
+	var repairs []string
+
+	seen := map[string]bool{}
+	for _, job := range config {
+		if len(job.JobName) > 0 && seen[job.JobName] {
+			repairs = append(repairs, fmt.Sprintf("removed the duplicate %s job", job.JobName))
+			continue
+		}
+		seen[job.JobName] = true
+		repaired = append(repaired, job)
+	}
+
+	for i := range repaired {
+		job := &repaired[i]
+		if !job.IsProbe() || len(job.Params.Module) > 0 {
+			continue
+		}
+		module := requirements.Modules[job.JobName]
+		if len(module) == 0 && len(job.StaticConfigs) > 0 {
+			module = job.StaticConfigs[0].Labels["module"]
+		}
+		if len(module) == 0 {
+			continue
+		}
+		job.Params.Module = []string{module}
+		repairs = append(repairs, fmt.Sprintf("set the module param of the %s job to %s", job.JobName, module))
+	}
+
+	for _, jobName := range requirements.Jobs {
+		if repaired.FindJob(jobName) >= 0 {
+			continue
+		}
+		module := requirements.Modules[jobName]
+		job := probeJob(repaired, jobName, module)
+		if interval, ok := requirements.Intervals[jobName]; ok {
+			SetScrapeInterval(&job, interval)
+		}
+		repaired = append(repaired, job)
+		repairs = append(repairs, fmt.Sprintf("added the %s job probing with the %s module", jobName, module))
+	}
+
+	// BIND servers are assigned to the jobs named after their position.
+	for i := 0; i < requirements.BindServers; i++ {
+		position := i + 1
+		jobName := BindJobName(position)
+		existing := repaired.FindJob(jobName)
+		if existing < 0 {
+			repaired = append(repaired, bindJob(repaired, jobName))
+			repairs = append(repairs, fmt.Sprintf("added the %s job for BIND server %d", jobName, position))
+			continue
+		}
+		if !repaired[existing].IsProbe() && len(repaired[existing].StaticConfigs) == 0 {
+			repaired[existing].StaticConfigs = bindStaticConfigs(jobName)
+			repairs = append(repairs, fmt.Sprintf("added a static config for BIND server %d to the %s job", position, jobName))
+		}
+	}
+
+	return repaired, repairs
+}
+
+// probeJob returns a probe job modeled on the first probe job of the template.
+func probeJob(config Config, jobName, module string) Job {
+	job := defaultJobs[0]
+	for _, existing := range config {
+		if existing.IsProbe() {
+			job = existing
+			break
+		}
+	}
+
+	job.JobName = jobName
+	job.Params.Module = []string{module}
+	job.StaticConfigs = []StaticConfig{{Targets: []string{}, Labels: map[string]string{"module": module}}}
+
+	return job
+}
+
+// bindJob returns a BIND server job modeled on the first BIND server job of the template.
+func bindJob(config Config, jobName string) Job {
+	job := defaultJobs[1]
+	for _, existing := range config {
+		if strings.HasPrefix(existing.JobName, "bind-server-") && !existing.IsProbe() {
+			job = existing
+			break
+		}
+	}
+
+	job.JobName = jobName
+	job.StaticConfigs = bindStaticConfigs(jobName)
+
+	return job
+}
+
+func bindStaticConfigs(alias string) []StaticConfig {
+	return []StaticConfig{{Targets: []string{}, Labels: map[string]string{"alias": alias}}}
+}
+
+func mustParse(data string) Config {
+	config, err := Parse([]byte(data))
+	if err != nil {
+		panic(err)
+	}
+
+	return config
+}
@@ -0,0 +1,138 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffOp is a single line operation of a line diff.
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+	// a and b are the zero-based positions of the operation in the old and new text.
+	a, b int
+}
+
+// UnifiedDiff returns the unified diff between two texts with the given
+// number of context lines, or an empty string if they are equal.
+func UnifiedDiff(fromName, toName, a, b string, context int) string {
+	if a == b {
+		return ""
+	}
+
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "--- %s\n+++ %s\n", fromName, toName)
+	for start := 0; start < len(ops); {
+		// Find the next change and the extent of its hunk.
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		hunkStart := first - context
+		if hunkStart < start {
+			hunkStart = start
+		}
+		if hunkStart < 0 {
+			hunkStart = 0
+		}
+
+		hunkEnd, equal := first, 0
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind == ' ' {
+				equal++
+				if equal > 2*context {
+					break
+				}
+				continue
+			}
+			equal = 0
+			hunkEnd = i
+		}
+		hunkEnd += context
+		if hunkEnd >= len(ops) {
+			hunkEnd = len(ops) - 1
+		}
+
+		var oldLines, newLines int
+		for _, op := range ops[hunkStart : hunkEnd+1] {
+			if op.kind != '+' {
+				oldLines++
+			}
+			if op.kind != '-' {
+				newLines++
+			}
+		}
+		fmt.Fprintf(&diff, "@@ -%s +%s @@\n", hunkRange(ops[hunkStart].a, oldLines), hunkRange(ops[hunkStart].b, newLines))
+		for _, op := range ops[hunkStart : hunkEnd+1] {
+			fmt.Fprintf(&diff, "%c%s\n", op.kind, op.line)
+		}
+
+		start = hunkEnd + 1
+	}
+
+	return diff.String()
+}
+
+// hunkRange formats the line range of a hunk side.
+func hunkRange(start, lines int) string {
+	if lines == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if lines == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, lines)
+}
+
+// diffLines returns the operations turning the lines x into y, based on their
+// longest common subsequence.
+func diffLines(x, y []string) []diffOp {
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			ops = append(ops, diffOp{kind: ' ', line: x[i], a: i, b: j})
+			i++
+			j++
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{kind: '-', line: x[i], a: i, b: j})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', line: y[j], a: i, b: j})
+			j++
+		}
+	}
+
+	return ops
+}
+
+func splitLines(text string) []string {
+	if len(text) == 0 {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,55 @@
+package render
+
+import (
+	"github.com/pkg/errors"
+)
+
+// TemplateRequirements are the scrape jobs a configuration renders targets into.
+type TemplateRequirements struct {
+	// Jobs are the names of the jobs that must exist in the template.
+	Jobs []string
+	// Modules are the Blackbox modules of the required jobs, by job name.
+	Modules map[string]string
+	// Intervals are the scrape intervals of the required jobs that do not
+	// use the interval of the template, by job name.
+	Intervals map[string]string
+	// BindServers is the number of BIND servers assigned to the bind-server-N jobs.
+	BindServers int
+}
+
+// ValidateTemplate returns every problem that would prevent the targets of a
+// configuration from being rendered into the scrape config template.
+func ValidateTemplate(config Config, requirements TemplateRequirements) []error {
+	var problems []error
+
+	seen := map[string]bool{}
+	for i, job := range config {
+		if len(job.JobName) == 0 {
+			problems = append(problems, errors.Errorf("scrape config template job %d has no job_name", i+1))
+			continue
+		}
+		if seen[job.JobName] {
+			problems = append(problems, errors.Errorf("scrape config template defines the %s job more than once", job.JobName))
+		}
+		seen[job.JobName] = true
+		if job.IsProbe() && len(job.Params.Module) == 0 {
+			problems = append(problems, errors.Errorf("scrape config template job %s probes targets but has no module param", job.JobName))
+		}
+	}
+
+	for _, jobName := range requirements.Jobs {
+		if !seen[jobName] {
+			problems = append(problems, errors.Errorf("scrape config template has no job named %s, add it or disable the setting that probes it", jobName))
+		}
+	}
+
+	// Missing BIND server jobs are added when rendering, but a probe job cannot scrape a server.
+	for i := 0; i < requirements.BindServers; i++ {
+		jobName := BindJobName(i + 1)
+		if j := config.FindJob(jobName); j >= 0 && config[j].IsProbe() {
+			problems = append(problems, errors.Errorf("scrape config template job %s is a Blackbox probe job but is assigned BIND server %d", jobName, i+1))
+		}
+	}
+
+	return problems
+}
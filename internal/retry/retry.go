@@ -0,0 +1,45 @@
+package retry
+
+import (
+	"math/rand"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Policy configures how often and how long failed calls are retried.
+type Policy struct {
+	// MaxAttempts is the number of attempts including the first call. A call
+	// is attempted once if MaxAttempts is below 2.
+	MaxAttempts int
+	// InitialDelay is the delay before the first retry. It doubles with
+	// every further retry up to MaxDelay.
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+}
+
+// DefaultPolicy retries a call twice, after about one and two seconds.
+var DefaultPolicy = Policy{MaxAttempts: 3, InitialDelay: time.Second, MaxDelay: 30 * time.Second}
+
+// Do calls fn until it succeeds, returns an error that is not retryable or
+// the attempts are exhausted, and returns its last error. The delays between
+// attempts grow exponentially and are randomized between half and the full
+// delay, so clients throttled at the same time don't retry in lockstep.
+func Do(policy Policy, description string, retryable func(error) bool, fn func() error) error {
+	delay := policy.InitialDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= policy.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		wait := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		log.WithError(err).Warnf("Attempt %d of %d to %s failed, retrying in %s", attempt, policy.MaxAttempts, description, wait.Round(time.Millisecond))
+		time.Sleep(wait)
+
+		delay *= 2
+		if delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package schedule
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Schedule returns the next time a run is due.
+type Schedule interface {
+	// Next returns the first activation time strictly after t.
+	Next(t time.Time) time.Time
+}
+
+// Cron is a standard five field cron schedule evaluated in a time zone.
+type Cron struct {
+	minute   uint64
+	hour     uint64
+	dom      uint64
+	month    uint64
+	dow      uint64
+	location *time.Location
+	// domStar and dowStar record unrestricted day fields, since a day matches
+	// either day field when both are restricted.
+	domStar bool
+	dowStar bool
+}
+
+// field is the range and the names accepted by a cron field.
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// descriptors are the predefined schedules accepted instead of five fields.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a five field cron expression (minute, hour, day of month,
+// month, day of week) or a descriptor such as @daily. The schedule is
+// evaluated in the given location, so 0 22 * * 1-5 runs at 22:00 local time
+// on weekdays, also across daylight saving time changes.
+func ParseCron(expression string, location *time.Location) (*Cron, error) {
+	if location == nil {
+		location = time.UTC
+	}
+	spec := strings.TrimSpace(expression)
+	if descriptor, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = descriptor
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, errors.Errorf("invalid cron expression %q, expected 5 fields but got %d", expression, len(fields))
+	}
+
+	cron := &Cron{
+		location: location,
+		domStar:  fields[2] == "*" || fields[2] == "?",
+		dowStar:  fields[4] == "*" || fields[4] == "?",
+	}
+	var err error
+	for i, f := range []struct {
+		field
+		bits *uint64
+	}{
+		{minuteField, &cron.minute},
+		{hourField, &cron.hour},
+		{domField, &cron.dom},
+		{monthField, &cron.month},
+		{dowField, &cron.dow},
+	} {
+		*f.bits, err = f.parse(fields[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cron expression %q", expression)
+		}
+	}
+	// Sunday can be written as 0 or 7.
+	if cron.dow&(1<<7) != 0 {
+		cron.dow |= 1
+	}
+
+	return cron, nil
+}
+
+// parse parses a comma-separated list of values, ranges and steps into a bit set.
+func (f field) parse(value string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(value, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rangePart = part[:i]
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return 0, errors.Errorf("invalid step in %s field %q", f.name, part)
+			}
+		}
+
+		var start, end int
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			start, end = f.min, f.max
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			start, err = f.value(bounds[0])
+			if err != nil {
+				return 0, err
+			}
+			end, err = f.value(bounds[1])
+			if err != nil {
+				return 0, err
+			}
+		default:
+			var err error
+			start, err = f.value(rangePart)
+			if err != nil {
+				return 0, err
+			}
+			end = start
+			if step > 1 {
+				end = f.max
+			}
+		}
+		if start > end {
+			return 0, errors.Errorf("invalid range in %s field %q", f.name, part)
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+// value parses a single number or name of the field.
+func (f field) value(value string) (int, error) {
+	if v, ok := f.names[strings.ToLower(value)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.Errorf("invalid %s %q", f.name, value)
+	}
+	if v < f.min || v > f.max {
+		return 0, errors.Errorf("%s %d is out of range %d-%d", f.name, v, f.min, f.max)
+	}
+
+	return v, nil
+}
+
+// Next returns the first activation time strictly after t.
+func (c *Cron) Next(t time.Time) time.Time {
+	original := t.Location()
+	t = t.In(c.location).Truncate(time.Minute).Add(time.Minute)
+
+	// Give up on schedules that never match, such as the 30th of February.
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, c.location)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.location)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Add(time.Hour - time.Duration(t.Minute())*time.Minute)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t.In(original)
+	}
+
+	return time.Time{}
+}
+
+// dayMatches reports whether the day of t matches the day of month and day of
+// week fields. If both are restricted, matching either of them is enough.
+func (c *Cron) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
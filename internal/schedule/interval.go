@@ -0,0 +1,15 @@
+package schedule
+
+import (
+	"time"
+)
+
+// Interval is a schedule running at a fixed interval.
+type Interval struct {
+	Every time.Duration
+}
+
+// Next returns the time one interval after t.
+func (i Interval) Next(t time.Time) time.Time {
+	return t.Add(i.Every)
+}
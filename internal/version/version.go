@@ -0,0 +1,48 @@
+// Package version holds the build metadata of the binary. The variables are
+// set at build time with -ldflags "-X", see the build target of the Makefile.
+package version
+
+import (
+	"fmt"
+	"runtime"
+)
+
+var (
+	// Version is the released version of the build.
+	Version = "dev"
+	// Commit is the git commit the binary was built from.
+	Commit = "unknown"
+	// BuildDate is the UTC time the binary was built at.
+	BuildDate = "unknown"
+)
+
+// Info is the build metadata of the running binary.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build metadata of the running binary.
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+}
+
+// String returns a single line description of the build.
+func (i Info) String() string {
+	return fmt.Sprintf("%s (commit %s, built %s, %s)", i.Version, i.Commit, i.BuildDate, i.GoVersion)
+}
+
+// BuildInfoMetric returns the build_info gauge in the Prometheus text exposition format.
+func (i Info) BuildInfoMetric() string {
+	return fmt.Sprintf("# HELP blackbox_discovery_build_info Build metadata of the Blackbox target discovery.\n"+
+		"# TYPE blackbox_discovery_build_info gauge\n"+
+		"blackbox_discovery_build_info{version=%q,commit=%q,build_date=%q,goversion=%q} 1\n",
+		i.Version, i.Commit, i.BuildDate, i.GoVersion)
+}
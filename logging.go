@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// configureLogging sets the format and level of the logs from the LOG_FORMAT
+// and LOG_LEVEL settings.
+func configureLogging(sources *settingSources) error {
+	formatter, err := logFormatter(sources.get("LOG_FORMAT"))
+	if err != nil {
+		return err
+	}
+	level, err := logLevel(sources.get("LOG_LEVEL"))
+	if err != nil {
+		return err
+	}
+
+	log.SetFormatter(formatter)
+	log.SetLevel(level)
+
+	return nil
+}
+
+// logFormatter returns the formatter of a LOG_FORMAT, text by default.
+func logFormatter(format string) (log.Formatter, error) {
+	switch strings.ToLower(format) {
+	case "", "text":
+		return &log.TextFormatter{}, nil
+	case "json":
+		return &log.JSONFormatter{}, nil
+	default:
+		return nil, errors.Errorf("LOG_FORMAT environment variable must be text or json")
+	}
+}
+
+// logLevel returns the level of a LOG_LEVEL, info by default.
+func logLevel(level string) (log.Level, error) {
+	if len(level) == 0 {
+		return log.InfoLevel, nil
+	}
+	parsed, err := log.ParseLevel(level)
+	if err != nil {
+		return log.InfoLevel, errors.Errorf("LOG_LEVEL environment variable must be one of panic, fatal, error, warn, info, debug or trace")
+	}
+
+	return parsed, nil
+}
@@ -2,338 +2,400 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"flag"
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"strings"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/route53"
+	awsclient "github.com/mattermost/cloud-blackbox-target-discovery/internal/aws"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/azure"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/bind"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/cloudflare"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/gcp"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/k8s"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/notify"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/provisioner"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/reconcile"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/version"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
-	"gopkg.in/yaml.v2"
-
-	corev1 "k8s.io/api/core/v1"
-	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
-type scrapeConfig []struct {
-	HonorTimestamps bool   `yaml:"honor_timestamps"`
-	JobName         string `yaml:"job_name"`
-	MetricsPath     string `yaml:"metrics_path"`
-	Params          struct {
-		Module []string `yaml:"module"`
-	} `yaml:"params"`
-	RelabelConfigs []struct {
-		SourceLabels []string `yaml:"source_labels,omitempty"`
-		TargetLabel  string   `yaml:"target_label,omitempty"`
-		Replacement  string   `yaml:"replacement,omitempty"`
-	} `yaml:"relabel_configs"`
-	Scheme         string `yaml:"scheme"`
-	ScrapeInterval string `yaml:"scrape_interval"`
-	ScrapeTimeout  string `yaml:"scrape_timeout"`
-	StaticConfigs  []struct {
-		Targets []string `yaml:"targets"`
-		Labels  struct {
-			Module string `yaml:"module"`
-		} `yaml:"labels"`
-	} `yaml:"static_configs"`
-}
-
-type environmentVariables struct {
-	PublicHostedZoneID   string
-	PrivateHostedZoneID  string
-	PrometheusNamespace  string
-	PrometheusSecretName string
-	MattermostAlertsHook string
-	ExcludedTargets      []string
-	AdditionalTargets    []string
-	DevMode              string
-	BindServers          []string
-}
-
 func main() {
-	envVars, err := validateAndGetEnvVars()
+	ctx := context.Background()
+	sources := newSettingSources()
+	args, err := sources.parse(os.Args[1:])
+	if err == flag.ErrHelp {
+		return
+	}
 	if err != nil {
-		log.WithError(err).Error("Environment variable validation failed")
-		err = sendMattermostErrorNotification(err, "Environment variable validation failed")
-		if err != nil {
-			log.WithError(err).Error("Failed to send Mattermost error notification")
+		os.Exit(2)
+	}
+	if *sources.showVersion {
+		args = []string{"version"}
+	}
+	err = configureLogging(sources)
+	if err != nil {
+		log.WithError(err).Error("Invalid logging configuration")
+		os.Exit(2)
+	}
+
+	if len(args) > 0 {
+		if command, ok := offlineCommands[args[0]]; ok {
+			err = command(sources, args[1:])
+			if err != nil {
+				log.WithError(err).Errorf("Failed to run the %s command", args[0])
+				os.Exit(1)
+			}
+			return
 		}
-		os.Exit(1)
 	}
 
-	err = blackboxTargetDiscovery(envVars)
+	log.Infof("Blackbox target discovery %s", version.Get())
+
+	notifier := newNotifier(sources)
+
+	envVars, err := validateAndGetEnvVars(sources)
 	if err != nil {
-		log.WithError(err).Error("Failed to run Blackbox target discovery")
-		err = sendMattermostErrorNotification(err, "The Blackbox target discovery failed")
+		log.WithError(err).Error("Configuration validation failed")
+		err = notifier.SendError(err, "Configuration validation failed")
 		if err != nil {
 			log.WithError(err).Error("Failed to send Mattermost error notification")
 		}
 		os.Exit(1)
 	}
-}
 
-// validateEnvironmentVariables is used to validate the environment variables needed by Blackbox target discovery.
-func validateAndGetEnvVars() (*environmentVariables, error) {
-	envVars := &environmentVariables{}
-	publiHostedZoneID := os.Getenv("PUBLIC_HOSTED_ZONE_ID")
-	if len(publiHostedZoneID) == 0 {
-		return nil, errors.Errorf("PUBLIC_HOSTED_ZONE_ID environment variable is not set")
+	if len(envVars.ReplayDir) > 0 && (len(args) == 0 || (args[0] != "plan" && args[0] != "snapshot")) {
+		log.Error("Replayed Route53 responses can only be used with the plan and snapshot commands")
+		os.Exit(1)
 	}
-	envVars.PublicHostedZoneID = publiHostedZoneID
 
-	privateHostedZoneID := os.Getenv("PRIVATE_HOSTED_ZONE_ID")
-	if len(privateHostedZoneID) == 0 {
-		return nil, errors.Errorf("PRIVATE_HOSTED_ZONE_ID environment variable is not set")
+	if envVars.DryRun && len(args) > 0 {
+		log.Errorf("The %s command cannot be used with a dry run", args[0])
+		os.Exit(1)
 	}
-	envVars.PrivateHostedZoneID = privateHostedZoneID
-
-	prometheusNamespace := os.Getenv("PROMETHEUS_NAMESPACE")
-	if len(prometheusNamespace) == 0 {
-		return nil, errors.Errorf("PROMETHEUS_NAMESPACE environment variable is not set")
+	if envVars.DryRun && len(envVars.OrchestrationConfig) > 0 {
+		log.Error("Dry runs are not supported with an orchestration file")
+		os.Exit(1)
 	}
-	envVars.PrometheusNamespace = prometheusNamespace
 
-	excludedTargets := os.Getenv("EXCLUDED_TARGETS")
-	if len(excludedTargets) > 0 {
-		envVars.ExcludedTargets = strings.Split(excludedTargets, ",")
+	if !envVars.WritesScrapeConfig() && (envVars.DryRun || (len(args) > 0 && (args[0] == "plan" || args[0] == "apply"))) {
+		log.Errorf("Dry runs and the plan and apply commands require the %s or %s output", reconcile.OutputSecret, reconcile.OutputConfigMap)
+		os.Exit(1)
 	}
-
-	additionalTargets := os.Getenv("ADDITIONAL_TARGETS")
-	if len(additionalTargets) > 0 {
-		envVars.AdditionalTargets = strings.Split(additionalTargets, ",")
+	if envVars.OutputKind != reconcile.OutputSecret && len(args) > 0 && args[0] == "migrate" {
+		log.Errorf("The migrate command requires the %s output", reconcile.OutputSecret)
+		os.Exit(1)
 	}
 
-	prometheusSecretName := os.Getenv("PROMETHEUS_SECRET_NAME")
-	if len(prometheusSecretName) == 0 {
-		return nil, errors.Errorf("PROMETHEUS_SECRET_NAME environment variable is not set.")
+	if envVars.RunSchedule != nil && len(args) > 0 {
+		log.Errorf("The %s command cannot be used with a run schedule", args[0])
+		os.Exit(1)
 	}
-	envVars.PrometheusSecretName = prometheusSecretName
 
-	mattermostAlertsHook := os.Getenv("MATTERMOST_ALERTS_HOOK")
-	if len(mattermostAlertsHook) == 0 {
-		return nil, errors.Errorf("MATTERMOST_ALERTS_HOOK environment variable is not set.")
+	if len(envVars.OrchestrationConfig) > 0 {
+		if len(args) > 0 {
+			log.Errorf("The %s command cannot be used with an orchestration file", args[0])
+			os.Exit(1)
+		}
+		run := func() error {
+			return runOrchestrated(ctx, envVars, notifier)
+		}
+		if envVars.RunSchedule != nil {
+			status := newDaemonStatus()
+			statusServer := startStatusServer(envVars, status, nil)
+			runDaemon(envVars.RunSchedule, nil, func() error {
+				err := run()
+				status.record(nil, err)
+				return err
+			})
+			stopStatusServer(statusServer)
+			return
+		}
+		if run() != nil {
+			os.Exit(1)
+		}
+		return
 	}
-	envVars.MattermostAlertsHook = mattermostAlertsHook
 
-	developerMode := os.Getenv("DEVELOPER_MODE")
-	if len(developerMode) == 0 {
-		envVars.DevMode = "false"
-	} else {
-		envVars.DevMode = developerMode
+	if envVars.DevMode == "true" && !envVars.DryRun && (len(args) == 0 || args[0] == "apply" || args[0] == "migrate") {
+		err = confirmContext(envVars)
+		if err != nil {
+			log.WithError(err).Error("Refusing to write to the Kubernetes context")
+			os.Exit(1)
+		}
 	}
 
-	bindServers := os.Getenv("BIND_SERVERS")
-	if len(bindServers) > 0 {
-		envVars.BindServers = strings.Split(bindServers, ",")
+	clients, err := newClients(ctx, envVars, notifier)
+	if err != nil {
+		log.WithError(err).Error("Failed to create clients")
+		err = notifier.SendError(err, "The Blackbox target discovery failed")
+		if err != nil {
+			log.WithError(err).Error("Failed to send Mattermost error notification")
+		}
+		os.Exit(1)
 	}
+	reconciler := reconcile.New(envVars, clients)
 
-	return envVars, nil
-}
+	if len(envVars.MetricsTextfile) > 0 {
+		err = writeMetricsTextfile(envVars.MetricsTextfile, version.Get().BuildInfoMetric())
+		if err != nil {
+			log.WithError(err).Warn("Failed to write the metrics textfile")
+		}
+	}
 
-// blackboxTargetDiscovery is used to keep Prometheus up to date with Blackbox targets.
-func blackboxTargetDiscovery(envVars *environmentVariables) error {
-	log.Infof("Getting Route53 records for public hostedzone %s", envVars.PublicHostedZoneID)
-	publicRecords, err := listAllRecordSets(envVars.PublicHostedZoneID)
-	if err != nil {
-		return errors.Wrap(err, "Unable to get the existing public Route53 records")
+	if len(args) > 0 {
+		err = runCommand(reconciler, args[0], args[1:])
+		if err != nil {
+			log.WithError(err).Errorf("Failed to run the %s command", args[0])
+			os.Exit(1)
+		}
+		return
 	}
 
-	log.Infof("Getting Route53 records for private hostedzone %s", envVars.PrivateHostedZoneID)
-	privateRecords, err := listAllRecordSets(envVars.PrivateHostedZoneID)
-	if err != nil {
-		return errors.Wrap(err, "Unable to get the existing private Route53 records")
+	status := newDaemonStatus()
+	run := func() error {
+		report, err := runDiscovery(reconciler, notifier)
+		status.record(report, err)
+		return err
+	}
+	if envVars.DryRun {
+		run = func() error {
+			err := dryRun(reconciler)
+			if err != nil {
+				log.WithError(err).Error("Failed to run the Blackbox target discovery dry run")
+			}
+			return err
+		}
 	}
+	if envVars.RunSchedule != nil {
+		stop := make(chan struct{})
+		defer close(stop)
+		statusServer := startStatusServer(envVars, status, reconciler)
+		runDaemon(envVars.RunSchedule, watchTargets(envVars, clients, stop), run)
+		stopStatusServer(statusServer)
+		return
+	}
+	if run() != nil {
+		os.Exit(1)
+	}
+}
 
-	log.Info("Getting Blackbox targets")
-	blackBoxTargets := getBlackBoxTargets(publicRecords, privateRecords, envVars.AdditionalTargets, envVars.ExcludedTargets)
-	if len(blackBoxTargets) < 1 {
-		log.Info("No targets to register, canceling run")
+// watchTargets watches the targets ConfigMap, if one is configured, and
+// returns a channel signaling its changes.
+func watchTargets(envVars *reconcile.Config, clients *reconcile.Clients, stop <-chan struct{}) <-chan struct{} {
+	kubeClient, ok := clients.ConfigMaps.(*k8s.Client)
+	if len(envVars.TargetsConfigMapName) == 0 || !ok {
 		return nil
 	}
 
-	log.Info("Getting k8s client")
-	clientset, err := getClientSet(envVars)
-	if err != nil {
-		return errors.Wrap(err, "Unable to create k8s clientset")
-	}
+	log.Infof("Watching ConfigMap %s/%s for target changes", envVars.PrometheusNamespace, envVars.TargetsConfigMapName)
+	return kubeClient.WatchConfigMap(envVars.PrometheusNamespace, envVars.TargetsConfigMapName, stop)
+}
 
-	log.Info("Reading scrape config yaml file")
-	scrapeConfigFile, err := ioutil.ReadFile("scrapeconfig.yml")
-	if err != nil {
-		return errors.Wrap(err, "Error reading scrape config file")
+// runDiscovery runs a full discovery, tracks its outcome and notifies about
+// failures. It returns the report of the run.
+func runDiscovery(reconciler *reconcile.Reconciler, notifier notify.Notifier) (*reconcile.Report, error) {
+	interactive := isTerminal(os.Stderr)
+	report := reconcile.NewReport()
+	if interactive {
+		report.OnPhase = phasePrinter(os.Stderr)
 	}
-
-	log.Info("Parsing scrape config file")
-	var config scrapeConfig
-	err = yaml.Unmarshal(scrapeConfigFile, &config)
-	if err != nil {
-		return errors.Wrap(err, "Error parsing scrape config file")
+	err := reconciler.Run(report)
+	report.Complete(err)
+	if interactive {
+		printSummary(os.Stderr, report)
 	}
 
-	log.Info("Adding new targets in config")
-	config[0].StaticConfigs[0].Targets = blackBoxTargets
-
-	//Adding Bind server targets
-	for i, bindServer := range envVars.BindServers {
-		config[i+1].StaticConfigs[0].Targets = []string{bindServer}
+	trackErr := reconciler.TrackRunOutcome(report)
+	if trackErr != nil {
+		log.WithError(trackErr).Error("Failed to track the run outcome")
 	}
 
-	data, err := yaml.Marshal(&config)
 	if err != nil {
-		return errors.Wrap(err, "Error running marshal for config file")
+		log.WithError(err).Error("Failed to run Blackbox target discovery")
+		notifyErr := notifier.SendError(err, "The Blackbox target discovery failed")
+		if notifyErr != nil {
+			log.WithError(notifyErr).Error("Failed to send Mattermost error notification")
+		}
 	}
 
-	secret := &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: envVars.PrometheusSecretName,
-		},
-		Data: map[string][]byte{"scrape_config_secret.yaml": data},
-	}
+	return report, err
+}
 
-	log.Info("Creating/updating Blackbox targets Prometheus secret")
-	_, err = createOrUpdateSecret(envVars.PrometheusNamespace, envVars.PrometheusSecretName, secret, clientset)
+// runOrchestrated runs every run of the orchestration file and notifies about failures.
+func runOrchestrated(ctx context.Context, envVars *reconcile.Config, notifier notify.Notifier) error {
+	err := runOrchestration(ctx, envVars, notifier)
 	if err != nil {
-		return errors.Wrap(err, "failed to create the Blackbox targets Prometheus secret")
+		log.WithError(err).Error("Failed to run the orchestrated Blackbox target discovery")
+		notifyErr := notifier.SendError(err, "The Blackbox target discovery failed")
+		if notifyErr != nil {
+			log.WithError(notifyErr).Error("Failed to send Mattermost error notification")
+		}
 	}
-	log.Info("Successfully updated Blackbox targets")
 
-	return nil
+	return err
 }
 
-// getClientSet gets the k8s clientset
-func getClientSet(envVars *environmentVariables) (*kubernetes.Clientset, error) {
-	if envVars.DevMode == "true" {
-		kubeconfig := filepath.Join(
-			os.Getenv("HOME"), ".kube", "config",
-		)
-
-		config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
-		if err != nil {
-			return nil, err
-		}
+// newNotifier creates the Mattermost notifier, which also notifies Slack and
+// the generic webhook if they are configured. An invalid generic webhook
+// template is reported by the configuration validation.
+func newNotifier(sources *settingSources) notify.Notifier {
+	notifiers := notify.Multi{notify.NewMattermost(sources.get("MATTERMOST_ALERTS_HOOK"))}
+	slackWebhookURL := sources.get("SLACK_WEBHOOK_URL")
+	if len(slackWebhookURL) > 0 {
+		notifiers = append(notifiers, notify.NewSlack(slackWebhookURL))
+	}
 
-		clientset, err := kubernetes.NewForConfig(config)
+	notifyWebhookURL := sources.get("NOTIFY_WEBHOOK_URL")
+	if len(notifyWebhookURL) > 0 {
+		webhook, err := newWebhookNotifier(notifyWebhookURL, sources.get("NOTIFY_WEBHOOK_TEMPLATE"))
 		if err != nil {
-			return nil, err
+			log.WithError(err).Error("Generic webhook notifications are disabled")
+		} else {
+			notifiers = append(notifiers, webhook)
 		}
-
-		return clientset, nil
 	}
 
-	config, err := rest.InClusterConfig()
-	if err != nil {
-		return nil, err
+	if len(notifiers) == 1 {
+		return notifiers[0]
 	}
 
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		return nil, err
+	return notifiers
+}
+
+// newWebhookNotifier creates the generic webhook notifier with the payload
+// template read from the given file, or the default payload.
+func newWebhookNotifier(webhookURL, templatePath string) (*notify.Webhook, error) {
+	var payloadTemplate []byte
+	if len(templatePath) > 0 {
+		var err error
+		payloadTemplate, err = ioutil.ReadFile(templatePath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read the webhook payload template %s", templatePath)
+		}
 	}
 
-	return clientset, nil
+	return notify.NewWebhook(webhookURL, string(payloadTemplate))
 }
 
-// listAllRecordSets is used to get the existing Route53 Records
-func listAllRecordSets(hostedZoneID string) ([]*route53.ResourceRecordSet, error) {
+// newClients creates the AWS and Kubernetes clients. The AWS requests are
+// made with contexts derived from ctx.
+func newClients(ctx context.Context, envVars *reconcile.Config, notifier notify.Notifier) (*reconcile.Clients, error) {
+	var awsClient *awsclient.Client
 	var err error
-
-	sess, err := session.NewSession()
-	if err != nil {
-		return nil, err
+	var accounts []awsclient.Account
+	for _, account := range envVars.AWSAccounts {
+		accounts = append(accounts, awsclient.Account{Name: account.Name, RoleARN: account.RoleARN})
 	}
-
-	// Create Route53 service client
-	svc := route53.New(sess)
-
-	req := route53.ListResourceRecordSetsInput{
-		HostedZoneId:    aws.String(hostedZoneID),
-		StartRecordName: aws.String("c"),
-		StartRecordType: aws.String("CNAME"),
+	if len(envVars.ReplayDir) > 0 {
+		log.Infof("Replaying Route53 responses recorded in %s", envVars.ReplayDir)
+		awsClient, err = awsclient.NewReplayClient(envVars.ReplayDir)
+	} else {
+		awsClient, err = awsclient.NewClient(ctx, awsclient.Options{
+			Region:       envVars.AWSRegion,
+			Profile:      envVars.AWSProfile,
+			EndpointURL:  envVars.AWSEndpointURL,
+			Timeout:      envVars.AWSTimeout,
+			RoleARN:      envVars.AssumeRoleARN,
+			ZoneRoleARNs: envVars.ZoneRoleARNs,
+			Accounts:     accounts,
+			ExternalID:   envVars.AssumeRoleExternalID,
+			SessionName:  envVars.AssumeRoleSessionName,
+			RequestRate:  envVars.Route53RequestRate,
+		})
 	}
-
-	var rrsets []*route53.ResourceRecordSet
-
-	for {
-		var resp *route53.ListResourceRecordSetsOutput
-		resp, err = svc.ListResourceRecordSets(&req)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to create AWS client")
+	}
+	if len(envVars.RecordDir) > 0 {
+		log.Infof("Recording Route53 responses to %s", envVars.RecordDir)
+		err = awsClient.RecordRoute53(envVars.RecordDir)
 		if err != nil {
 			return nil, err
 		}
-		rrsets = append(rrsets, resp.ResourceRecordSets...)
-		if *resp.IsTruncated {
-			req.StartRecordName = resp.NextRecordName
-			req.StartRecordType = resp.NextRecordType
-			req.StartRecordIdentifier = resp.NextRecordIdentifier
-		} else {
-			break
-		}
 	}
 
-	return rrsets, nil
-}
+	log.Info("Getting k8s client")
+	kubeClient, err := k8s.NewClient(envVars.DevMode == "true", envVars.KubeContext)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to create k8s clientset")
+	}
+	kubeClient.SetForceConflicts(envVars.ForceConflicts)
 
-// getBlackBoxTargets is used to get all Blackbox target that need to be registered.
-func getBlackBoxTargets(publicRecords, privateRecords []*route53.ResourceRecordSet, additionalTargets, excludedTargets []string) []string {
-	blackBoxTargets := []string{}
-	for _, record := range publicRecords {
-		if record.SetIdentifier != nil {
-			if !isExcludedTarget(excludedTargets, *record.Name) && !strings.HasPrefix(*record.Name, "_") && !strings.Contains(*record.SetIdentifier, "[hibernating]") {
-				record := strings.TrimSuffix(*record.Name, ".")
-				blackBoxTargets = append(blackBoxTargets, fmt.Sprintf("%s/api/v4/system/ping", record))
-			}
+	zoneRecords := map[string]reconcile.RecordLister{}
+	if len(envVars.CloudflareZoneIDs) > 0 {
+		cloudflareClient := &cloudflare.Client{APIToken: envVars.CloudflareAPIToken}
+		for _, zoneID := range envVars.CloudflareZoneIDs {
+			zoneRecords[zoneID] = cloudflareClient
 		}
-
 	}
-
-	for _, record := range privateRecords {
-		if !isExcludedTarget(excludedTargets, *record.Name) && !strings.HasPrefix(*record.Name, "_") {
-			if strings.Contains(*record.Name, "-grpc.") {
-				blackBoxTargets = append(blackBoxTargets, fmt.Sprintf("%s:9090", *record.Name))
-			}
+	if len(envVars.GCPDNSZones) > 0 {
+		gcpClient, err := gcp.NewClient(envVars.GCPProject, envVars.GCPCredentialsFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "Unable to create Cloud DNS client")
+		}
+		for _, managedZone := range envVars.GCPDNSZones {
+			zoneRecords[managedZone] = gcpClient
 		}
 	}
-
-	for _, target := range additionalTargets {
-		log.Infof("Adding additional target %s", target)
-		blackBoxTargets = append(blackBoxTargets, target)
+	if len(envVars.AzureDNSZones) > 0 {
+		azureClient := azure.NewClient(envVars.AzureSubscriptionID, envVars.AzureResourceGroup, envVars.AzureClientID)
+		for _, zoneName := range envVars.AzureDNSZones {
+			zoneRecords[zoneName] = azureClient
+		}
 	}
-	log.Info("Returning Blackbox targets")
 
-	return blackBoxTargets
-}
+	var installations reconcile.InstallationLister
+	if len(envVars.ProvisionerURL) > 0 {
+		installations = &provisioner.Client{ServerURL: envVars.ProvisionerURL}
+	}
 
-// isExcludedTarget checks if a Route53 record is in the excluded targets
-func isExcludedTarget(excludedTargets []string, record string) bool {
-	if len(excludedTargets) > 0 {
-		for _, target := range excludedTargets {
-			if target == record {
-				return true
-			}
+	if len(envVars.BindZones) > 0 {
+		bindClient := bind.NewClient(envVars.BindServers)
+		for _, zone := range envVars.BindZones {
+			zoneRecords[zone] = bindClient
 		}
 	}
 
-	return false
+	return &reconcile.Clients{
+		Records:       awsClient,
+		ZoneRecords:   zoneRecords,
+		Objects:       awsClient,
+		Secrets:       kubeClient,
+		ConfigMaps:    kubeClient,
+		Resources:     kubeClient,
+		Workloads:     kubeClient,
+		Ingresses:     kubeClient,
+		Services:      kubeClient,
+		Certificates:  kubeClient,
+		Installations: installations,
+		Notifier:      notifier,
+	}, nil
 }
 
-// createOrUpdateSecret creates or update a secret
-func createOrUpdateSecret(prometheusNamespace, secretName string, secret *corev1.Secret, clientset *kubernetes.Clientset) (metav1.Object, error) {
-	ctx := context.TODO()
-	_, err := clientset.CoreV1().Secrets(prometheusNamespace).Get(ctx, secretName, metav1.GetOptions{})
-	if err != nil && !k8sErrors.IsNotFound(err) {
-		return nil, err
+// writeMetricsTextfile atomically writes metrics in the Prometheus text format
+// for the node exporter textfile collector.
+func writeMetricsTextfile(path, metrics string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".metrics")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary metrics file")
 	}
+	defer os.Remove(tmp.Name())
 
-	if err != nil && k8sErrors.IsNotFound(err) {
-		return clientset.CoreV1().Secrets(prometheusNamespace).Create(ctx, secret, metav1.CreateOptions{})
+	_, err = tmp.WriteString(metrics)
+	if err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "failed to write metrics")
+	}
+	err = tmp.Close()
+	if err != nil {
+		return errors.Wrap(err, "failed to write metrics")
+	}
+	err = os.Chmod(tmp.Name(), 0644)
+	if err != nil {
+		return errors.Wrap(err, "failed to set metrics file permissions")
 	}
 
-	return clientset.CoreV1().Secrets(prometheusNamespace).Update(ctx, secret, metav1.UpdateOptions{})
+	return errors.Wrapf(os.Rename(tmp.Name(), path), "failed to move metrics to %s", path)
 }
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/reconcile"
+)
+
+// migrateCommand converts the managed Prometheus secret to the current format
+// and optionally moves it to a new namespace or name.
+func migrateCommand(reconciler *reconcile.Reconciler, args []string) error {
+	envVars := reconciler.Config()
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	toNamespace := flags.String("to-namespace", envVars.PrometheusNamespace, "namespace to migrate the secret to")
+	toSecret := flags.String("to-secret", envVars.PrometheusSecretName, "name to migrate the secret to")
+	deleteSource := flags.Bool("delete-source", false, "delete the source secret after migrating it to a new location")
+	dryRun := flags.Bool("dry-run", false, "convert and validate the secret without writing anything")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	result, err := reconciler.Migrate(reconcile.Migration{
+		FromNamespace: envVars.PrometheusNamespace,
+		FromSecret:    envVars.PrometheusSecretName,
+		ToNamespace:   *toNamespace,
+		ToSecret:      *toSecret,
+		DeleteSource:  *deleteSource,
+		DryRun:        *dryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	from := fmt.Sprintf("%s/%s", envVars.PrometheusNamespace, envVars.PrometheusSecretName)
+	to := fmt.Sprintf("%s/%s", *toNamespace, *toSecret)
+	switch {
+	case result.Unchanged:
+		fmt.Fprintf(os.Stdout, "Secret %s is already in the current format with %d targets.\n", to, result.Targets)
+	case *dryRun:
+		fmt.Fprintf(os.Stdout, "Secret %s can be migrated to %s with %d equivalent targets.\n", from, to, result.Targets)
+	default:
+		fmt.Fprintf(os.Stdout, "Migrated secret %s to %s with %d targets.\n", from, to, result.Targets)
+	}
+	if to != from && !*dryRun {
+		fmt.Fprintf(os.Stdout, "Set PROMETHEUS_NAMESPACE=%s and PROMETHEUS_SECRET_NAME=%s before the next run.\n", *toNamespace, *toSecret)
+	}
+
+	return nil
+}
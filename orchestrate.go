@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/notify"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/orchestrate"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/reconcile"
+)
+
+// runOrchestration executes every run of the orchestration file and prints
+// an aggregated report. It returns an error if any run failed.
+func runOrchestration(ctx context.Context, envVars *reconcile.Config, notifier notify.Notifier) error {
+	spec, err := orchestrate.Load(envVars.OrchestrationConfig)
+	if err != nil {
+		return err
+	}
+
+	if envVars.DevMode == "true" {
+		for _, run := range spec.Runs {
+			err = confirmContext(spec.RunConfig(envVars, run))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	result := orchestrate.Run(spec, envVars, func(config *reconcile.Config) (*reconcile.Clients, error) {
+		return newClients(ctx, config, notifier)
+	})
+
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RUN\tRESULT\tTARGETS\tDURATION\tERROR")
+	for _, run := range result.Runs {
+		status := "success"
+		if !run.Report.Success {
+			status = "failed"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", run.Name, status, run.Report.TargetCount, run.Report.FinishedAt.Sub(run.Report.StartedAt).Round(time.Millisecond), run.Report.Error)
+	}
+	w.Flush()
+
+	return result.Err()
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/reconcile"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/render"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// changePlan is a reviewed set of changes to the Prometheus scrape config secret.
+type changePlan struct {
+	CreatedAt      time.Time       `json:"created_at"`
+	Namespace      string          `json:"namespace"`
+	SecretName     string          `json:"secret_name"`
+	BaseChecksum   string          `json:"base_checksum"`
+	Changes        []render.Change `json:"changes"`
+	Config         string          `json:"config"`
+	ConfigChecksum string          `json:"config_checksum"`
+}
+
+// planCommand discovers the Blackbox targets and writes the changes they
+// would make to the Prometheus secret as a plan, without applying them.
+func planCommand(reconciler *reconcile.Reconciler, args []string) error {
+	envVars := reconciler.Config()
+	flags := flag.NewFlagSet("plan", flag.ContinueOnError)
+	out := flags.String("out", "plan.json", "file to write the plan to")
+	showDiff := flags.Bool("diff", false, "also print the unified diff of the scrape config YAML")
+	noColor := flags.Bool("no-color", false, "disable colorized output")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	report := reconcile.NewReport()
+	if isTerminal(os.Stderr) {
+		report.OnPhase = phasePrinter(os.Stderr)
+	}
+	plan, currentData, err := newPlan(reconciler, envVars, report)
+	if err != nil {
+		return err
+	}
+
+	planData, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal plan")
+	}
+
+	err = ioutil.WriteFile(*out, planData, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write plan to %s", *out)
+	}
+
+	color := useColor(os.Stdout, *noColor)
+	printPlan(plan, color)
+	if *showDiff {
+		printPlanDiff(plan, currentData, color)
+	}
+	log.Infof("Plan with %d changes written to %s", len(plan.Changes), *out)
+
+	return nil
+}
+
+// dryRun discovers the Blackbox targets and prints the changes and the
+// unified diff a run would make to the Prometheus secret, without writing it.
+func dryRun(reconciler *reconcile.Reconciler) error {
+	report := reconcile.NewReport()
+	if isTerminal(os.Stderr) {
+		report.OnPhase = phasePrinter(os.Stderr)
+	}
+	plan, currentData, err := newPlan(reconciler, reconciler.Config(), report)
+	if err != nil {
+		return err
+	}
+
+	color := useColor(os.Stdout, false)
+	printPlan(plan, color)
+	printPlanDiff(plan, currentData, color)
+	log.Infof("Dry run found %d changes, the secret was not modified", len(plan.Changes))
+
+	return nil
+}
+
+// newPlan discovers the Blackbox targets and compares the resulting scrape
+// config with the current secret. It returns the plan and the current data.
+func newPlan(reconciler *reconcile.Reconciler, envVars *reconcile.Config, report *reconcile.Report) (changePlan, []byte, error) {
+	config, blackBoxTargets, err := reconciler.GenerateScrapeConfig(report)
+	if err != nil {
+		return changePlan{}, nil, err
+	}
+	err = reconciler.CheckMinTargets(blackBoxTargets)
+	if err != nil {
+		return changePlan{}, nil, err
+	}
+	err = reconciler.CheckTargetDrop(config)
+	if err != nil {
+		return changePlan{}, nil, err
+	}
+	if len(blackBoxTargets) < 1 {
+		return changePlan{}, nil, errors.New("no targets discovered, refusing to create a plan")
+	}
+
+	data, err := config.Marshal()
+	if err != nil {
+		return changePlan{}, nil, err
+	}
+
+	currentData, err := reconciler.CurrentScrapeConfig(envVars.PrometheusNamespace, envVars.PrometheusSecretName)
+	if err != nil {
+		return changePlan{}, nil, err
+	}
+
+	changes, err := render.Diff(currentData, config, reconciler.TargetReasons(blackBoxTargets))
+	if err != nil {
+		return changePlan{}, nil, errors.Wrap(err, "failed to compare scrape configs")
+	}
+
+	return changePlan{
+		CreatedAt:      time.Now().UTC(),
+		Namespace:      envVars.PrometheusNamespace,
+		SecretName:     envVars.PrometheusSecretName,
+		BaseChecksum:   checksum(currentData),
+		Changes:        changes,
+		Config:         string(data),
+		ConfigChecksum: checksum(data),
+	}, currentData, nil
+}
+
+// printPlanDiff prints the unified diff between the current and the planned scrape config.
+func printPlanDiff(plan changePlan, currentData []byte, color bool) {
+	fmt.Fprint(os.Stdout, colorizeDiff(color, render.UnifiedDiff(
+		fmt.Sprintf("%s/%s (current)", plan.Namespace, plan.SecretName),
+		fmt.Sprintf("%s/%s (planned)", plan.Namespace, plan.SecretName),
+		string(currentData), plan.Config, 3)))
+}
+
+// applyCommand applies exactly the changes of a previously created plan,
+// refusing to do so if the secret has changed since the plan was created.
+func applyCommand(reconciler *reconcile.Reconciler, args []string) error {
+	flags := flag.NewFlagSet("apply", flag.ContinueOnError)
+	planFile := flags.String("plan", "", "plan file created by the plan command")
+	noColor := flags.Bool("no-color", false, "disable colorized output")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if len(*planFile) == 0 {
+		return errors.New("the --plan flag is required")
+	}
+
+	planData, err := ioutil.ReadFile(*planFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read plan %s", *planFile)
+	}
+
+	var plan changePlan
+	err = json.Unmarshal(planData, &plan)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse plan %s", *planFile)
+	}
+
+	if checksum([]byte(plan.Config)) != plan.ConfigChecksum {
+		return errors.New("plan config does not match its checksum, the plan has been modified")
+	}
+
+	currentData, err := reconciler.CurrentScrapeConfig(plan.Namespace, plan.SecretName)
+	if err != nil {
+		return err
+	}
+	if checksum(currentData) != plan.BaseChecksum {
+		return errors.Errorf("secret %s/%s has changed since the plan was created, create a new plan", plan.Namespace, plan.SecretName)
+	}
+
+	printPlan(plan, useColor(os.Stdout, *noColor))
+
+	log.Info("Applying plan to the Blackbox targets Prometheus scrape config")
+	err = reconciler.WriteScrapeConfig(plan.Namespace, plan.SecretName, []byte(plan.Config))
+	if err != nil {
+		return errors.Wrap(err, "failed to apply the plan")
+	}
+	if plan.BaseChecksum != plan.ConfigChecksum {
+		err = reconciler.ReloadPrometheus([]byte(plan.Config))
+		if err != nil {
+			return err
+		}
+	}
+	err = reconciler.WriteAudit(plan.Namespace, plan.SecretName, reconcile.NewReport().RunID, plan.Changes)
+	if err != nil {
+		return errors.Wrap(err, "failed to write the audit record")
+	}
+	log.Infof("Successfully applied %d changes", len(plan.Changes))
+
+	return nil
+}
+
+func printPlan(plan changePlan, color bool) {
+	if len(plan.Changes) == 0 {
+		fmt.Fprintln(os.Stdout, "No changes. The Prometheus secret is up to date.")
+		return
+	}
+
+	fmt.Fprintf(os.Stdout, "Plan for secret %s/%s:\n", plan.Namespace, plan.SecretName)
+	symbols := map[string]string{render.ActionAdd: "+", render.ActionRemove: "-", render.ActionModify: "~"}
+	colors := map[string]string{render.ActionAdd: colorGreen, render.ActionRemove: colorRed, render.ActionModify: colorYellow}
+	for _, change := range plan.Changes {
+		line := fmt.Sprintf("%s %s %s", symbols[change.Action], change.Job, change.Target)
+		fmt.Fprintf(os.Stdout, "  %s (%s)\n", colorize(color, colors[change.Action], line), change.Reason)
+	}
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/reconcile"
+)
+
+// isTerminal reports whether the file is an interactive terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// phasePrinter returns a callback printing each completed phase of a run.
+func phasePrinter(out io.Writer) func(phase reconcile.Phase) {
+	return func(phase reconcile.Phase) {
+		fmt.Fprintf(out, "✓ %-22s %-40s %s\n", phase.Name, phase.Detail, phase.Duration.Round(time.Millisecond))
+	}
+}
+
+// printSummary prints a summary table of a completed run.
+func printSummary(out io.Writer, report *reconcile.Report) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nPHASE\tDETAIL\tDURATION")
+	for _, phase := range report.Phases {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", phase.Name, phase.Detail, phase.Duration.Round(time.Millisecond))
+	}
+	fmt.Fprintln(w, "\t\t")
+	fmt.Fprintf(w, "Public records\t%d\t\n", report.PublicRecords)
+	fmt.Fprintf(w, "Private records\t%d\t\n", report.PrivateRecords)
+	fmt.Fprintf(w, "Targets\t%d\t\n", report.TargetCount)
+	fmt.Fprintf(w, "Total\t\t%s\n", report.FinishedAt.Sub(report.StartedAt).Round(time.Millisecond))
+	if report.Success {
+		fmt.Fprintln(w, "Result\tsuccess\t")
+	} else {
+		fmt.Fprintf(w, "Result\tfailed: %s\t\n", report.Error)
+	}
+	w.Flush()
+}
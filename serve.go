@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/httpsd"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/reconcile"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/schedule"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// serveCommand serves the discovered targets at /targets in the Prometheus
+// HTTP service discovery format instead of writing the scrape config secret.
+// The targets are discovered again at every refresh and whenever the targets
+// ConfigMap changes. A failed discovery keeps the previous targets.
+func serveCommand(reconciler *reconcile.Reconciler, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	listen := flags.String("listen", ":8080", "address to serve the targets on")
+	refresh := flags.Duration("refresh", 5*time.Minute, "interval between discoveries")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *refresh < time.Minute {
+		return errors.New("the --refresh interval must be at least 1m")
+	}
+
+	server := httpsd.NewServer()
+	mux := http.NewServeMux()
+	mux.Handle("/targets", server)
+	httpServer := &http.Server{Handler: mux}
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen on %s", *listen)
+	}
+	go func() {
+		log.Infof("Serving Blackbox targets at http://%s/targets", listener.Addr())
+		err := httpServer.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			log.WithError(err).Fatal("Failed to serve the Blackbox targets")
+		}
+	}()
+
+	run := func() error {
+		targets, err := reconciler.DiscoverTargets(reconcile.NewReport())
+		if err == nil {
+			err = reconciler.CheckMinTargets(targets)
+		}
+		if err == nil && len(targets) < 1 {
+			err = errors.New("no targets discovered")
+		}
+		if err != nil {
+			log.WithError(err).Error("Failed to discover the Blackbox targets, serving the previous targets")
+			return err
+		}
+
+		server.Update(targets)
+		log.Infof("Serving %d Blackbox targets", len(targets))
+		return nil
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	runDaemon(schedule.Interval{Every: *refresh}, watchTargets(reconciler.Config(), reconciler.Clients(), stop), run)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return httpServer.Shutdown(ctx)
+}
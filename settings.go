@@ -0,0 +1,426 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// setting is a configuration value that can be set with a command line flag
+// or an environment variable.
+type setting struct {
+	env   string
+	usage string
+}
+
+// settings lists every configuration value of the Blackbox target discovery.
+// The flag name of a setting is derived from its environment variable, so
+// PUBLIC_HOSTED_ZONE_ID can also be set with --public-hosted-zone-id.
+var settings = []setting{
+	{"PUBLIC_HOSTED_ZONE_ID", "comma-separated Route53 public hosted zones used to discover installation ping targets (required)"},
+	{"PRIVATE_HOSTED_ZONE_ID", "comma-separated Route53 private hosted zones used to discover gRPC targets (required)"},
+	{"HOSTED_ZONE_TAG", "discover the hosted zones carrying this tag, given as key=value or key"},
+	{"CLOUDFLARE_ZONE_IDS", "comma-separated Cloudflare zones whose CNAME records are discovered like public hosted zones"},
+	{"CLOUDFLARE_API_TOKEN", "Cloudflare API token with the Zone.DNS read permission"},
+	{"GCP_DNS_ZONES", "comma-separated Cloud DNS managed zones whose CNAME records are discovered like public hosted zones"},
+	{"GCP_PROJECT", "Google Cloud project of the Cloud DNS managed zones"},
+	{"GCP_CREDENTIALS_FILE", "service account key file used for Cloud DNS (default workload identity)"},
+	{"AZURE_DNS_ZONES", "comma-separated Azure DNS zones whose CNAME records are discovered like public hosted zones"},
+	{"AZURE_SUBSCRIPTION_ID", "Azure subscription of the DNS zones"},
+	{"AZURE_RESOURCE_GROUP", "Azure resource group of the DNS zones"},
+	{"AZURE_CLIENT_ID", "client ID of the user-assigned managed identity used for Azure DNS (default system-assigned identity)"},
+	{"PROMETHEUS_NAMESPACE", "namespace of the Prometheus scrape config secret (required)"},
+	{"PROMETHEUS_SECRET_NAME", "name of the Prometheus scrape config secret (required)"},
+	{"SECRET_KEY", "key of the Prometheus secret holding the scrape config (default scrape_config_secret.yaml)"},
+	{"SECRET_KEY_RULES", "semicolon-separated key=job,job rules writing matching scrape jobs to their own keys of the secret"},
+	{"OUTPUT_SECRETS", "semicolon-separated secret_name=template pairs writing the jobs of each template to its own secret"},
+	{"SECRET_SIZE_WARN_PERCENT", "share of the 1MiB secret size limit above which a warning is sent (default 80)"},
+	{"SECRET_CHUNKING", "spread the scrape config across chunk secrets loaded with scrape_config_files"},
+	{"SECRET_MOUNT_PATH", "directory Prometheus mounts the chunk secrets in (default /etc/prometheus/secrets)"},
+	{"PROMETHEUS_RELOAD_URL", "Prometheus reload endpoint posted to when the scrape config changes"},
+	{"PROMETHEUS_WORKLOAD", "statefulset/name or deployment/name of Prometheus, annotated with the scrape config checksum when it changes"},
+	{"MATTERMOST_ALERTS_HOOK", "Mattermost webhook used for error notifications (required)"},
+	{"NOTIFY_ON_SUCCESS", "also notify about the added and removed targets of successful runs"},
+	{"SLACK_WEBHOOK_URL", "Slack webhook that receives the same notifications as Mattermost"},
+	{"NOTIFY_WEBHOOK_URL", "endpoint the notifications are also posted to as a templated JSON payload"},
+	{"NOTIFY_WEBHOOK_TEMPLATE", "Go template file rendering the JSON payload of NOTIFY_WEBHOOK_URL"},
+	{"EXCLUDED_TARGETS", "comma-separated record names, globs or re: regular expressions to skip"},
+	{"INCLUDED_TARGET_PATTERNS", "comma-separated record names, globs or re: regular expressions, only matching records become targets"},
+	{"TARGET_URL_TEMPLATE", "Go template of the targets of public records (default {{ .Name }}/api/v4/system/ping)"},
+	{"TARGET_URL_RULES", "semicolon-separated pattern=template rules overriding TARGET_URL_TEMPLATE for matching records"},
+	{"PRIVATE_RECORD_RULES", "semicolon-separated pattern=port or pattern=template rules selecting the private records to probe (default *-grpc.*=9090)"},
+	{"JOB_RULES", "semicolon-separated pattern=job_name,module[,interval] rules moving matching targets into separate scrape jobs"},
+	{"MODULE_RULES", "semicolon-separated pattern=module rules selecting the Blackbox module of matching targets"},
+	{"DISCOVER_SRV_RECORDS", "probe the host:port targets of the SRV records of the hosted zones"},
+	{"RECORD_TYPES", "comma-separated DNS record types of the hosted zones to consider, all types if unset"},
+	{"RECORD_NAME_PREFIXES", "comma-separated prefixes of the names of the records to consider, all names if unset"},
+	{"IP_VERSION", "ipv4, ipv6 or both, the IP version private records are probed over (default both)"},
+	{"TARGET_LABELS", "comma-separated labels added to every target: zone, record_type, environment, target_class, record_ttl, routing_policy"},
+	{"ENVIRONMENT", "value of the environment target label"},
+	{"ADDITIONAL_TARGETS", "comma-separated targets to always probe"},
+	{"BIND_SERVERS", "comma-separated BIND server metric endpoints"},
+	{"BIND_ZONES", "comma-separated zones transferred from the BIND servers with AXFR and discovered like public hosted zones"},
+	{"DEVELOPER_MODE", "use the local kubeconfig instead of the in-cluster config"},
+	{"SCRAPE_CONFIG_TEMPLATE", "scrape config template, rendered as a Go template if it ends in .tmpl (default scrapeconfig.yml)"},
+	{"SERVICENOW_URL", "ServiceNow instance URL the target inventory is synced to"},
+	{"SERVICENOW_USERNAME", "ServiceNow API user"},
+	{"SERVICENOW_PASSWORD", "ServiceNow API password"},
+	{"SERVICENOW_TABLE", "CMDB table to sync into (default cmdb_ci_endpoint)"},
+	{"STATE_CONFIGMAP_NAME", "ConfigMap used to keep state between runs (default blackbox-target-discovery-state)"},
+	{"TARGETS_CONFIGMAP_NAME", "ConfigMap with additional and excluded targets, watched in daemon mode"},
+	{"STATUS_CONFIGMAP_NAME", "ConfigMap the outcome of each run is published to (default blackbox-target-discovery-status)"},
+	{"HISTORY_CONFIGMAP_NAME", "ConfigMap the target snapshot and the history of target changes are kept in"},
+	{"OUTPUT_KIND", "where the targets are written, secret, configmap, probe or scrapeconfig (default secret)"},
+	{"FILE_SD_PATH", "file the targets are also written to in the Prometheus file_sd JSON format"},
+	{"FILE_SD_CONFIGMAP_NAME", "ConfigMap the targets are also written to in the Prometheus file_sd JSON format"},
+	{"FILE_SD_CONFIGMAP_KEY", "key of the file_sd ConfigMap (default blackbox-targets.json)"},
+	{"TICKET_FAILURE_THRESHOLD", "open a ticket after this many consecutive failed runs"},
+	{"TICKET_PROVIDER", "ticket provider, jira or github"},
+	{"JIRA_URL", "Jira instance URL"},
+	{"JIRA_USERNAME", "Jira user"},
+	{"JIRA_API_TOKEN", "Jira API token"},
+	{"JIRA_PROJECT_KEY", "Jira project issues are opened in"},
+	{"JIRA_ISSUE_TYPE", "Jira issue type (default Bug)"},
+	{"GITHUB_TOKEN", "GitHub token used to open issues"},
+	{"GITHUB_REPOSITORY", "GitHub owner/repo issues are opened in"},
+	{"PAGERDUTY_ROUTING_KEY", "PagerDuty Events API v2 routing key used to raise an incident for failed runs"},
+	{"OPSGENIE_API_KEY", "Opsgenie API key used to create an alert for failed runs"},
+	{"OPSGENIE_API_URL", "Opsgenie API endpoint (default https://api.opsgenie.com)"},
+	{"OPSGENIE_PRIORITY", "priority of the Opsgenie alert, P1 to P5 (default P3)"},
+	{"INCIDENT_FAILURE_THRESHOLD", "raise an incident after this many consecutive failed runs (default 1)"},
+	{"CHANGE_WEBHOOK_URL", "endpoint receiving an event whenever the target set changes"},
+	{"OUTPUT_S3_BUCKET", "bucket the rendered scrape config or file_sd targets are uploaded to after every run"},
+	{"OUTPUT_S3_KEY", "key of the uploaded object (default blackbox-scrape-config.yaml, or blackbox-targets.json with filesd)"},
+	{"OUTPUT_S3_FORMAT", "format of the uploaded object, scrapeconfig or filesd (default scrapeconfig)"},
+	{"OUTPUT_S3_KMS_KEY_ID", "KMS key ID, ARN or alias the uploaded object is encrypted with using SSE-KMS"},
+	{"AUDIT_S3_BUCKET", "bucket a JSON record of every applied target change is written to"},
+	{"AUDIT_S3_PREFIX", "key prefix of the audit records (default blackbox-target-audit)"},
+	{"AUDIT_OPERATOR", "operator recorded in the audit records (default the user or host name)"},
+	{"INVENTORY_S3_BUCKET", "bucket to publish the target inventory to"},
+	{"INVENTORY_S3_PREFIX", "key prefix of the published inventory (default blackbox-target-inventory)"},
+	{"INVENTORY_RETENTION_DAYS", "delete inventory versions older than this many days"},
+	{"REGION_NAME_PATTERN", "regular expression with a named region group used to infer a record's region"},
+	{"REGION_LATENCY_BUDGETS", "comma-separated region=duration latency budgets"},
+	{"DEFAULT_LATENCY_BUDGET", "latency budget of regions without an explicit budget"},
+	{"GRPC_PROBE_MODULE", "Blackbox module used to probe private gRPC records"},
+	{"GRPC_HEALTH_SERVICE", "service name sent in gRPC health checks"},
+	{"WEBSOCKET_PROBE_MODULE", "Blackbox module used to probe the WebSocket endpoint of every installation"},
+	{"WEBSOCKET_TARGET_PATTERN", "WebSocket endpoint URL with a {host} placeholder (default https://{host}/api/v4/websocket)"},
+	{"PROBE_PROFILES", "file with named HTTP probe profiles and the targets they apply to"},
+	{"TCP_PROBE_TARGETS", "comma-separated host:port entries probed with the tcp_connect module"},
+	{"CLOUD_MAP_NAMESPACES", "comma-separated AWS Cloud Map namespaces whose service instances are probed with tcp_connect"},
+	{"LOAD_BALANCER_TAG", "probe the listeners of the ALBs and NLBs carrying this tag, given as key=value or key"},
+	{"DATABASE_TAG", "probe the endpoints of the RDS instances and Aurora clusters carrying this tag with tcp_connect"},
+	{"DISCOVER_INGRESSES", "probe the hosts of the Ingresses annotated with blackbox.mattermost.com/probe: \"true\""},
+	{"DISCOVER_SERVICES", "probe the external addresses of the LoadBalancer Services annotated with blackbox.mattermost.com/probe: \"true\""},
+	{"CERTIFICATE_PROBE_MODULE", "TLS verifying Blackbox module probing the DNS names of every cert-manager Certificate"},
+	{"PROVISIONER_URL", "Mattermost Cloud provisioning server whose stable installations are pinged"},
+	{"PREFLIGHT_TIMEOUT", "probe new targets once with this timeout and quarantine the unreachable ones"},
+	{"QUARANTINE_RUNS", "runs an unreachable new target is quarantined before it is added anyway (default 3)"},
+	{"MIN_TARGETS", "refuse to update the secret if fewer targets are discovered"},
+	{"MAX_TARGET_DROP_PERCENT", "refuse to update the secret if the targets drop by more than this percentage since the previous run"},
+	{"FORCE_APPLY", "update the secret even if the targets dropped by more than MAX_TARGET_DROP_PERCENT"},
+	{"FORCE_CONFLICTS", "take over the fields of the secret changed by another field manager instead of failing"},
+	{"METRICS_TEXTFILE", "file the build_info metric is written to"},
+	{"PUSHGATEWAY_URL", "Prometheus Pushgateway the metrics of every run are pushed to"},
+	{"RETRY_MAX_ATTEMPTS", "attempts of Route53 and Kubernetes calls failing with a transient error (default 3)"},
+	{"RETRY_INITIAL_DELAY", "delay before the first retry, doubled for every further retry (default 1s)"},
+	{"RETRY_MAX_DELAY", "maximum delay between retries (default 30s)"},
+	{"AWS_REGION", "AWS region of the Route53, STS and S3 clients (default from the shared config)"},
+	{"AWS_PROFILE", "profile of the shared AWS config and credentials files"},
+	{"AWS_ENDPOINT_URL", "endpoint all AWS requests are sent to, e.g. localstack"},
+	{"ZONE_LIST_WORKERS", "number of hosted zones whose records are listed concurrently (default 4)"},
+	{"ROUTE53_REQUEST_RATE", "maximum number of Route53 requests listing records per second, 0 to disable (default 5)"},
+	{"AWS_TIMEOUT", "timeout of every Route53 and S3 operation including all of its pages, 0 to disable (default 2m)"},
+	{"AWS_ASSUME_ROLE_ARN", "IAM role assumed for Route53 calls, e.g. for hosted zones in another account"},
+	{"AWS_ZONE_ROLE_ARNS", "comma-separated zone=role ARN pairs of hosted zones read with their own role"},
+	{"AWS_ACCOUNT_ROLE_ARNS", "comma-separated account=role ARN pairs of the AWS accounts hosted zones are discovered in"},
+	{"AWS_ASSUME_ROLE_EXTERNAL_ID", "external ID passed when assuming the roles"},
+	{"AWS_ASSUME_ROLE_SESSION_NAME", "session name of the assumed roles (default blackbox-target-discovery)"},
+	{"STATUS_LISTEN_ADDRESS", "address the daemon serves /metrics, /healthz, /readyz and /selftest on, e.g. :8081"},
+	{"KUBE_CONTEXT", "kubeconfig context used in developer mode (default current context)"},
+	{"PRODUCTION_CONTEXT_PATTERN", "regular expression matching production kubeconfig contexts (default prod)"},
+	{"DRY_RUN", "print the changes and diff a run would make without updating the secret"},
+	{"ASSUME_YES", "skip the confirmation before writing to a production context"},
+	{"MAINTENANCE_WINDOWS", "semicolon-separated pattern@start/end maintenance windows"},
+	{"MAINTENANCE_ACTION", "label or remove targets in maintenance (default label)"},
+	{"MAINTENANCE_CONFIGMAP_NAME", "ConfigMap with additional maintenance windows"},
+	{"RUN_INTERVAL", "interval to keep running as a daemon at, e.g. 5m"},
+	{"RUN_SCHEDULE", "cron expression to keep running as a daemon at, e.g. 0 22 * * 1-5"},
+	{"RUN_TIMEZONE", "time zone the run schedule is evaluated in (default UTC)"},
+	{"ORCHESTRATION_CONFIG", "orchestration file describing the hosted zones, filters and secrets of several runs"},
+	{"RECORD", "directory the raw Route53 responses of the run are recorded to"},
+	{"REPLAY", "directory of recorded Route53 responses to replay instead of calling Route53"},
+	{"LOG_FORMAT", "log format, text or json (default text)"},
+	{"LOG_LEVEL", "log level, such as debug, info or warn (default info)"},
+}
+
+// hiddenSettings are settings for testing that are left out of the usage output and completion.
+var hiddenSettings = []setting{
+	{"FAIL_PHASE", "deliberately fail the aws, k8s or render phase to test alerting"},
+}
+
+// booleanSettings are the true/false settings whose flags can be given without
+// a value, e.g. --dry-run instead of --dry-run=true.
+var booleanSettings = map[string]bool{
+	"DEVELOPER_MODE": true,
+	"ASSUME_YES":     true,
+	"DRY_RUN":        true,
+}
+
+// settingValue is the flag value of a setting.
+type settingValue struct {
+	value  string
+	isBool bool
+}
+
+func (v *settingValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.value
+}
+
+func (v *settingValue) Set(value string) error {
+	v.value = value
+	return nil
+}
+
+// IsBoolFlag allows boolean settings to be given as flags without a value.
+func (v *settingValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+// listSeparators are the separators of list settings whose entries are not
+// comma-separated, used to join lists given in a configuration file.
+var listSeparators = map[string]string{
+	"MAINTENANCE_WINDOWS":  ";",
+	"TARGET_URL_RULES":     ";",
+	"PRIVATE_RECORD_RULES": ";",
+	"JOB_RULES":            ";",
+	"MODULE_RULES":         ";",
+	"SECRET_KEY_RULES":     ";",
+	"OUTPUT_SECRETS":       ";",
+}
+
+// hostedZoneSettings are the settings of the keys of the hosted_zones block
+// of a configuration file.
+var hostedZoneSettings = map[string]string{
+	"public":  "PUBLIC_HOSTED_ZONE_ID",
+	"private": "PRIVATE_HOSTED_ZONE_ID",
+}
+
+// settingSources resolves settings from command line flags, falling back to
+// environment variables and then to the configuration file. Flags take
+// precedence over environment variables, which take precedence over the
+// configuration file and the defaults applied during validation.
+type settingSources struct {
+	flags       *flag.FlagSet
+	values      map[string]*string
+	set         map[string]bool
+	file        map[string]string
+	configFile  *string
+	showVersion *bool
+}
+
+// newSettingSources creates a flag for every setting.
+func newSettingSources() *settingSources {
+	sources := &settingSources{
+		flags:  flag.NewFlagSet(os.Args[0], flag.ContinueOnError),
+		values: map[string]*string{},
+		set:    map[string]bool{},
+		file:   map[string]string{},
+	}
+	for _, s := range append(append([]setting{}, settings...), hiddenSettings...) {
+		value := &settingValue{isBool: booleanSettings[s.env]}
+		sources.flags.Var(value, flagName(s.env), fmt.Sprintf("%s (env %s)", s.usage, s.env))
+		sources.values[s.env] = &value.value
+	}
+	sources.configFile = sources.flags.String("config", "", "YAML configuration file with a lower case key for every setting (env CONFIG_FILE)")
+	sources.showVersion = sources.flags.Bool("version", false, "print the build metadata and exit")
+	sources.flags.Usage = sources.usage
+
+	return sources
+}
+
+// parse parses the global flags and returns the remaining arguments, which
+// start with the subcommand, if any.
+func (s *settingSources) parse(args []string) ([]string, error) {
+	err := s.flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	s.flags.Visit(func(f *flag.Flag) {
+		s.set[f.Name] = true
+	})
+
+	configFile := *s.configFile
+	if len(configFile) == 0 {
+		configFile = os.Getenv("CONFIG_FILE")
+	}
+	if len(configFile) > 0 {
+		err = s.loadFile(configFile)
+		if err != nil {
+			fmt.Fprintln(s.flags.Output(), err)
+			return nil, err
+		}
+	}
+
+	return s.flags.Args(), nil
+}
+
+// loadFile reads the settings of a YAML configuration file. Its keys are the
+// lower case environment variables of the settings, e.g. public_hosted_zone_id.
+// List settings can be given as YAML lists. The hosted zones can also be given
+// as public and private lists in a hosted_zones block.
+func (s *settingSources) loadFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read configuration file %s", path)
+	}
+
+	var values map[string]interface{}
+	err = yaml.Unmarshal(data, &values)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse configuration file %s", path)
+	}
+
+	known := map[string]bool{}
+	for _, setting := range append(append([]setting{}, settings...), hiddenSettings...) {
+		known[setting.env] = true
+	}
+
+	var unknown []string
+	for key, value := range values {
+		if key == "hosted_zones" {
+			continue
+		}
+		env := strings.ToUpper(key)
+		if !known[env] {
+			unknown = append(unknown, key)
+			continue
+		}
+		s.file[env], err = fileValue(env, value)
+		if err != nil {
+			return errors.Wrapf(err, "configuration file %s has an invalid %s setting", path, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return errors.Errorf("configuration file %s has unknown settings: %s", path, strings.Join(unknown, ", "))
+	}
+
+	if hostedZones, ok := values["hosted_zones"]; ok {
+		err = s.loadHostedZones(hostedZones)
+		if err != nil {
+			return errors.Wrapf(err, "configuration file %s has an invalid hosted_zones block", path)
+		}
+	}
+
+	return nil
+}
+
+// loadHostedZones reads the public and private hosted zone lists of the
+// hosted_zones block. They cannot be combined with the corresponding
+// public_hosted_zone_id and private_hosted_zone_id keys.
+func (s *settingSources) loadHostedZones(value interface{}) error {
+	block, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return errors.New("expected public and private hosted zone lists")
+	}
+
+	for key, zones := range block {
+		env, ok := hostedZoneSettings[fmt.Sprint(key)]
+		if !ok {
+			return errors.Errorf("unknown key %v, expected public or private", key)
+		}
+		if _, ok := s.file[env]; ok {
+			return errors.Errorf("%s hosted zones are also set by %s", key, strings.ToLower(env))
+		}
+		zoneIDs, err := fileValue(env, zones)
+		if err != nil {
+			return errors.Wrapf(err, "invalid %v hosted zones", key)
+		}
+		s.file[env] = zoneIDs
+	}
+
+	return nil
+}
+
+// fileValue converts a value of the configuration file to the string
+// representation of the setting.
+func fileValue(env string, value interface{}) (string, error) {
+	switch value.(type) {
+	case nil:
+		return "", nil
+	case map[interface{}]interface{}:
+		return "", errors.New("expected a value or a list")
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return fmt.Sprint(value), nil
+	}
+
+	separator, ok := listSeparators[env]
+	if !ok {
+		separator = ","
+	}
+	entries := make([]string, 0, len(list))
+	for _, entry := range list {
+		switch entry.(type) {
+		case map[interface{}]interface{}, []interface{}:
+			return "", errors.New("expected a list of values")
+		}
+		entries = append(entries, fmt.Sprint(entry))
+	}
+
+	return strings.Join(entries, separator), nil
+}
+
+// get returns the value of a setting from its flag if it was given, otherwise
+// from its environment variable or the configuration file.
+func (s *settingSources) get(env string) string {
+	if s.set[flagName(env)] {
+		return *s.values[env]
+	}
+	if value := os.Getenv(env); len(value) > 0 {
+		return value
+	}
+
+	return s.file[env]
+}
+
+func (s *settingSources) usage() {
+	out := s.flags.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] [command]\n\n", s.flags.Name())
+	fmt.Fprintln(out, "Without a command a full discovery runs and the Prometheus secret is updated.")
+	fmt.Fprintln(out, "\nCommands:")
+	for _, command := range commands {
+		fmt.Fprintf(out, "  %-11s %s\n", command.name, command.usage)
+	}
+	fmt.Fprintln(out, "\nEvery flag can also be set with the environment variable shown next to it,")
+	fmt.Fprintln(out, "or in the --config file. Flags take precedence over environment variables,")
+	fmt.Fprintln(out, "which take precedence over the configuration file.\n\nFlags:")
+	hidden := map[string]bool{}
+	for _, setting := range hiddenSettings {
+		hidden[flagName(setting.env)] = true
+	}
+	s.flags.VisitAll(func(f *flag.Flag) {
+		if hidden[f.Name] {
+			return
+		}
+		valueName, usage := flag.UnquoteUsage(f)
+		fmt.Fprintf(out, "  --%s %s\n    \t%s\n", f.Name, valueName, usage)
+	})
+}
+
+// flagName returns the flag name of the setting with the given environment variable.
+func flagName(env string) string {
+	return strings.ReplaceAll(strings.ToLower(env), "_", "-")
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	awsclient "github.com/mattermost/cloud-blackbox-target-discovery/internal/aws"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/discovery"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/fake"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/reconcile"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// snapshotCommand records the records of all hosted zones to a snapshot file
+// that can be used with the simulate command.
+func snapshotCommand(reconciler *reconcile.Reconciler, args []string) error {
+	flags := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	out := flags.String("out", "zones.json", "file to write the snapshot to")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	publicZoneIDs, privateZoneIDs, err := reconciler.HostedZoneIDs()
+	if err != nil {
+		return err
+	}
+	zones := map[string][]*route53types.ResourceRecordSet{}
+	var publicRecords, privateRecords int
+	for _, zoneID := range publicZoneIDs {
+		records, err := reconciler.Clients().Records.ListAllRecordSets(zoneID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to list the records of hosted zone %s", zoneID)
+		}
+		zones[zoneID] = records
+		publicRecords += len(records)
+	}
+	for _, zoneID := range privateZoneIDs {
+		records, err := reconciler.Clients().Records.ListAllRecordSets(zoneID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to list the records of hosted zone %s", zoneID)
+		}
+		zones[zoneID] = records
+		privateRecords += len(records)
+	}
+
+	data, err := json.MarshalIndent(zones, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal snapshot")
+	}
+	err = ioutil.WriteFile(*out, data, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write snapshot to %s", *out)
+	}
+	log.Infof("Snapshot with %d public and %d private records written to %s", publicRecords, privateRecords, *out)
+
+	return nil
+}
+
+// simulateCommand runs the filtering and rendering pipeline against a recorded
+// Route53 snapshot and reports the resulting targets, without contacting AWS
+// or Kubernetes.
+func simulateCommand(sources *settingSources, args []string) error {
+	flags := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	snapshot := flags.String("snapshot", "zones.json", "Route53 snapshot created by the snapshot command")
+	configOut := flags.String("config-out", "", "file to write the rendered scrape config to")
+	asJSON := flags.Bool("json", false, "print the targets as JSON")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	envVars, err := validateAndGetEnvVars(sources)
+	if err != nil {
+		return err
+	}
+
+	if len(envVars.HostedZoneTagKey) > 0 {
+		return errors.New("hosted zones cannot be discovered by tag in a snapshot, set PUBLIC_HOSTED_ZONE_ID and PRIVATE_HOSTED_ZONE_ID instead of HOSTED_ZONE_TAG")
+	}
+
+	zones, err := fake.LoadZones(*snapshot)
+	if err != nil {
+		return err
+	}
+	for _, zoneID := range append(append([]string{}, envVars.PublicHostedZoneIDs...), envVars.PrivateHostedZoneIDs...) {
+		if _, ok := zones[zoneID]; !ok {
+			return errors.Errorf("snapshot %s has no records for hosted zone %s", *snapshot, zoneID)
+		}
+	}
+
+	reconciler := reconcile.New(envVars, &reconcile.Clients{
+		Records: awsclient.NewClientWithAPIs(fake.NewRoute53(zones), nil),
+	})
+	report := reconcile.NewReport()
+	config, blackBoxTargets, err := reconciler.GenerateScrapeConfig(report)
+	if err != nil {
+		return err
+	}
+
+	if len(*configOut) > 0 && config != nil {
+		data, err := config.Marshal()
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(*configOut, data, 0644)
+		if err != nil {
+			return errors.Wrapf(err, "failed to write scrape config to %s", *configOut)
+		}
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(blackBoxTargets, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal targets")
+		}
+		fmt.Fprintln(os.Stdout, string(data))
+		return nil
+	}
+
+	printTargets(blackBoxTargets)
+	fmt.Fprintf(os.Stdout, "\n%d targets from %d public and %d private records\n", report.TargetCount, report.PublicRecords, report.PrivateRecords)
+
+	return nil
+}
+
+// printTargets prints the targets sorted by job and target.
+func printTargets(targets []discovery.Target) {
+	sorted := append([]discovery.Target{}, targets...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Job != sorted[j].Job {
+			return sorted[i].Job < sorted[j].Job
+		}
+		return sorted[i].Target < sorted[j].Target
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "JOB\tTARGET\tMODULE\tSOURCE\tLABELS")
+	for _, target := range sorted {
+		var labels []string
+		for name, value := range target.Labels {
+			labels = append(labels, fmt.Sprintf("%s=%s", name, value))
+		}
+		sort.Strings(labels)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", target.Job, target.Target, target.Module, target.Source, strings.Join(labels, ","))
+	}
+	w.Flush()
+}
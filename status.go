@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/reconcile"
+	"github.com/mattermost/cloud-blackbox-target-discovery/internal/version"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// daemonStatus keeps track of the runs of the daemon for its status endpoints.
+type daemonStatus struct {
+	mu          sync.Mutex
+	runs        map[string]int
+	apiErrors   map[string]int
+	lastReport  *reconcile.Report
+	lastSuccess time.Time
+	lastError   error
+	completed   bool
+}
+
+func newDaemonStatus() *daemonStatus {
+	return &daemonStatus{
+		runs:      map[string]int{"success": 0, "failure": 0},
+		apiErrors: map[string]int{reconcile.APIAWS: 0, reconcile.APIKubernetes: 0},
+	}
+}
+
+// record records the outcome of a run. The report is nil for orchestrated runs.
+func (s *daemonStatus) record(report *reconcile.Report, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.completed = true
+	s.lastError = err
+	if report != nil {
+		s.lastReport = report
+	}
+	if err != nil {
+		s.runs["failure"]++
+		if api := reconcile.FailedAPI(err); len(api) > 0 {
+			s.apiErrors[api]++
+		}
+		return
+	}
+	s.runs["success"]++
+	s.lastSuccess = time.Now()
+}
+
+// metrics returns the metrics of the daemon in the Prometheus text exposition format.
+func (s *daemonStatus) metrics() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var metrics strings.Builder
+	metrics.WriteString("# HELP blackbox_discovery_runs_total Number of completed Blackbox target discovery runs by result.\n")
+	metrics.WriteString("# TYPE blackbox_discovery_runs_total counter\n")
+	for _, result := range []string{"success", "failure"} {
+		fmt.Fprintf(&metrics, "blackbox_discovery_runs_total{result=%q} %d\n", result, s.runs[result])
+	}
+	metrics.WriteString("# HELP blackbox_discovery_api_errors_total Number of runs that failed because of an AWS or Kubernetes API error.\n")
+	metrics.WriteString("# TYPE blackbox_discovery_api_errors_total counter\n")
+	for _, api := range []string{reconcile.APIAWS, reconcile.APIKubernetes} {
+		fmt.Fprintf(&metrics, "blackbox_discovery_api_errors_total{api=%q} %d\n", api, s.apiErrors[api])
+	}
+	if !s.lastSuccess.IsZero() {
+		metrics.WriteString("# HELP blackbox_discovery_last_success_timestamp Unix time the last successful run finished.\n")
+		metrics.WriteString("# TYPE blackbox_discovery_last_success_timestamp gauge\n")
+		fmt.Fprintf(&metrics, "blackbox_discovery_last_success_timestamp %d\n", s.lastSuccess.Unix())
+	}
+	if s.lastReport != nil {
+		metrics.WriteString(reconcile.RunMetrics(s.lastReport))
+	} else {
+		metrics.WriteString(version.Get().BuildInfoMetric())
+	}
+
+	return metrics.String()
+}
+
+// ready returns nil once a run completed and the last run succeeded.
+func (s *daemonStatus) ready() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.completed {
+		return errors.New("no run has completed yet")
+	}
+
+	return s.lastError
+}
+
+// serveStatus serves the daemon metrics at /metrics, a liveness probe at
+// /healthz, a readiness probe at /readyz and the self-test at /selftest. It
+// returns the server, so it can be shut down when the daemon stops.
+func serveStatus(address string, status *daemonStatus, reconciler *reconcile.Reconciler) (*http.Server, error) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		fmt.Fprint(w, status.metrics())
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, req *http.Request) {
+		err := status.ready()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	if reconciler != nil {
+		mux.Handle("/selftest", reconciler.SelfTestHandler())
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to listen on %s", address)
+	}
+	server := &http.Server{Handler: mux}
+	go func() {
+		log.Infof("Serving the daemon status at http://%s", listener.Addr())
+		err := server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			log.WithError(err).Error("Failed to serve the daemon status")
+		}
+	}()
+
+	return server, nil
+}
+
+// startStatusServer starts the status server if a listen address is
+// configured. Failing to start it is logged but doesn't stop the daemon.
+func startStatusServer(envVars *reconcile.Config, status *daemonStatus, reconciler *reconcile.Reconciler) *http.Server {
+	if len(envVars.StatusListenAddress) == 0 {
+		return nil
+	}
+
+	server, err := serveStatus(envVars.StatusListenAddress, status, reconciler)
+	if err != nil {
+		log.WithError(err).Error("Failed to start the daemon status server")
+		return nil
+	}
+
+	return server
+}
+
+// stopStatusServer shuts the status server down, if it was started.
+func stopStatusServer(server *http.Server) {
+	if server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := server.Shutdown(ctx)
+	if err != nil {
+		log.WithError(err).Warn("Failed to shut down the daemon status server")
+	}
+}